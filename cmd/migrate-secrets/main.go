@@ -0,0 +1,67 @@
+// migrate-secrets 将存量客户数据中的明文API密钥替换为哈希，并使用当前版本的加密密钥
+// 加密（或重新加密）签名密钥。轮换加密密钥后再次执行即可完成重新加密。
+//
+// 用法：CONFIG_PATH=config.yaml GATEWAY_ENCRYPTION_KEYS=1:<base64> ./migrate-secrets
+package main
+
+import (
+	"api-gateway/config"
+	"api-gateway/database"
+	"api-gateway/pkg/logger"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	logger.Init("api-gateway-migrate-secrets")
+
+	// 失败时以非零状态退出，数据库连接已在 run 返回前关闭
+	if err := run(); err != nil {
+		logger.Errorf("Secret migration failed: %v", err)
+		logger.Close()
+		os.Exit(1)
+	}
+	logger.Close()
+}
+
+func run() error {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dbManager, err := database.NewDatabaseManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	defer func() {
+		if err := dbManager.Close(context.Background()); err != nil {
+			logger.Errorf("Error closing database: %v", err)
+		}
+	}()
+
+	if dbManager.Keyring == nil {
+		return fmt.Errorf("no encryption keys configured, refusing to migrate secrets")
+	}
+
+	clients, err := dbManager.ClientRepo.MigrateSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to migrate clients after %d documents: %w", clients, err)
+	}
+	logger.Infof("Migrated %d clients", clients)
+
+	credentials, err := dbManager.CredentialRepo.MigrateSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to migrate credentials after %d documents: %w", credentials, err)
+	}
+	logger.Infof("Migrated %d credentials", credentials)
+
+	logger.Infof("Secret migration completed with key version %d", dbManager.Keyring.CurrentVersion())
+	return nil
+}
@@ -26,6 +26,35 @@ type TargetConfig struct {
 type AuthConfig struct {
 	EnableSignature     bool `yaml:"enable_signature"`
 	SignatureTimeWindow int  `yaml:"signature_time_window"` // 时间窗口（秒）
+	AllowMissingNonce   bool `yaml:"allow_missing_nonce"`   // 兼容未发送 X-Nonce 的旧客户端，默认要求 X-Nonce 防重放
+	MinSignatureVersion int  `yaml:"min_signature_version"` // 允许的最低签名版本，默认1（v1和v2并行）
+	// 密钥轮换后旧密钥的保留时间（秒），默认24小时
+	KeyRotationGracePeriod int              `yaml:"key_rotation_grace_period"`
+	JWT                    JWTConfig        `yaml:"jwt"`         // Bearer JWT 认证配置
+	BruteForce             BruteForceConfig `yaml:"brute_force"` // 认证失败防护配置
+}
+
+// BruteForceConfig 认证失败防护配置
+// 同一IP或同一API密钥在窗口内失败次数达到阈值后临时封禁，重复封禁时长加倍
+type BruteForceConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	MaxFailures    int  `yaml:"max_failures"`     // 窗口内允许的失败次数，默认10
+	Window         int  `yaml:"window"`           // 失败计数窗口（秒），默认60
+	BanDuration    int  `yaml:"ban_duration"`     // 首次封禁时长（秒），默认60
+	MaxBanDuration int  `yaml:"max_ban_duration"` // 最长封禁时长（秒），默认86400
+	StrikeTTL      int  `yaml:"strike_ttl"`       // 封禁次数保留时间（秒），超过后封禁时长重新计算，默认86400
+}
+
+// JWTConfig Bearer JWT 认证配置
+// HS256 使用客户签名密钥验证，RS256/ES256 使用 JWKS 文件中的公钥验证
+type JWTConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Audience    string   `yaml:"audience"`     // 期望的 aud，为空时不校验
+	Issuer      string   `yaml:"issuer"`       // 期望的 iss，为空时不校验
+	ClockSkew   int      `yaml:"clock_skew"`   // 允许的时钟偏差（秒），默认60
+	JWKSFile    string   `yaml:"jwks_file"`    // JWKS 文件路径
+	ClientClaim string   `yaml:"client_claim"` // 映射到客户的声明（客户ID或API密钥），默认 sub
+	Algorithms  []string `yaml:"algorithms"`   // 允许的签名算法，默认 HS256/RS256/ES256
 }
 
 type AsyncConfig struct {
@@ -41,6 +70,143 @@ type RedisConfig struct {
 	QueueKey string `yaml:"queue_key"`
 }
 
+// OAuthConfig OAuth2 客户端凭证模式配置
+type OAuthConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	Issuer     string   `yaml:"issuer"`      // 令牌签发方，默认 api-gateway
+	SigningKey string   `yaml:"signing_key"` // HS256 签名密钥，可通过环境变量 OAUTH_SIGNING_KEY 覆盖
+	TokenTTL   int      `yaml:"token_ttl"`   // 令牌有效期（秒），默认3600
+	Scopes     []string `yaml:"scopes"`      // 支持的 scope，默认 ["api"]；api 覆盖客户被授权的全部路由，其余为产品名或路由模式
+}
+
+// AccessConfig 客户路由授权配置
+type AccessConfig struct {
+	Products     map[string][]string `yaml:"products"`      // 产品名 -> 路由模式，如 math: ["/api/math/*"]
+	DefaultDeny  bool                `yaml:"default_deny"`  // 未配置 scope 的客户是否禁止访问所有路由
+	ExemptRoutes []string            `yaml:"exempt_routes"` // 不做授权检查的路由，默认 ["/api/tasks*"]
+}
+
+// AdminConfig 管理接口认证配置
+type AdminConfig struct {
+	Bootstrap []AdminBootstrapUser `yaml:"bootstrap"` // 启动时创建的初始管理员，已存在时不覆盖
+}
+
+// AdminBootstrapUser 初始管理员账号，密码和令牌至少配置一项
+type AdminBootstrapUser struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+	Role     string `yaml:"role"` // 默认 superadmin
+}
+
+// ExpiryConfig 客户合同到期检查配置
+// 到期的客户自动禁用，到期前发送提醒事件
+type ExpiryConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	CheckInterval int    `yaml:"check_interval"` // 检查间隔（秒），默认3600
+	NotifyDays    int    `yaml:"notify_days"`    // 到期前多少天发送提醒，默认7
+	WebhookURL    string `yaml:"webhook_url"`    // 事件回调地址，为空时只记录日志
+	WebhookSecret string `yaml:"webhook_secret"` // 非空时使用 HMAC-SHA256 签名请求体，放在 X-Webhook-Signature 头
+}
+
+// RateLimitConfig 限流配置
+// 使用 Redis 时多个网关实例共享限流状态，Redis 不可用时按 fail_mode 处理
+type RateLimitConfig struct {
+	Backend       string `yaml:"backend"`        // local 或 redis，默认 local；redis 需配置共享 Redis
+	Algorithm     string `yaml:"algorithm"`      // gcra 或 sliding_window，默认 gcra
+	FailMode      string `yaml:"fail_mode"`      // Redis 不可用时：local（使用本地令牌桶，默认）、open（放行）、closed（拒绝）
+	RetryInterval int    `yaml:"retry_interval"` // Redis 出错后多久重试（秒），默认5
+	KeyPrefix     string `yaml:"key_prefix"`     // Redis 键前缀，默认 gw:ratelimit:
+	// 多维度限流规则，与客户 QPS 限制一起检查，任一规则超限即拒绝
+	Rules []RateLimitRule `yaml:"rules"`
+	// 路由权重，开销大的路由每次请求消耗多个令牌，按顺序匹配第一个
+	RouteCosts []RouteCost `yaml:"route_costs"`
+}
+
+// RouteCost 路由的请求权重
+type RouteCost struct {
+	Route string `yaml:"route"` // 路由模式，如 /api/essay/*
+	Cost  int    `yaml:"cost"`  // 每次请求消耗的令牌数
+}
+
+// RateLimitRule 限流规则，客户可通过 rate_limits 单独覆盖 client 和 client_route 维度的规则
+type RateLimitRule struct {
+	Name   string   `yaml:"name"`
+	Scope  string   `yaml:"scope"`  // client、client_route、ip、version、upstream
+	Routes []string `yaml:"routes"` // 适用的路由模式，如 /api/sts/*，为空时适用所有路由
+	Limit  int      `yaml:"limit"`  // 窗口内允许的请求数
+	Window string   `yaml:"window"` // second、minute、hour，默认 second
+	Burst  int      `yaml:"burst"`  // 允许的突发请求数，默认等于 limit
+	Mode   string   `yaml:"mode"`   // enforce、shadow（超限只记录不拒绝）、off，默认 enforce
+}
+
+// QuotaConfig 按日、按月的调用配额配置
+type QuotaConfig struct {
+	Timezone  string      `yaml:"timezone"`   // 配额窗口使用的时区，如 Asia/Shanghai，默认 UTC
+	ResetTime string      `yaml:"reset_time"` // 每日重置时间 HH:MM，默认 00:00；月配额在每月1日的该时间重置
+	Quotas    []QuotaRule `yaml:"quotas"`     // 适用于所有客户的默认配额
+}
+
+// QuotaRule 默认配额
+type QuotaRule struct {
+	Name   string   `yaml:"name"`
+	Period string   `yaml:"period"` // day 或 month
+	Limit  int      `yaml:"limit"`
+	Routes []string `yaml:"routes"` // 适用的路由模式，为空时适用所有路由
+	Mode   string   `yaml:"mode"`   // enforce、shadow（超限只记录不拒绝）、off，默认 enforce
+}
+
+// PricingConfig 按路由计费配置，未匹配的调用扣减1次
+// 调用前从余额中预扣，成功时确认，失败时退回；超时未确认的预扣由清理任务退回
+type PricingConfig struct {
+	Prices         []RoutePrice `yaml:"prices"`          // 按顺序匹配第一个，具体的价格应放在通用的价格之前
+	ReservationTTL int          `yaml:"reservation_ttl"` // 预扣有效期（秒），应大于最长的调用时间，超时后才成功的调用会重新扣费，默认600
+	SweepInterval  int          `yaml:"sweep_interval"`  // 清理超时预扣的间隔（秒），默认60
+}
+
+// RoutePrice 路由价格
+type RoutePrice struct {
+	Route   string `yaml:"route"`   // 路由模式，如 /api/ocr/*
+	Version string `yaml:"version"` // 适用的API版本，为空时适用所有版本
+	Plan    string `yaml:"plan"`    // 适用的客户套餐，为空时适用所有套餐
+	Cost    int    `yaml:"cost"`    // 每次成功调用扣减的次数
+}
+
+// ConcurrencyConfig 上游自适应并发限制配置
+// 按上游服务分别限制并发数，同步代理和异步任务共用限制；上游延迟升高或超时时减小限制，
+// 超出限制的同步请求返回 503，异步任务等待许可
+type ConcurrencyConfig struct {
+	Enabled      bool    `yaml:"enabled"`
+	InitialLimit int     `yaml:"initial_limit"` // 初始并发限制，默认20
+	MinLimit     int     `yaml:"min_limit"`     // 最小并发限制，默认1
+	MaxLimit     int     `yaml:"max_limit"`     // 最大并发限制，默认200
+	Tolerance    float64 `yaml:"tolerance"`     // 近期延迟超过基线的倍数时减小限制，默认2
+	BackoffRatio float64 `yaml:"backoff_ratio"` // 每次减小时乘以的系数，默认0.9
+	RetryAfter   int     `yaml:"retry_after"`   // 被拒绝请求的 Retry-After（秒），默认1
+}
+
+// CORSConfig 浏览器跨域访问配置
+// 全局策略用于预检请求和跨域响应头，客户可单独配置允许的来源、方法和请求头；
+// 配置了允许来源的客户为浏览器端密钥，只接受来自这些来源的浏览器请求
+type CORSConfig struct {
+	Enabled          bool     `yaml:"enabled"`
+	AllowedOrigins   []string `yaml:"allowed_origins"`   // 全局允许的来源，为空时只允许客户单独配置的来源
+	AllowedMethods   []string `yaml:"allowed_methods"`   // 默认 GET、POST
+	AllowedHeaders   []string `yaml:"allowed_headers"`   // 默认包含签名、异步回调相关请求头
+	ExposedHeaders   []string `yaml:"exposed_headers"`   // 默认 X-Request-ID、Retry-After、RateLimit-* 和 X-Remaining-Calls
+	AllowCredentials bool     `yaml:"allow_credentials"` // 是否允许携带 Cookie，不能与来源 * 同时使用
+	MaxAge           int      `yaml:"max_age"`           // 预检结果缓存时间（秒），默认600
+	PolicyCacheTTL   int      `yaml:"policy_cache_ttl"`  // 客户跨域策略的缓存时间（秒），默认30
+}
+
+// EncryptionConfig 签名密钥加密配置
+// 密钥文件和环境变量均未配置时，签名密钥以明文存储
+type EncryptionConfig struct {
+	KeysFile       string `yaml:"keys_file"`       // 密钥文件路径
+	KeysEnv        string `yaml:"keys_env"`        // 密钥环境变量名，默认 GATEWAY_ENCRYPTION_KEYS
+	CurrentVersion int    `yaml:"current_version"` // 当前加密使用的密钥版本，0表示使用最大版本
+}
+
 // SignatureConfig 签名配置
 type SignatureConfig struct {
 	Type   string            `yaml:"type"`
@@ -54,12 +220,25 @@ type PathSignatureMapping struct {
 }
 
 type Config struct {
-	Port           int                     `yaml:"port"`
-	Database       DatabaseConfig          `yaml:"database"`
-	Auth           AuthConfig              `yaml:"auth"`
-	Async          AsyncConfig             `yaml:"async"` // 异步任务配置
-	Targets        map[string]TargetConfig `yaml:"targets"`
-	PathSignatures []PathSignatureMapping  `yaml:"path_signatures"`
+	Port            int                     `yaml:"port"`
+	TrustedProxies  []string                `yaml:"trusted_proxies"`   // 可信代理 IP/CIDR，为空时不信任任何转发头
+	RemoteIPHeaders []string                `yaml:"remote_ip_headers"` // 从可信代理读取客户端 IP 的请求头，默认 X-Forwarded-For、X-Real-IP
+	Database        DatabaseConfig          `yaml:"database"`
+	Auth            AuthConfig              `yaml:"auth"`
+	Async           AsyncConfig             `yaml:"async"`       // 异步任务配置
+	Encryption      EncryptionConfig        `yaml:"encryption"`  // 签名密钥加密配置
+	Redis           RedisConfig             `yaml:"redis"`       // 共享 Redis（令牌吊销、nonce 等），未配置时使用进程内存储
+	OAuth           OAuthConfig             `yaml:"oauth"`       // OAuth2 令牌端点配置
+	Access          AccessConfig            `yaml:"access"`      // 客户路由授权配置
+	Admin           AdminConfig             `yaml:"admin"`       // 管理接口认证配置
+	Expiry          ExpiryConfig            `yaml:"expiry"`      // 客户合同到期检查配置
+	CORS            CORSConfig              `yaml:"cors"`        // 浏览器跨域访问配置
+	RateLimit       RateLimitConfig         `yaml:"rate_limit"`  // 限流配置
+	Quota           QuotaConfig             `yaml:"quota"`       // 调用配额配置
+	Concurrency     ConcurrencyConfig       `yaml:"concurrency"` // 上游自适应并发限制配置
+	Pricing         PricingConfig           `yaml:"pricing"`     // 按路由计费配置
+	Targets         map[string]TargetConfig `yaml:"targets"`
+	PathSignatures  []PathSignatureMapping  `yaml:"path_signatures"`
 }
 
 func NewConfig() (*Config, error) {
@@ -6,20 +6,41 @@ import (
 
 	"api-gateway/config"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/secrets"
 	"api-gateway/repository"
 	"api-gateway/service"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // DatabaseManager manages database connections and repositories
 type DatabaseManager struct {
-	MongoDB       *MongoDB
-	ClientRepo    repository.ClientRepository
-	CallLogRepo   repository.CallLogRepository
-	ClientService *service.ClientService
+	MongoDB         *MongoDB
+	ClientRepo      repository.ClientRepository
+	CallLogRepo     repository.CallLogRepository
+	CredentialRepo  repository.CredentialRepository
+	AdminUserRepo   repository.AdminUserRepository
+	AuditLogRepo    repository.AuditLogRepository
+	QuotaRepo       repository.QuotaRepository // 调用配额计数，配置了 Redis 时存储在 Redis
+	ReservationRepo repository.ReservationRepository
+	ClientService   *service.ClientService
+	Keyring         *secrets.Keyring // 签名密钥加密密钥环，未配置时为nil
+	Redis           *redis.Client    // 共享 Redis 连接，未配置时为nil
 }
 
 // NewDatabaseManager creates a new database manager with all repositories
 func NewDatabaseManager(cfg *config.Config) (*DatabaseManager, error) {
+	// Load encryption keys for client secrets
+	keyring, err := secrets.LoadKeyring(cfg.Encryption.KeysFile, cfg.Encryption.KeysEnv, cfg.Encryption.CurrentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+	if keyring == nil {
+		logger.Info("No encryption keys configured, client secrets will be stored in plaintext")
+	} else {
+		logger.Infof("Encryption keys loaded, current key version %d", keyring.CurrentVersion())
+	}
+
 	// Connect to MongoDB
 	logger.Infof("Connecting to MongoDB: %s/%s", cfg.Database.URL, cfg.Database.DB)
 	mongoDB, err := NewMongoDB(cfg.Database.URL, cfg.Database.DB)
@@ -28,24 +49,60 @@ func NewDatabaseManager(cfg *config.Config) (*DatabaseManager, error) {
 	}
 	logger.Info("MongoDB connection established successfully")
 
+	// Connect to shared Redis if configured
+	var redisClient *redis.Client
+	if cfg.Redis.Addr != "" {
+		redisClient, err = NewRedisClient(cfg.Redis)
+		if err != nil {
+			mongoDB.Close(context.Background())
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Redis connection established successfully")
+	}
+
 	// Create repositories
-	clientRepo := repository.NewClientMongoRepository(mongoDB.GetCollection("gw_clients"))
+	clientRepo := repository.NewClientMongoRepository(mongoDB.GetCollection("gw_clients"), keyring)
 	callLogRepo := repository.NewCallLogMongoRepository(mongoDB.GetCollection("gw_call_logs"))
+	credentialRepo := repository.NewCredentialMongoRepository(mongoDB.GetCollection("gw_client_credentials"), keyring)
+	adminUserRepo := repository.NewAdminUserMongoRepository(mongoDB.GetCollection("gw_admin_users"))
+	auditLogRepo := repository.NewAuditLogMongoRepository(mongoDB.GetCollection("gw_audit_logs"))
+
+	var quotaRepo repository.QuotaRepository
+	if redisClient != nil {
+		quotaRepo = repository.NewRedisQuotaRepository(redisClient)
+	} else {
+		quotaRepo = repository.NewQuotaMongoRepository(mongoDB.GetCollection("gw_quota_counters"))
+	}
+
+	reservationRepo := repository.NewReservationMongoRepository(mongoDB.GetCollection("gw_call_reservations"))
 
 	// Create services
 	clientService := service.NewClientService(clientRepo, callLogRepo)
 
 	return &DatabaseManager{
-		MongoDB:       mongoDB,
-		ClientRepo:    clientRepo,
-		CallLogRepo:   callLogRepo,
-		ClientService: clientService,
+		MongoDB:         mongoDB,
+		ClientRepo:      clientRepo,
+		CallLogRepo:     callLogRepo,
+		CredentialRepo:  credentialRepo,
+		AdminUserRepo:   adminUserRepo,
+		AuditLogRepo:    auditLogRepo,
+		QuotaRepo:       quotaRepo,
+		ReservationRepo: reservationRepo,
+		ClientService:   clientService,
+		Keyring:         keyring,
+		Redis:           redisClient,
 	}, nil
 }
 
 // Close closes all database connections
 func (dm *DatabaseManager) Close(ctx context.Context) error {
 	logger.Info("Closing database connections...")
+	if dm.Redis != nil {
+		if err := dm.Redis.Close(); err != nil {
+			logger.Errorf("Error closing Redis connection: %v", err)
+		}
+	}
+
 	err := dm.MongoDB.Close(ctx)
 	if err != nil {
 		logger.Errorf("Error closing MongoDB connection: %v", err)
@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,16 +12,32 @@ const (
 	// Authentication related errors
 	ErrInvalidAPIKey  = 40001 // API密钥无效
 	ErrClientDisabled = 40002 // 客户已禁用
+	ErrClientExpired  = 40005 // 客户合同已到期
+	ErrClientNotValid = 40006 // 客户合同尚未开始
 
 	// Billing related errors
 	ErrInsufficientCalls = 40301 // 调用次数不足
 	ErrCallLimitExceeded = 42901 // 调用频率超限
 	ErrRateLimitExceeded = 42902 // QPS限流超限
+	ErrAuthBanned        = 42903 // 认证失败次数过多，临时封禁
+	ErrQuotaExceeded     = 42904 // 调用配额已用完
+
+	// Access control errors
+	ErrIPNotAllowed     = 40302 // 客户端IP不允许访问
+	ErrRouteNotAllowed  = 40303 // 未授权访问该接口
+	ErrOriginNotAllowed = 40304 // 请求来源不允许访问
+	ErrScopeNotGranted  = 40305 // 访问令牌的 scope 不包含该接口
 
 	// Proxy related errors
 	ErrUpstreamTimeout = 50401 // 上游服务超时
 	ErrUpstreamError   = 50402 // 上游服务错误
 
+	// Upstream overload errors
+	ErrUpstreamOverloaded = 50302 // 上游服务繁忙，超过自适应并发限制
+
+	// Rate limit related errors
+	ErrRateLimitUnavailable = 50301 // 限流服务不可用
+
 	// Version related errors
 	ErrUnsupportedVersion = 40004 // 不支持的版本
 )
@@ -61,14 +78,65 @@ func NewClientDisabledError(clientID string) *APIError {
 	})
 }
 
+func NewClientExpiredError(clientID string, validUntil time.Time) *APIError {
+	return NewAPIError(ErrClientExpired, "客户合同已到期", gin.H{
+		"client_id":   clientID,
+		"valid_until": validUntil,
+	})
+}
+
+func NewClientNotYetValidError(clientID string, validFrom time.Time) *APIError {
+	return NewAPIError(ErrClientNotValid, "客户合同尚未开始", gin.H{
+		"client_id":  clientID,
+		"valid_from": validFrom,
+	})
+}
+
+func NewAuthBannedError(scope string, retryAfter int) *APIError {
+	return NewAPIError(ErrAuthBanned, "认证失败次数过多，请稍后重试", gin.H{
+		"scope":       scope,
+		"retry_after": retryAfter,
+	})
+}
+
 // Billing errors
-func NewInsufficientCallsError(remainingCalls int, clientID string) *APIError {
+func NewInsufficientCallsError(remainingCalls, cost int, clientID string) *APIError {
 	return NewAPIError(ErrInsufficientCalls, "调用次数不足，请充值", gin.H{
 		"remaining_calls": remainingCalls,
+		"cost":            cost,
 		"client_id":       clientID,
 	})
 }
 
+// Access control errors
+func NewIPNotAllowedError(ip, clientID string) *APIError {
+	return NewAPIError(ErrIPNotAllowed, "客户端IP不允许访问", gin.H{
+		"client_ip": ip,
+		"client_id": clientID,
+	})
+}
+
+func NewRouteNotAllowedError(path, clientID string) *APIError {
+	return NewAPIError(ErrRouteNotAllowed, "未授权访问该接口", gin.H{
+		"path":      path,
+		"client_id": clientID,
+	})
+}
+
+func NewScopeNotGrantedError(path, scope string) *APIError {
+	return NewAPIError(ErrScopeNotGranted, "访问令牌未授权访问该接口", gin.H{
+		"path":  path,
+		"scope": scope,
+	})
+}
+
+func NewOriginNotAllowedError(origin, clientID string) *APIError {
+	return NewAPIError(ErrOriginNotAllowed, "请求来源不允许访问", gin.H{
+		"origin":    origin,
+		"client_id": clientID,
+	})
+}
+
 // Version errors
 func NewUnsupportedVersionError(version string) *APIError {
 	return NewAPIError(ErrUnsupportedVersion, "不支持的版本", gin.H{
@@ -87,7 +155,39 @@ func NewUpstreamError(message string) *APIError {
 	})
 }
 
+func NewUpstreamOverloadedError(upstream string, limit, retryAfter int) *APIError {
+	return NewAPIError(ErrUpstreamOverloaded, "上游服务繁忙，请稍后重试", gin.H{
+		"upstream":    upstream,
+		"limit":       limit,
+		"retry_after": retryAfter,
+	})
+}
+
 // Rate limit errors
+func NewRateLimitRuleExceededError(clientID, rule string, limit int, window string, retryAfter int) *APIError {
+	return NewAPIError(ErrRateLimitExceeded, "请求频率超限，请稍后重试", gin.H{
+		"client_id":   clientID,
+		"rule":        rule,
+		"limit":       limit,
+		"window":      window,
+		"retry_after": retryAfter,
+	})
+}
+
+func NewQuotaExceededError(clientID, quota string, limit int, period string, resetAt string) *APIError {
+	return NewAPIError(ErrQuotaExceeded, "调用配额已用完，请在配额重置后重试", gin.H{
+		"client_id": clientID,
+		"quota":     quota,
+		"limit":     limit,
+		"period":    period,
+		"reset_at":  resetAt,
+	})
+}
+
+func NewRateLimitUnavailableError() *APIError {
+	return NewAPIError(ErrRateLimitUnavailable, "限流服务暂不可用，请稍后重试", nil)
+}
+
 func NewRateLimitExceededError(clientID string, qps int) *APIError {
 	return NewAPIError(ErrRateLimitExceeded, "请求频率超限，请稍后重试", gin.H{
 		"client_id": clientID,
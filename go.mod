@@ -9,6 +9,7 @@ require (
 	github.com/redis/go-redis/v9 v9.16.0
 	github.com/zeromicro/go-zero v1.7.6
 	go.mongodb.org/mongo-driver v1.17.4
+	golang.org/x/crypto v0.41.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -55,7 +56,6 @@ require (
 	go.uber.org/automaxprocs v1.6.0 // indirect
 	go.yaml.in/yaml/v2 v2.4.2 // indirect
 	golang.org/x/arch v0.12.0 // indirect
-	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
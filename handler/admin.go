@@ -5,6 +5,7 @@ import (
 	"api-gateway/service"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,13 +13,20 @@ import (
 
 // AdminHandler handles admin operations
 type AdminHandler struct {
-	clientService service.ClientServiceInterface
+	clientService     service.ClientServiceInterface
+	credentialService service.CredentialServiceInterface
+	scopeService      service.ScopeServiceInterface
+	auditService      service.AuditServiceInterface
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(clientService service.ClientServiceInterface) *AdminHandler {
+func NewAdminHandler(clientService service.ClientServiceInterface, credentialService service.CredentialServiceInterface,
+	scopeService service.ScopeServiceInterface, auditService service.AuditServiceInterface) *AdminHandler {
 	return &AdminHandler{
-		clientService: clientService,
+		clientService:     clientService,
+		credentialService: credentialService,
+		scopeService:      scopeService,
+		auditService:      auditService,
 	}
 }
 
@@ -28,6 +36,11 @@ type CreateClientRequest struct {
 	Version          string `json:"version" binding:"required"`
 	InitialCallCount int    `json:"initial_call_count" binding:"min=0"`
 	QPS              int    `json:"qps" binding:"min=1"`
+	Burst            int    `json:"burst" binding:"min=0"` // 允许的突发请求数，为0时等于 QPS
+	Plan             string `json:"plan" binding:"max=64"` // 计费套餐，可选
+
+	ValidFrom  *time.Time `json:"valid_from"`  // 合同开始时间，可选
+	ValidUntil *time.Time `json:"valid_until"` // 合同结束时间，可选
 }
 
 // CreateClientResponse represents the response after creating a client
@@ -39,8 +52,13 @@ type CreateClientResponse struct {
 	Version          string `json:"version"`
 	InitialCallCount int    `json:"initial_call_count"`
 	QPS              int    `json:"qps"`
+	Burst            int    `json:"burst,omitempty"`
+	Plan             string `json:"plan,omitempty"`
 	Status           int    `json:"status"`
 	CreatedAt        string `json:"created_at"`
+
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
 }
 
 // RechargeRequest represents the request to recharge a client
@@ -55,7 +73,13 @@ type UpdateStatusRequest struct {
 
 // UpdateQPSRequest represents the request to update client QPS
 type UpdateQPSRequest struct {
-	QPS int `json:"qps" binding:"required,min=1,max=1000"`
+	QPS   int `json:"qps" binding:"required,min=1,max=1000"`
+	Burst int `json:"burst" binding:"min=0,max=10000"` // 允许的突发请求数，为0时等于 QPS
+}
+
+// UpdatePlanRequest represents the request to update a client's billing plan
+type UpdatePlanRequest struct {
+	Plan string `json:"plan" binding:"max=64"` // 为空表示不使用套餐价格
 }
 
 // StatsResponse represents basic statistics
@@ -78,6 +102,15 @@ func (h *AdminHandler) CreateClient(c *gin.Context) {
 		return
 	}
 
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidFrom.Before(*req.ValidUntil) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request parameters",
+			Error:   "valid_from must be before valid_until",
+		})
+		return
+	}
+
 	client, err := h.clientService.CreateClient(c.Request.Context(), req.Name, req.Version, req.InitialCallCount)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -88,9 +121,13 @@ func (h *AdminHandler) CreateClient(c *gin.Context) {
 		return
 	}
 
-	// 如果请求中指定了QPS，则更新客户的QPS设置
-	if req.QPS > 0 {
-		err = h.clientService.UpdateClientQPS(c.Request.Context(), client.ID, req.QPS)
+	// 如果请求中指定了QPS或突发请求数，则更新客户的QPS设置
+	if req.QPS > 0 || req.Burst > 0 {
+		qps := req.QPS
+		if qps == 0 {
+			qps = client.QPS
+		}
+		err = h.clientService.UpdateClientQPS(c.Request.Context(), client.ID, qps, req.Burst)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Code:    50001,
@@ -99,9 +136,48 @@ func (h *AdminHandler) CreateClient(c *gin.Context) {
 			})
 			return
 		}
-		client.QPS = req.QPS
+		client.QPS = qps
+		client.Burst = req.Burst
+	}
+
+	// 设置计费套餐
+	if req.Plan != "" {
+		if err := h.clientService.UpdateClientPlan(c.Request.Context(), client.ID, req.Plan); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50001,
+				Message: "Failed to set client plan",
+				Error:   err.Error(),
+			})
+			return
+		}
+		client.Plan = req.Plan
+	}
+
+	// 设置合同有效期
+	if req.ValidFrom != nil || req.ValidUntil != nil {
+		client.ValidFrom = req.ValidFrom
+		client.ValidUntil = req.ValidUntil
+		if _, err := h.clientService.UpdateClientValidity(c.Request.Context(), client.ID, req.ValidFrom, req.ValidUntil, false); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50001,
+				Message: "Failed to set client validity",
+				Error:   err.Error(),
+			})
+			return
+		}
 	}
 
+	h.recordClientAudit(c, model.AuditActionClientCreate, client.ID, "", nil, gin.H{
+		"name":        client.Name,
+		"version":     client.Version,
+		"call_count":  client.CallCount,
+		"qps":         client.QPS,
+		"burst":       client.Burst,
+		"plan":        client.Plan,
+		"valid_from":  client.ValidFrom,
+		"valid_until": client.ValidUntil,
+	})
+
 	response := CreateClientResponse{
 		ID:               client.ID.Hex(),
 		Name:             client.Name,
@@ -110,8 +186,12 @@ func (h *AdminHandler) CreateClient(c *gin.Context) {
 		Version:          client.Version,
 		InitialCallCount: client.CallCount,
 		QPS:              client.QPS,
+		Burst:            client.Burst,
+		Plan:             client.Plan,
 		Status:           client.Status,
 		CreatedAt:        client.CreatedAt.Format("2006-01-02 15:04:05"),
+		ValidFrom:        client.ValidFrom,
+		ValidUntil:       client.ValidUntil,
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -197,6 +277,8 @@ func (h *AdminHandler) RechargeClient(c *gin.Context) {
 		return
 	}
 
+	before := h.clientSnapshot(c, id)
+
 	err = h.clientService.RechargeClient(c.Request.Context(), id, req.CallCount)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -208,7 +290,15 @@ func (h *AdminHandler) RechargeClient(c *gin.Context) {
 	}
 
 	// Return updated client information
+	// 充值已生效，读取失败时审计日志只记录充值数量
 	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	after := gin.H{"recharge": req.CallCount}
+	if err == nil {
+		after["call_count"] = client.CallCount
+		after["total_count"] = client.TotalCount
+	}
+	h.recordClientAudit(c, model.AuditActionClientRecharge, id, "", balanceValues(before), after)
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Code:    50004,
@@ -247,6 +337,8 @@ func (h *AdminHandler) UpdateClientStatus(c *gin.Context) {
 		return
 	}
 
+	before := h.clientSnapshot(c, id)
+
 	err = h.clientService.UpdateClientStatus(c.Request.Context(), id, req.Status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -257,6 +349,12 @@ func (h *AdminHandler) UpdateClientStatus(c *gin.Context) {
 		return
 	}
 
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"status": before.Status}
+	}
+	h.recordClientAudit(c, model.AuditActionClientStatus, id, "", beforeValues, gin.H{"status": req.Status})
+
 	// Return updated client information
 	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
 	if err != nil {
@@ -383,7 +481,9 @@ func (h *AdminHandler) UpdateClientQPS(c *gin.Context) {
 		return
 	}
 
-	err = h.clientService.UpdateClientQPS(c.Request.Context(), objectID, req.QPS)
+	before := h.clientSnapshot(c, objectID)
+
+	err = h.clientService.UpdateClientQPS(c.Request.Context(), objectID, req.QPS, req.Burst)
 	if err != nil {
 		if err.Error() == "client not found" {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -401,13 +501,100 @@ func (h *AdminHandler) UpdateClientQPS(c *gin.Context) {
 		return
 	}
 
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"qps": before.QPS, "burst": before.Burst}
+	}
+	h.recordClientAudit(c, model.AuditActionClientQPS, objectID, "", beforeValues, gin.H{"qps": req.QPS, "burst": req.Burst})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Client QPS updated successfully",
 		"client_id": clientID,
 		"qps":       req.QPS,
+		"burst":     req.Burst,
 	})
 }
 
+// UpdateClientPlan updates a client's billing plan, which selects the plan specific route prices
+// PUT /admin/clients/:id/plan
+func (h *AdminHandler) UpdateClientPlan(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	if err := h.clientService.UpdateClientPlan(c.Request.Context(), id, req.Plan); err != nil {
+		if err.Error() == "client not found" {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40404,
+				Message: "Client not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50024,
+			Message: "Failed to update client plan",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"plan": before.Plan}
+	}
+	h.recordClientAudit(c, model.AuditActionClientPlan, id, "", beforeValues, gin.H{"plan": req.Plan})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Client plan updated successfully",
+		"client_id": id.Hex(),
+		"plan":      req.Plan,
+	})
+}
+
+// clientSnapshot loads a client before a change for the audit log, returns nil if it cannot be loaded
+func (h *AdminHandler) clientSnapshot(c *gin.Context, id primitive.ObjectID) *model.Client {
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+// recordClientAudit records an administrative action on a client
+func (h *AdminHandler) recordClientAudit(c *gin.Context, action string, clientID primitive.ObjectID, targetID string, before, after gin.H) {
+	entry := newAuditLog(c, action)
+	entry.ClientID = &clientID
+	entry.TargetID = targetID
+	entry.Before = before
+	entry.After = after
+	h.auditService.Record(entry)
+}
+
+// balanceValues returns the billing fields of a client for the audit log
+func balanceValues(client *model.Client) gin.H {
+	if client == nil {
+		return nil
+	}
+	return gin.H{
+		"call_count":  client.CallCount,
+		"total_count": client.TotalCount,
+	}
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Code    int    `json:"code"`
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateCORSRequest represents the request to replace a client's CORS policy
+type UpdateCORSRequest struct {
+	AllowedOrigins []string `json:"allowed_origins" binding:"max=50"` // 为空表示删除策略，使用全局配置
+	AllowedMethods []string `json:"allowed_methods" binding:"max=20"`
+	AllowedHeaders []string `json:"allowed_headers" binding:"max=50"`
+}
+
+// CORSResponse represents the CORS policy of a client
+type CORSResponse struct {
+	ClientID string            `json:"client_id"`
+	CORS     *model.CORSPolicy `json:"cors"` // 为空时使用全局配置
+}
+
+// GetClientCORS retrieves the CORS policy of a client
+func (h *AdminHandler) GetClientCORS(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CORSResponse{ClientID: client.ID.Hex(), CORS: client.CORS})
+}
+
+// UpdateClientCORS replaces the CORS policy of a client
+func (h *AdminHandler) UpdateClientCORS(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateCORSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40013,
+			Message: "Invalid CORS policy",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	client, err := h.clientService.UpdateClientCORS(c.Request.Context(), id, &model.CORSPolicy{
+		AllowedOrigins: req.AllowedOrigins,
+		AllowedMethods: req.AllowedMethods,
+		AllowedHeaders: req.AllowedHeaders,
+	})
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40013,
+				Message: "Invalid CORS policy",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50018,
+				Message: "Failed to update client CORS policy",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"cors": before.CORS}
+	}
+	h.recordClientAudit(c, model.AuditActionClientCORS, id, "", beforeValues, gin.H{"cors": client.CORS})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Client CORS policy updated successfully",
+		"cors":    CORSResponse{ClientID: client.ID.Hex(), CORS: client.CORS},
+	})
+}
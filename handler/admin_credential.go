@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IssueCredentialRequest represents the request to issue a new API key for a client
+type IssueCredentialRequest struct {
+	Label     string     `json:"label" binding:"max=64"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RotateCredentialRequest represents the request to rotate an API key
+type RotateCredentialRequest struct {
+	GracePeriodSeconds int `json:"grace_period_seconds" binding:"min=0"` // 旧密钥保留时间，0表示使用默认配置
+}
+
+// CredentialResponse represents a newly issued credential
+type CredentialResponse struct {
+	ID        string     `json:"id"`
+	ClientID  string     `json:"client_id"`
+	Label     string     `json:"label"`
+	APIKey    string     `json:"api_key"`
+	Secret    string     `json:"secret"` // 仅签发时返回一次
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt string     `json:"created_at"`
+}
+
+// IssueCredential issues a new API key for a client
+func (h *AdminHandler) IssueCredential(c *gin.Context) {
+	clientID, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req IssueCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40005,
+			Message: "Invalid credential parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40005,
+			Message: "Invalid credential parameters",
+			Error:   "expires_at must be in the future",
+		})
+		return
+	}
+
+	credential, err := h.credentialService.IssueCredential(c.Request.Context(), clientID, req.Label, req.ExpiresAt)
+	if err != nil {
+		h.respondCredentialError(c, err, "Failed to issue credential")
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionCredentialIssue, clientID, credential.ID.Hex(), nil, gin.H{
+		"label":          credential.Label,
+		"api_key_prefix": credential.APIKeyPrefix,
+		"expires_at":     credential.ExpiresAt,
+	})
+
+	c.JSON(http.StatusCreated, newCredentialResponse(credential))
+}
+
+// ListCredentials lists all API keys of a client
+func (h *AdminHandler) ListCredentials(c *gin.Context) {
+	clientID, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	credentials, err := h.credentialService.ListCredentials(c.Request.Context(), clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50009,
+			Message: "Failed to retrieve credentials",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credentials": credentials,
+		"count":       len(credentials),
+	})
+}
+
+// RotateCredential replaces an API key, keeping the old one valid for a grace period
+func (h *AdminHandler) RotateCredential(c *gin.Context) {
+	clientID, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	credentialID, ok := parseObjectIDParam(c, "key_id", "Invalid credential ID format")
+	if !ok {
+		return
+	}
+
+	var req RotateCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40005,
+			Message: "Invalid credential parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	gracePeriod := time.Duration(req.GracePeriodSeconds) * time.Second
+	credential, err := h.credentialService.RotateCredential(c.Request.Context(), clientID, credentialID, gracePeriod)
+	if err != nil {
+		h.respondCredentialError(c, err, "Failed to rotate credential")
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionCredentialRotate, clientID, credentialID.Hex(),
+		gin.H{"credential_id": credentialID.Hex()},
+		gin.H{"credential_id": credential.ID.Hex(), "api_key_prefix": credential.APIKeyPrefix, "grace_period": gracePeriod.String()})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Credential rotated successfully",
+		"credential": newCredentialResponse(credential),
+	})
+}
+
+// RevokeCredential revokes an API key immediately
+func (h *AdminHandler) RevokeCredential(c *gin.Context) {
+	clientID, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	credentialID, ok := parseObjectIDParam(c, "key_id", "Invalid credential ID format")
+	if !ok {
+		return
+	}
+
+	if err := h.credentialService.RevokeCredential(c.Request.Context(), clientID, credentialID); err != nil {
+		h.respondCredentialError(c, err, "Failed to revoke credential")
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionCredentialRevoke, clientID, credentialID.Hex(),
+		gin.H{"status": model.CredentialStatusActive}, gin.H{"status": model.CredentialStatusRevoked})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Credential revoked successfully",
+		"credential_id": credentialID.Hex(),
+	})
+}
+
+// respondCredentialError maps credential service errors to responses
+func (h *AdminHandler) respondCredentialError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "client not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+		})
+	case err.Error() == "credential not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40402,
+			Message: "Credential not found",
+		})
+	case strings.Contains(err.Error(), "not active"):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    40901,
+			Message: "Credential is revoked or expired",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50010,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+// parseObjectIDParam parses an ObjectID path parameter, responding with 400 on failure
+func parseObjectIDParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func newCredentialResponse(credential *model.Credential) CredentialResponse {
+	return CredentialResponse{
+		ID:        credential.ID.Hex(),
+		ClientID:  credential.ClientID.Hex(),
+		Label:     credential.Label,
+		APIKey:    credential.APIKey,
+		Secret:    credential.Secret,
+		ExpiresAt: credential.ExpiresAt,
+		CreatedAt: credential.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateIPRulesRequest represents the request to replace a client's IP rules
+type UpdateIPRulesRequest struct {
+	Allowlist []string `json:"allowlist" binding:"max=100"` // IP 或 CIDR，为空表示不限制
+	Denylist  []string `json:"denylist" binding:"max=100"`
+}
+
+// IPRulesResponse represents the IP rules of a client
+type IPRulesResponse struct {
+	ClientID  string   `json:"client_id"`
+	Allowlist []string `json:"allowlist"`
+	Denylist  []string `json:"denylist"`
+}
+
+// GetClientIPRules retrieves the IP allowlist and denylist of a client
+func (h *AdminHandler) GetClientIPRules(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newIPRulesResponse(client.ID.Hex(), client.IPAllowlist, client.IPDenylist))
+}
+
+// UpdateClientIPRules replaces the IP allowlist and denylist of a client
+func (h *AdminHandler) UpdateClientIPRules(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateIPRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40006,
+			Message: "Invalid IP rules",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	client, err := h.clientService.UpdateClientIPRules(c.Request.Context(), id, req.Allowlist, req.Denylist)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40006,
+				Message: "Invalid IP rules",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50011,
+				Message: "Failed to update client IP rules",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"allowlist": before.IPAllowlist, "denylist": before.IPDenylist}
+	}
+	h.recordClientAudit(c, model.AuditActionClientIPRules, id, "", beforeValues,
+		gin.H{"allowlist": client.IPAllowlist, "denylist": client.IPDenylist})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Client IP rules updated successfully",
+		"ip_rules": newIPRulesResponse(client.ID.Hex(), client.IPAllowlist, client.IPDenylist),
+	})
+}
+
+func newIPRulesResponse(clientID string, allowlist, denylist []string) IPRulesResponse {
+	if allowlist == nil {
+		allowlist = []string{}
+	}
+	if denylist == nil {
+		denylist = []string{}
+	}
+	return IPRulesResponse{
+		ClientID:  clientID,
+		Allowlist: allowlist,
+		Denylist:  denylist,
+	}
+}
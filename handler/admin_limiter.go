@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"api-gateway/middleware"
+	"api-gateway/model"
+	"api-gateway/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LimiterHandler handles live rate limiter state and temporary limits of clients
+type LimiterHandler struct {
+	clientService service.ClientServiceInterface
+	rateLimit     *middleware.RateLimitMiddleware
+	quotaService  *service.QuotaService
+	auditService  service.AuditServiceInterface
+}
+
+// NewLimiterHandler creates a new limiter handler
+func NewLimiterHandler(clientService service.ClientServiceInterface, rateLimit *middleware.RateLimitMiddleware, quotaService *service.QuotaService, auditService service.AuditServiceInterface) *LimiterHandler {
+	return &LimiterHandler{
+		clientService: clientService,
+		rateLimit:     rateLimit,
+		quotaService:  quotaService,
+		auditService:  auditService,
+	}
+}
+
+// UpdateTemporaryLimitRequest represents the request to set a temporary rate limit adjustment
+// duration 和 expires_at 二选一，duration 单位为秒
+type UpdateTemporaryLimitRequest struct {
+	QPS        int        `json:"qps" binding:"min=0"`
+	Burst      int        `json:"burst" binding:"min=0"`
+	Multiplier float64    `json:"multiplier" binding:"min=0"`
+	Duration   int        `json:"duration" binding:"min=0"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Reason     string     `json:"reason" binding:"max=200"`
+}
+
+// GetClientLimiter retrieves the live rate limiter state and quota usage of a client
+// GET /admin/clients/:id/limiter
+func (h *LimiterHandler) GetClientLimiter(c *gin.Context) {
+	client, ok := h.getClient(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.rateLimit.ClientState(c.Request.Context(), client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50021,
+			Message: "Failed to retrieve rate limiter state",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	usage, err := h.quotaService.Usage(c.Request.Context(), client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50020,
+			Message: "Failed to retrieve quota usage",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	qps, burst := client.EffectiveQPS(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"client_id":       client.ID.Hex(),
+		"qps":             qps,
+		"burst":           burst,
+		"temporary_limit": client.ActiveTemporaryLimit(time.Now()),
+		"rules":           rules,
+		"quotas":          usage,
+	})
+}
+
+// ResetClientLimiter clears the rate limit buckets and current quota counters of a client
+// DELETE /admin/clients/:id/limiter
+func (h *LimiterHandler) ResetClientLimiter(c *gin.Context) {
+	client, ok := h.getClient(c)
+	if !ok {
+		return
+	}
+
+	if err := h.rateLimit.ResetClient(c.Request.Context(), client); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50022,
+			Message: "Failed to reset rate limiter state",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := h.quotaService.Reset(c.Request.Context(), client); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50022,
+			Message: "Failed to reset quota counters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	entry := newAuditLog(c, model.AuditActionClientLimiterReset)
+	entry.ClientID = &client.ID
+	h.auditService.Record(entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Client rate limiter reset successfully",
+		"client_id": client.ID.Hex(),
+	})
+}
+
+// UpdateTemporaryLimit sets a temporary rate limit adjustment of a client, replacing the current one
+// PUT /admin/clients/:id/temporary-limit
+func (h *LimiterHandler) UpdateTemporaryLimit(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateTemporaryLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40016,
+			Message: "Invalid temporary limit",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	limit := &model.TemporaryLimit{
+		QPS:        req.QPS,
+		Burst:      req.Burst,
+		Multiplier: req.Multiplier,
+		Reason:     req.Reason,
+		CreatedAt:  now,
+	}
+	switch {
+	case req.ExpiresAt != nil:
+		limit.ExpiresAt = *req.ExpiresAt
+	case req.Duration > 0:
+		limit.ExpiresAt = now.Add(time.Duration(req.Duration) * time.Second)
+	}
+	if user, ok := c.Value("admin_user").(*model.AdminUser); ok {
+		limit.CreatedBy = user.Username
+	}
+
+	h.updateTemporaryLimit(c, id, limit)
+}
+
+// DeleteTemporaryLimit removes the temporary rate limit adjustment of a client
+// DELETE /admin/clients/:id/temporary-limit
+func (h *LimiterHandler) DeleteTemporaryLimit(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	h.updateTemporaryLimit(c, id, nil)
+}
+
+func (h *LimiterHandler) updateTemporaryLimit(c *gin.Context, id primitive.ObjectID, limit *model.TemporaryLimit) {
+	before, _ := h.clientService.GetClientByID(c.Request.Context(), id)
+
+	client, err := h.clientService.UpdateClientTemporaryLimit(c.Request.Context(), id, limit)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40016,
+				Message: "Invalid temporary limit",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50023,
+				Message: "Failed to update temporary limit",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	entry := newAuditLog(c, model.AuditActionClientTemporaryLimit)
+	entry.ClientID = &id
+	if before != nil {
+		entry.Before = gin.H{"temporary_limit": before.TemporaryLimit}
+	}
+	entry.After = gin.H{"temporary_limit": client.TemporaryLimit}
+	h.auditService.Record(entry)
+
+	message := "Client temporary limit updated successfully"
+	if limit == nil {
+		message = "Client temporary limit removed successfully"
+	}
+	qps, burst := client.EffectiveQPS(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"message":         message,
+		"client_id":       client.ID.Hex(),
+		"qps":             qps,
+		"burst":           burst,
+		"temporary_limit": client.TemporaryLimit,
+	})
+}
+
+func (h *LimiterHandler) getClient(c *gin.Context) (*model.Client, bool) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return nil, false
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	return client, true
+}
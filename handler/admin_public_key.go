@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPublicKeyRequest represents the request to register a client public key
+type RegisterPublicKeyRequest struct {
+	KeyID     string `json:"key_id" binding:"max=64"`       // 为空时使用公钥指纹前缀
+	PublicKey string `json:"public_key" binding:"required"` // PKIX PEM 格式的 Ed25519 或 ECDSA P-256 公钥
+}
+
+// UpdateSignatureTypeRequest represents the request to switch a client's signature type
+type UpdateSignatureTypeRequest struct {
+	SignatureType string `json:"signature_type" binding:"required,oneof=hmac ed25519 ecdsa"`
+}
+
+// ListPublicKeys lists the public keys registered by a client
+// GET /admin/clients/:id/public-keys
+func (h *AdminHandler) ListPublicKeys(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	keys := client.PublicKeys
+	if keys == nil {
+		keys = []model.PublicKey{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client_id":      client.ID.Hex(),
+		"signature_type": client.EffectiveSignatureType(),
+		"public_keys":    keys,
+	})
+}
+
+// RegisterPublicKey registers a public key for asymmetric request signatures
+// POST /admin/clients/:id/public-keys
+func (h *AdminHandler) RegisterPublicKey(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req RegisterPublicKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40012,
+			Message: "Invalid public key",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	key, err := h.clientService.RegisterPublicKey(c.Request.Context(), id, req.KeyID, req.PublicKey)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case err.Error() == "public key already exists":
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:    40903,
+				Message: "Public key ID already exists",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40012,
+				Message: "Invalid public key",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50017,
+				Message: "Failed to register public key",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionPublicKeyAdd, id, key.KeyID, nil, gin.H{
+		"algorithm":   key.Algorithm,
+		"fingerprint": key.Fingerprint,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Public key registered successfully",
+		"public_key": key,
+	})
+}
+
+// RemovePublicKey removes a registered public key
+// DELETE /admin/clients/:id/public-keys/:key_id
+func (h *AdminHandler) RemovePublicKey(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+	keyID := c.Param("key_id")
+
+	if err := h.clientService.RemovePublicKey(c.Request.Context(), id, keyID); err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case err.Error() == "public key not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40406,
+				Message: "Public key not found",
+			})
+		case strings.HasPrefix(err.Error(), "cannot remove"):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Code:    40904,
+				Message: "Public key is still in use",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50017,
+				Message: "Failed to remove public key",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionPublicKeyRemove, id, keyID, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Public key removed successfully",
+	})
+}
+
+// UpdateSignatureType switches a client between HMAC and asymmetric request signatures
+// PUT /admin/clients/:id/signature-type
+func (h *AdminHandler) UpdateSignatureType(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateSignatureTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40012,
+			Message: "Invalid signature type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	client, err := h.clientService.UpdateSignatureType(c.Request.Context(), id, req.SignatureType)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40012,
+				Message: "Invalid signature type",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50017,
+				Message: "Failed to update signature type",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"signature_type": before.EffectiveSignatureType()}
+	}
+	h.recordClientAudit(c, model.AuditActionSignatureType, id, "", beforeValues,
+		gin.H{"signature_type": client.EffectiveSignatureType()})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Client signature type updated successfully",
+		"client_id":      client.ID.Hex(),
+		"signature_type": client.EffectiveSignatureType(),
+	})
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"api-gateway/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler handles client call quotas
+type QuotaHandler struct {
+	clientService service.ClientServiceInterface
+	quotaService  *service.QuotaService
+	auditService  service.AuditServiceInterface
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(clientService service.ClientServiceInterface, quotaService *service.QuotaService, auditService service.AuditServiceInterface) *QuotaHandler {
+	return &QuotaHandler{
+		clientService: clientService,
+		quotaService:  quotaService,
+		auditService:  auditService,
+	}
+}
+
+// UpdateQuotasRequest represents the request to replace a client's call quotas
+type UpdateQuotasRequest struct {
+	Quotas []model.Quota `json:"quotas" binding:"max=50"` // 为空表示全部使用配置中的配额
+}
+
+// GetClientQuotas retrieves the quotas of a client with their usage in the current window
+// GET /admin/clients/:id/quotas
+func (h *QuotaHandler) GetClientQuotas(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	usage, err := h.quotaService.Usage(c.Request.Context(), client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50020,
+			Message: "Failed to retrieve quota usage",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client_id": client.ID.Hex(),
+		"quotas":    clientQuotas(client),
+		"usage":     usage,
+	})
+}
+
+// UpdateClientQuotas replaces the call quotas of a client
+// PUT /admin/clients/:id/quotas
+func (h *QuotaHandler) UpdateClientQuotas(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateQuotasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40015,
+			Message: "Invalid quotas",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before, _ := h.clientService.GetClientByID(c.Request.Context(), id)
+
+	client, err := h.clientService.UpdateClientQuotas(c.Request.Context(), id, req.Quotas)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40015,
+				Message: "Invalid quotas",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50020,
+				Message: "Failed to update client quotas",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	entry := newAuditLog(c, model.AuditActionClientQuotas)
+	entry.ClientID = &id
+	if before != nil {
+		entry.Before = gin.H{"quotas": before.Quotas}
+	}
+	entry.After = gin.H{"quotas": client.Quotas}
+	h.auditService.Record(entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Client quotas updated successfully",
+		"client_id": client.ID.Hex(),
+		"quotas":    clientQuotas(client),
+	})
+}
+
+func clientQuotas(client *model.Client) []model.Quota {
+	if client.Quotas == nil {
+		return []model.Quota{}
+	}
+	return client.Quotas
+}
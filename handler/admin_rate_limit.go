@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateRateLimitsRequest represents the request to replace a client's rate limit overrides
+type UpdateRateLimitsRequest struct {
+	RateLimits []model.RateLimitOverride `json:"rate_limits" binding:"max=50"` // 为空表示全部使用配置中的规则
+}
+
+// RateLimitsResponse represents the rate limit settings of a client
+type RateLimitsResponse struct {
+	ClientID   string                    `json:"client_id"`
+	QPS        int                       `json:"qps"`
+	Burst      int                       `json:"burst,omitempty"`
+	RateLimits []model.RateLimitOverride `json:"rate_limits"`
+}
+
+// GetClientRateLimits retrieves the QPS and rate limit overrides of a client
+func (h *AdminHandler) GetClientRateLimits(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.GetClientByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "Client not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newRateLimitsResponse(client))
+}
+
+// UpdateClientRateLimits replaces the rate limit overrides of a client
+func (h *AdminHandler) UpdateClientRateLimits(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateRateLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40014,
+			Message: "Invalid rate limit overrides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	client, err := h.clientService.UpdateClientRateLimits(c.Request.Context(), id, req.RateLimits)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40014,
+				Message: "Invalid rate limit overrides",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50019,
+				Message: "Failed to update client rate limits",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"rate_limits": before.RateLimits}
+	}
+	h.recordClientAudit(c, model.AuditActionClientRateLimits, id, "", beforeValues, gin.H{"rate_limits": client.RateLimits})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Client rate limits updated successfully",
+		"rate_limits": newRateLimitsResponse(client),
+	})
+}
+
+func newRateLimitsResponse(client *model.Client) RateLimitsResponse {
+	overrides := client.RateLimits
+	if overrides == nil {
+		overrides = []model.RateLimitOverride{}
+	}
+	return RateLimitsResponse{
+		ClientID:   client.ID.Hex(),
+		QPS:        client.QPS,
+		Burst:      client.Burst,
+		RateLimits: overrides,
+	}
+}
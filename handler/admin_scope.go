@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopesRequest represents the request to grant or revoke client scopes
+type ScopesRequest struct {
+	Scopes []string `json:"scopes" binding:"required,min=1,max=50"` // 产品名或路由模式（如 /api/math/*）
+}
+
+// GrantScopes grants products or route patterns to a client
+func (h *AdminHandler) GrantScopes(c *gin.Context) {
+	h.updateScopes(c, true)
+}
+
+// RevokeScopes revokes products or route patterns from a client
+func (h *AdminHandler) RevokeScopes(c *gin.Context) {
+	h.updateScopes(c, false)
+}
+
+func (h *AdminHandler) updateScopes(c *gin.Context, grant bool) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req ScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40007,
+			Message: "Invalid scope parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	var (
+		client  *model.Client
+		err     error
+		action  string
+		message string
+	)
+	if grant {
+		client, err = h.scopeService.GrantScopes(c.Request.Context(), id, req.Scopes)
+		action = model.AuditActionClientScopeGrant
+		message = "Client scopes granted successfully"
+	} else {
+		client, err = h.scopeService.RevokeScopes(c.Request.Context(), id, req.Scopes)
+		action = model.AuditActionClientScopeRevoke
+		message = "Client scopes revoked successfully"
+	}
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid scope"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40007,
+				Message: "Invalid scope parameters",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50012,
+				Message: "Failed to update client scopes",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	scopes := client.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	var beforeValues gin.H
+	if before != nil {
+		beforeValues = gin.H{"scopes": before.Scopes}
+	}
+	h.recordClientAudit(c, action, id, "", beforeValues, gin.H{"scopes": scopes, "changed": req.Scopes})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"client_id": client.ID.Hex(),
+		"scopes":    scopes,
+	})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"api-gateway/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserHandler handles admin user management
+type AdminUserHandler struct {
+	adminUserService *service.AdminUserService
+	auditService     service.AuditServiceInterface
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler(adminUserService *service.AdminUserService, auditService service.AuditServiceInterface) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+		auditService:     auditService,
+	}
+}
+
+// CreateAdminUserRequest represents the request to create an admin user
+type CreateAdminUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=64"`
+	Password string `json:"password"` // 可选，不设置时只能使用访问令牌
+	Role     string `json:"role" binding:"required"`
+}
+
+// UpdateAdminRoleRequest represents the request to change an admin user's role
+type UpdateAdminRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AdminTokenResponse represents an admin user with a newly issued access token
+type AdminTokenResponse struct {
+	User  *model.AdminUser `json:"user"`
+	Token string           `json:"token"` // 仅签发时返回一次
+}
+
+// Me returns the authenticated admin user
+func (h *AdminUserHandler) Me(c *gin.Context) {
+	user, _ := c.Get("admin_user")
+	c.JSON(http.StatusOK, user)
+}
+
+// ListUsers lists all admin users
+func (h *AdminUserHandler) ListUsers(c *gin.Context) {
+	users, err := h.adminUserService.ListUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50013,
+			Message: "Failed to retrieve admin users",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+// CreateUser creates an admin user
+func (h *AdminUserHandler) CreateUser(c *gin.Context) {
+	var req CreateAdminUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40008,
+			Message: "Invalid admin user parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, token, err := h.adminUserService.CreateUser(c.Request.Context(), req.Username, req.Password, req.Role)
+	if err != nil {
+		h.respondError(c, err, "Failed to create admin user")
+		return
+	}
+
+	h.recordAudit(c, model.AuditActionAdminUserCreate, user.ID.Hex(), nil, gin.H{
+		"username": user.Username,
+		"role":     user.Role,
+	})
+
+	c.JSON(http.StatusCreated, AdminTokenResponse{User: user, Token: token})
+}
+
+// UpdateRole changes the role of an admin user
+func (h *AdminUserHandler) UpdateRole(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid admin user ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40008,
+			Message: "Invalid admin user parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, err := h.adminUserService.UpdateRole(c.Request.Context(), id, req.Role)
+	if err != nil {
+		h.respondError(c, err, "Failed to update admin user role")
+		return
+	}
+
+	h.recordAudit(c, model.AuditActionAdminUserRole, user.ID.Hex(), nil, gin.H{"role": user.Role})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Admin user role updated successfully",
+		"user":    user,
+	})
+}
+
+// UpdateStatus enables or disables an admin user
+func (h *AdminUserHandler) UpdateStatus(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid admin user ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40008,
+			Message: "Invalid admin user parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 禁止禁用自己，避免误操作导致无人可管理
+	if current, ok := c.Value("admin_user").(*model.AdminUser); ok && current.ID == id && req.Status == model.AdminUserStatusDisabled {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40008,
+			Message: "Invalid admin user parameters",
+			Error:   "cannot disable yourself",
+		})
+		return
+	}
+
+	user, err := h.adminUserService.UpdateStatus(c.Request.Context(), id, req.Status)
+	if err != nil {
+		h.respondError(c, err, "Failed to update admin user status")
+		return
+	}
+
+	h.recordAudit(c, model.AuditActionAdminUserStatus, user.ID.Hex(), nil, gin.H{"status": user.Status})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Admin user status updated successfully",
+		"user":    user,
+	})
+}
+
+// RotateToken issues a new access token for an admin user
+func (h *AdminUserHandler) RotateToken(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid admin user ID format")
+	if !ok {
+		return
+	}
+
+	user, token, err := h.adminUserService.RotateToken(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to rotate admin token")
+		return
+	}
+
+	h.recordAudit(c, model.AuditActionAdminUserToken, user.ID.Hex(), nil, gin.H{"token_prefix": user.TokenPrefix})
+
+	c.JSON(http.StatusOK, AdminTokenResponse{User: user, Token: token})
+}
+
+// recordAudit records an administrative action on an admin user
+func (h *AdminUserHandler) recordAudit(c *gin.Context, action, targetID string, before, after gin.H) {
+	entry := newAuditLog(c, action)
+	entry.TargetID = targetID
+	entry.Before = before
+	entry.After = after
+	h.auditService.Record(entry)
+}
+
+// respondError maps admin user service errors to responses
+func (h *AdminUserHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "admin user not found":
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40403,
+			Message: "Admin user not found",
+		})
+	case err.Error() == "admin user already exists":
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    40902,
+			Message: "Admin user already exists",
+		})
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40008,
+			Message: "Invalid admin user parameters",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50013,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateValidityRequest represents the request to replace or extend a client's contract
+type UpdateValidityRequest struct {
+	ValidFrom  *time.Time `json:"valid_from"`  // RFC 3339，为空表示不限制开始时间
+	ValidUntil *time.Time `json:"valid_until"` // RFC 3339，为空表示长期有效
+	Reactivate bool       `json:"reactivate"`  // 重新启用到期被禁用的客户
+}
+
+// UpdateClientValidity replaces the contract validity window of a client
+// PUT /admin/clients/:id/validity
+func (h *AdminHandler) UpdateClientValidity(c *gin.Context) {
+	id, ok := parseObjectIDParam(c, "id", "Invalid client ID format")
+	if !ok {
+		return
+	}
+
+	var req UpdateValidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40011,
+			Message: "Invalid validity window",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := h.clientSnapshot(c, id)
+
+	client, err := h.clientService.UpdateClientValidity(c.Request.Context(), id, req.ValidFrom, req.ValidUntil, req.Reactivate)
+	if err != nil {
+		switch {
+		case err.Error() == "client not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "Client not found",
+			})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40011,
+				Message: "Invalid validity window",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50016,
+				Message: "Failed to update client validity",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	h.recordClientAudit(c, model.AuditActionClientValidity, id, "", validityValues(before), validityValues(client))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Client validity updated successfully",
+		"client":  client,
+	})
+}
+
+// validityValues returns the contract fields of a client for the audit log
+func validityValues(client *model.Client) gin.H {
+	if client == nil {
+		return nil
+	}
+	return gin.H{
+		"valid_from":  client.ValidFrom,
+		"valid_until": client.ValidUntil,
+		"status":      client.Status,
+	}
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"api-gateway/repository"
+	"api-gateway/service"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditHandler handles audit log queries
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+// NewAuditHandler creates a new audit log handler
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs queries audit logs with filters and pagination
+// GET /admin/audit-logs?actor=&action=&client_id=&request_id=&from=&to=&offset=&limit=
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	filter, ok := parseAuditLogFilter(c)
+	if !ok {
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination parameters
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	logs, total, err := h.auditService.Find(c.Request.Context(), filter, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50014,
+			Message: "Failed to retrieve audit logs",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":   logs,
+		"offset": offset,
+		"limit":  limit,
+		"count":  len(logs),
+		"total":  total,
+	})
+}
+
+// ExportAuditLogs streams all audit logs matching the filters as CSV or JSON Lines
+// GET /admin/audit-logs/export?format=csv|jsonl&...
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	filter, ok := parseAuditLogFilter(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40009,
+			Message: "Invalid audit log filter",
+			Error:   "format must be csv or jsonl",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+
+	var err error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		err = h.exportCSV(c, filter)
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		err = h.exportJSONL(c, filter)
+	}
+
+	// 已开始输出后无法再返回错误响应，只能中断
+	if err != nil {
+		c.Error(err)
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50014,
+				Message: "Failed to export audit logs",
+				Error:   err.Error(),
+			})
+		}
+	}
+}
+
+func (h *AuditHandler) exportCSV(c *gin.Context, filter repository.AuditLogFilter) error {
+	w := csv.NewWriter(c.Writer)
+	header := []string{"time", "actor", "actor_role", "action", "client_id", "target_id", "before", "after", "ip", "request_id"}
+	headerWritten := false
+
+	err := h.auditService.Export(c.Request.Context(), filter, func(log *model.AuditLog) error {
+		if !headerWritten {
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+
+		clientID := ""
+		if log.ClientID != nil {
+			clientID = log.ClientID.Hex()
+		}
+		before, _ := json.Marshal(log.Before)
+		after, _ := json.Marshal(log.After)
+
+		return w.Write([]string{
+			log.CreatedAt.Format(time.RFC3339),
+			log.Actor,
+			log.ActorRole,
+			log.Action,
+			clientID,
+			log.TargetID,
+			string(before),
+			string(after),
+			log.IP,
+			log.RequestID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if !headerWritten {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (h *AuditHandler) exportJSONL(c *gin.Context, filter repository.AuditLogFilter) error {
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	return h.auditService.Export(c.Request.Context(), filter, func(log *model.AuditLog) error {
+		return encoder.Encode(log)
+	})
+}
+
+// parseAuditLogFilter parses audit log filters from query parameters, responding with 400 on failure
+func parseAuditLogFilter(c *gin.Context) (repository.AuditLogFilter, bool) {
+	filter := repository.AuditLogFilter{
+		Actor:     c.Query("actor"),
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+	}
+
+	invalid := func(err string) (repository.AuditLogFilter, bool) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40009,
+			Message: "Invalid audit log filter",
+			Error:   err,
+		})
+		return filter, false
+	}
+
+	if clientID := c.Query("client_id"); clientID != "" {
+		id, err := primitive.ObjectIDFromHex(clientID)
+		if err != nil {
+			return invalid("invalid client_id")
+		}
+		filter.ClientID = &id
+	}
+
+	// 时间参数为 RFC3339 格式，如 2024-01-01T00:00:00+08:00
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return invalid("from must be RFC3339 time")
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return invalid("to must be RFC3339 time")
+		}
+		filter.To = &t
+	}
+
+	return filter, true
+}
+
+// newAuditLog creates an audit log entry with the actor, source IP and request ID of the request
+func newAuditLog(c *gin.Context, action string) *model.AuditLog {
+	entry := &model.AuditLog{
+		Action:    action,
+		IP:        c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+	if user, ok := c.Value("admin_user").(*model.AdminUser); ok {
+		entry.Actor = user.Username
+		entry.ActorID = user.ID
+		entry.ActorRole = user.Role
+	}
+	return entry
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"api-gateway/model"
+	"api-gateway/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthBanHandler handles bans caused by repeated authentication failures
+type AuthBanHandler struct {
+	authGuard    *service.AuthGuard
+	auditService service.AuditServiceInterface
+}
+
+// NewAuthBanHandler creates a new auth ban handler
+func NewAuthBanHandler(authGuard *service.AuthGuard, auditService service.AuditServiceInterface) *AuthBanHandler {
+	return &AuthBanHandler{
+		authGuard:    authGuard,
+		auditService: auditService,
+	}
+}
+
+// ListBans lists all active IP and key prefix bans
+// GET /admin/bans
+func (h *AuthBanHandler) ListBans(c *gin.Context) {
+	bans, err := h.authGuard.ListBans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50015,
+			Message: "Failed to retrieve bans",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans":  bans,
+		"count": len(bans),
+	})
+}
+
+// Unban lifts a ban manually
+// DELETE /admin/bans/:scope/:subject
+func (h *AuthBanHandler) Unban(c *gin.Context) {
+	scope := c.Param("scope")
+	subject := c.Param("subject")
+
+	if err := h.authGuard.Unban(c.Request.Context(), scope, subject); err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid ban scope"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40010,
+				Message: "Invalid ban scope",
+				Error:   err.Error(),
+			})
+		case err.Error() == "ban not found":
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40405,
+				Message: "Ban not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    50015,
+				Message: "Failed to lift ban",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	entry := newAuditLog(c, model.AuditActionAuthUnban)
+	entry.TargetID = model.AuthBanKey(scope, subject)
+	h.auditService.Record(entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ban lifted successfully",
+		"scope":   scope,
+		"subject": subject,
+	})
+}
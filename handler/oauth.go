@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"api-gateway/middleware"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/service"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthHandler OAuth2 客户端凭证模式端点
+type OAuthHandler struct {
+	oauthService *service.OAuthService
+	authGuard    *service.AuthGuard // 为空时不启用认证失败防护
+}
+
+// NewOAuthHandler 创建 OAuth2 处理器，客户认证与API密钥认证共用认证失败防护
+func NewOAuthHandler(oauthService *service.OAuthService, authGuard *service.AuthGuard) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		authGuard:    authGuard,
+	}
+}
+
+// OAuthErrorResponse RFC 6749 错误响应
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenResponse 访问令牌响应
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// IntrospectionResponse RFC 7662 令牌内省响应
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// Token 使用API密钥和签名密钥换取访问令牌
+// POST /oauth/token (grant_type=client_credentials)
+func (h *OAuthHandler) Token(c *gin.Context) {
+	if grantType := c.PostForm("grant_type"); grantType != "client_credentials" {
+		h.respondError(c, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	client, credential, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token, err := h.oauthService.IssueToken(client, credential, c.PostForm("scope"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid scope") {
+			h.respondError(c, http.StatusBadRequest, "invalid_scope", err.Error())
+			return
+		}
+		logger.Errorf("Failed to issue access token for client %s: %v", client.ID.Hex(), err)
+		h.respondError(c, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	logger.Infof("Issued access token %s for client %s", token.Claims.ID, client.ID.Hex())
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken: token.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       token.Scope,
+	})
+}
+
+// Revoke 吊销访问令牌
+// POST /oauth/revoke (token=...)
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	client, _, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		h.respondError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	if err := h.oauthService.RevokeToken(c.Request.Context(), client, token); err != nil {
+		logger.Errorf("Failed to revoke access token for client %s: %v", client.ID.Hex(), err)
+		h.respondError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "")
+		return
+	}
+
+	// RFC 7009: 无效令牌同样返回200
+	c.Status(http.StatusOK)
+}
+
+// Introspect 查询访问令牌状态，只能查询本客户签发的令牌
+// POST /oauth/introspect (token=...)
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	client, _, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		h.respondError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	claims, err := h.oauthService.Introspect(c.Request.Context(), client, token)
+	if err != nil {
+		logger.Errorf("Failed to introspect access token for client %s: %v", client.ID.Hex(), err)
+		h.respondError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "")
+		return
+	}
+
+	if claims == nil {
+		c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.Subject,
+		TokenType: "Bearer",
+		Exp:       claims.ExpiresAt,
+		Iat:       claims.IssuedAt,
+		Sub:       claims.Subject,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+	})
+}
+
+// authenticateClient 通过 HTTP Basic 或表单参数认证客户，失败时直接写入响应
+// client_id 为API密钥，client_secret 为签名密钥
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*model.Client, *model.Credential, bool) {
+	apiKey, secret, basic := c.Request.BasicAuth()
+	if !basic {
+		apiKey = c.PostForm("client_id")
+		secret = c.PostForm("client_secret")
+	}
+
+	if apiKey == "" || secret == "" {
+		h.respondInvalidClient(c, basic)
+		return nil, nil, false
+	}
+	if h.rejectBanned(c, apiKey) {
+		return nil, nil, false
+	}
+
+	client, credential, err := h.oauthService.AuthenticateClient(c.Request.Context(), apiKey, secret)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid client credentials") || strings.Contains(err.Error(), "disabled") ||
+			strings.Contains(err.Error(), "client contract") {
+			logger.Infof("OAuth client authentication failed: %v", err)
+			if strings.Contains(err.Error(), "invalid client credentials") && h.authGuard != nil {
+				h.authGuard.RecordFailure(c.Request.Context(), c.ClientIP(), apiKey, "invalid_client")
+			}
+			h.respondInvalidClient(c, basic)
+			return nil, nil, false
+		}
+		logger.Errorf("Database error during OAuth client authentication: %v", err)
+		h.respondError(c, http.StatusInternalServerError, "server_error", "")
+		return nil, nil, false
+	}
+
+	if rule := client.CheckIP(c.ClientIP()); rule != model.IPRuleAllowed {
+		logger.Infof("OAuth client authentication failed: IP %s of client %s rejected by %s", c.ClientIP(), client.ID.Hex(), rule)
+		h.respondInvalidClient(c, basic)
+		return nil, nil, false
+	}
+
+	return client, credential, true
+}
+
+// rejectBanned 检查客户端IP和API密钥是否被封禁，被封禁时写入响应并返回 true
+func (h *OAuthHandler) rejectBanned(c *gin.Context, apiKey string) bool {
+	if h.authGuard == nil {
+		return false
+	}
+
+	ban, err := h.authGuard.Check(c.Request.Context(), c.ClientIP(), apiKey)
+	if err != nil {
+		// 防护存储不可用时放行，避免影响正常客户
+		logger.Errorf("Failed to check auth bans: %v", err)
+		return false
+	}
+	if ban == nil {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(ban.ExpiresAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	logger.Infof("OAuth client authentication rejected: %s is banned until %s", ban.Scope, ban.ExpiresAt.Format(time.RFC3339))
+	c.Header(middleware.RetryAfterHeader, strconv.Itoa(retryAfter))
+	h.respondError(c, http.StatusTooManyRequests, "invalid_client", "too many failed authentication attempts")
+	return true
+}
+
+// respondInvalidClient 客户认证失败，使用 Basic 认证时返回 WWW-Authenticate
+func (h *OAuthHandler) respondInvalidClient(c *gin.Context, basic bool) {
+	if basic {
+		c.Header("WWW-Authenticate", `Basic realm="api-gateway"`)
+	}
+	h.respondError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+}
+
+func (h *OAuthHandler) respondError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.AbortWithStatusJSON(status, OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
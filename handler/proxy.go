@@ -3,8 +3,11 @@ package handler
 import (
 	"api-gateway/config"
 	"api-gateway/errors"
+	"api-gateway/middleware"
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/pkg/ratelimit"
 	"api-gateway/pkg/signature"
 	"bytes"
 	"context"
@@ -12,6 +15,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +27,14 @@ type ProxyHandler struct {
 	client           *http.Client
 	config           *config.Config
 	signatureFactory *signature.SignatureFactory
+	concurrency      map[string]*upstreamLimiter // API版本 -> 上游自适应并发限制，指向同一服务的版本共享
+	retryAfter       int
+}
+
+// upstreamLimiter 上游服务的自适应并发限制
+type upstreamLimiter struct {
+	name    string // 上游服务 host，用作监控标签
+	limiter *ratelimit.ConcurrencyLimiter
 }
 
 // NewProxyHandler 创建代理处理器
@@ -50,7 +62,7 @@ func NewProxyHandler() *ProxyHandler {
 		// 使用 Context 超时控制整体请求时间（从 config.yaml 读取）
 	}
 
-	return &ProxyHandler{
+	p := &ProxyHandler{
 		client: &http.Client{
 			Timeout:   0, // 使用 context 超时控制，而不是 client 级别超时
 			Transport: transport,
@@ -58,6 +70,47 @@ func NewProxyHandler() *ProxyHandler {
 		config:           config.GetConfig(),
 		signatureFactory: signature.NewSignatureFactory(),
 	}
+	p.initConcurrency()
+	return p
+}
+
+// initConcurrency 按上游服务创建自适应并发限制
+func (p *ProxyHandler) initConcurrency() {
+	if p.config == nil || !p.config.Concurrency.Enabled {
+		return
+	}
+
+	cfg := p.config.Concurrency
+	p.retryAfter = cfg.RetryAfter
+	if p.retryAfter <= 0 {
+		p.retryAfter = 1
+	}
+
+	adaptive := ratelimit.AdaptiveConfig{
+		InitialLimit: cfg.InitialLimit,
+		MinLimit:     cfg.MinLimit,
+		MaxLimit:     cfg.MaxLimit,
+		Tolerance:    cfg.Tolerance,
+		BackoffRatio: cfg.BackoffRatio,
+	}
+
+	byHost := make(map[string]*upstreamLimiter)
+	p.concurrency = make(map[string]*upstreamLimiter, len(p.config.Targets))
+	for version, target := range p.config.Targets {
+		host := ratelimit.UpstreamHost(target.URL)
+
+		limiter, exists := byHost[host]
+		if !exists {
+			// 与异步任务共用同一上游的限制
+			limiter = &upstreamLimiter{
+				name:    host,
+				limiter: ratelimit.UpstreamLimiter(host, adaptive),
+			}
+			byHost[host] = limiter
+			metrics.GetMetrics().UpstreamLimit.WithLabelValues(host).Set(float64(limiter.limiter.Limit()))
+		}
+		p.concurrency[version] = limiter
+	}
 }
 
 // ProxyRequest 代理请求处理
@@ -107,22 +160,70 @@ func (p *ProxyHandler) ProxyRequest(c *gin.Context) {
 	defer cancel()
 	proxyReq = proxyReq.WithContext(ctx)
 
+	// 上游自适应并发限制，超出时直接返回 503
+	upstream := p.concurrency[client.Version]
+	var permit *ratelimit.Permit
+	if upstream != nil {
+		var ok bool
+		if permit, ok = upstream.limiter.Acquire(); !ok {
+			p.shed(c, upstream)
+			return
+		}
+		metrics.GetMetrics().UpstreamInFlight.WithLabelValues(upstream.name).Inc()
+		defer func() {
+			metrics.GetMetrics().UpstreamInFlight.WithLabelValues(upstream.name).Dec()
+			metrics.GetMetrics().UpstreamLimit.WithLabelValues(upstream.name).Set(float64(upstream.limiter.Limit()))
+		}()
+	}
+
 	// 发送请求
 	logger.Infof("Proxying request to %s for client %s", targetURL, client.ID.Hex())
+	start := time.Now()
 	resp, err := p.client.Do(proxyReq)
+	latency := time.Since(start)
 	if err != nil {
+		if permit != nil {
+			// 调用方断开不是上游的问题，不作为样本
+			if c.Request.Context().Err() != nil {
+				permit.Ignore()
+			} else {
+				permit.Dropped()
+			}
+		}
 		logger.Errorf("Upstream request failed: %v", err)
 		p.handleUpstreamError(c, err)
 		return
 	}
 	defer resp.Body.Close()
 
+	// 流式响应转发完成后才释放许可，延迟样本取上游返回响应头的耗时
+	if permit != nil {
+		defer func() {
+			switch resp.StatusCode {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+				permit.Dropped()
+			default:
+				permit.Success(latency)
+			}
+		}()
+	}
+
 	logger.Infof("Received response from upstream: status %d", resp.StatusCode)
 
 	// 转发响应
 	p.forwardResponse(c, resp)
 }
 
+// shed 超过上游并发限制时拒绝请求
+func (p *ProxyHandler) shed(c *gin.Context, upstream *upstreamLimiter) {
+	limit := upstream.limiter.Limit()
+	logger.Infof("Upstream %s overloaded, shedding request (concurrency limit %d)", upstream.name, limit)
+	metrics.GetMetrics().UpstreamShed.WithLabelValues(upstream.name).Inc()
+	c.Header(middleware.RetryAfterHeader, strconv.Itoa(p.retryAfter))
+	errors.RespondWithError(c, http.StatusServiceUnavailable,
+		errors.NewUpstreamOverloadedError(upstream.name, limit, p.retryAfter))
+}
+
 // getTargetURL 根据版本获取目标URL
 func (p *ProxyHandler) getTargetURL(version string) (string, error) {
 	if p.config == nil {
@@ -170,11 +271,20 @@ func (p *ProxyHandler) createProxyRequest(c *gin.Context, targetURL string) (*ht
 
 	// 复制请求头，但跳过一些不应该转发的头
 	skipHeaders := map[string]bool{
-		"host":           true,
-		"content-length": true,
-		"x-api-key":      true,
-		"x-signature":    true,
-		"x-timestamp":    true,
+		"host":                true,
+		"content-length":      true,
+		"x-api-key":           true,
+		"x-signature":         true,
+		"x-timestamp":         true,
+		"x-nonce":             true,
+		"x-signature-version": true,
+		"x-signed-headers":    true,
+		"x-key-id":            true,
+	}
+
+	// Bearer 令牌由网关消费，不转发给上游
+	if authMethod := c.GetString("auth_method"); authMethod == middleware.AuthMethodJWT || authMethod == middleware.AuthMethodOAuth {
+		skipHeaders["authorization"] = true
 	}
 
 	for name, values := range c.Request.Header {
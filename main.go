@@ -8,6 +8,7 @@ import (
 	"api-gateway/pkg/worker"
 	"api-gateway/repository"
 	"api-gateway/router"
+	"api-gateway/service"
 	"context"
 	"fmt"
 	"os"
@@ -58,10 +59,30 @@ func main() {
 		logger.Info("Redis queue initialized successfully")
 
 		workerPool = worker.NewWorkerPool(workerCount, taskQueue, taskRepo)
+	}
+
+	r, err := router.SetupRouter(dbManager, taskRepo, taskQueue)
+	if err != nil {
+		logger.Errorf("Failed to setup router: %v", err)
+		os.Exit(1)
+	}
+
+	// 监控指标在 SetupRouter 中初始化，worker 上报上游并发指标，需在其后启动
+	if workerPool != nil {
 		workerPool.Start()
 	}
 
-	r := router.SetupRouter(dbManager.ClientRepo, dbManager.CallLogRepo, taskRepo, taskQueue)
+	// 客户合同到期检查
+	var expiryJob *worker.ClientExpiryJob
+	if cfg.Expiry.Enabled {
+		expiryJob = worker.NewClientExpiryJob(dbManager.ClientRepo, cfg.Expiry)
+		expiryJob.Start()
+	}
+
+	// 退回超时未确认的调用预扣
+	billingService := service.NewBillingService(dbManager.ClientRepo, dbManager.ReservationRepo, cfg.Pricing)
+	sweepJob := worker.NewReservationSweepJob(billingService, time.Duration(cfg.Pricing.SweepInterval)*time.Second)
+	sweepJob.Start()
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
 	logger.Infof("API Gateway starting on port %d", cfg.Port)
@@ -88,6 +109,14 @@ func main() {
 		workerPool.Stop()
 	}
 
+	// 停止合同到期检查
+	if expiryJob != nil {
+		expiryJob.Stop()
+	}
+
+	// 停止预扣清理
+	sweepJob.Stop()
+
 	// 关闭任务队列
 	if taskQueue != nil {
 		taskQueue.Close()
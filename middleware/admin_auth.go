@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/service"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware 管理接口认证与权限中间件
+type AdminAuthMiddleware struct {
+	adminUserService *service.AdminUserService
+}
+
+// NewAdminAuthMiddleware 创建管理接口认证中间件
+func NewAdminAuthMiddleware(adminUserService *service.AdminUserService) *AdminAuthMiddleware {
+	return &AdminAuthMiddleware{
+		adminUserService: adminUserService,
+	}
+}
+
+// Authenticate 认证管理员，支持 HTTP Basic（用户名密码）和 Bearer / X-Admin-Token（访问令牌）
+func (a *AdminAuthMiddleware) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		var (
+			user *model.AdminUser
+			err  error
+		)
+		if username, password, ok := c.Request.BasicAuth(); ok {
+			user, err = a.adminUserService.Authenticate(ctx, username, password)
+		} else if token := a.extractToken(c); token != "" {
+			user, err = a.adminUserService.AuthenticateToken(ctx, token)
+		} else {
+			a.respondUnauthorized(c, "missing admin credentials")
+			return
+		}
+
+		if err != nil {
+			if strings.Contains(err.Error(), "invalid admin credentials") || strings.Contains(err.Error(), "disabled") {
+				logger.Infof("Admin authentication failed from %s: %v", c.ClientIP(), err)
+				a.respondUnauthorized(c, err.Error())
+				return
+			}
+			logger.Errorf("Database error during admin authentication: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    50000,
+				"message": "内部服务器错误",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("admin_user", user)
+		c.Next()
+	}
+}
+
+// RequirePermission 检查当前管理员是否拥有指定权限，需在 Authenticate 之后使用
+func (a *AdminAuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userInterface, exists := c.Get("admin_user")
+		user, ok := userInterface.(*model.AdminUser)
+		if !exists || !ok {
+			a.respondUnauthorized(c, "missing admin credentials")
+			return
+		}
+
+		if !user.HasPermission(permission) {
+			logger.Infof("Admin %s (%s) denied permission %s for %s %s",
+				user.Username, user.Role, permission, c.Request.Method, c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    40310,
+				"message": "Permission denied",
+				"error":   "missing permission " + permission,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// extractToken 从 Authorization: Bearer 或 X-Admin-Token 中提取访问令牌
+func (a *AdminAuthMiddleware) extractToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) > 7 && strings.EqualFold(authHeader[:7], "Bearer ") {
+		return strings.TrimSpace(authHeader[7:])
+	}
+	return c.GetHeader("X-Admin-Token")
+}
+
+func (a *AdminAuthMiddleware) respondUnauthorized(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", `Basic realm="api-gateway-admin"`)
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"code":    40120,
+		"message": "Admin authentication required",
+		"error":   reason,
+	})
+	c.Abort()
+}
@@ -5,6 +5,7 @@ import (
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
 	"api-gateway/pkg/queue"
+	"api-gateway/pkg/secrets"
 	"api-gateway/repository"
 	"bytes"
 	"io"
@@ -107,7 +108,7 @@ func (m *AsyncMiddleware) HandleAsync() gin.HandlerFunc {
 		task := model.NewTask(
 			taskID,
 			client.ID.Hex(),
-			client.APIKey,
+			secrets.KeyPrefix(client.APIKey), // 仅记录密钥前缀，避免明文密钥落库
 			c.Request.Method,
 			c.Request.URL.Path,
 			targetURLStr,
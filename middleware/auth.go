@@ -3,61 +3,98 @@ package middleware
 import (
 	"api-gateway/config"
 	"api-gateway/errors"
+	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/pkg/secrets"
 	"api-gateway/repository"
+	"api-gateway/service"
 	"context"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// lastUsedUpdateInterval 密钥最后使用时间的最小更新间隔，避免每次请求都写库
+const lastUsedUpdateInterval = time.Minute
+
 // AuthMiddleware 认证中间件
 type AuthMiddleware struct {
 	clientRepo         repository.ClientRepository
+	credentialRepo     repository.CredentialRepository
+	keyResolver        *service.KeyResolver
 	signatureValidator SignatureValidator
+	jwtAuthenticator   *JWTAuthenticator     // 为空时不启用 Bearer JWT 认证
+	oauthService       *service.OAuthService // 为空时不接受网关签发的访问令牌
+	authGuard          *service.AuthGuard    // 为空时不启用认证失败防护
 	config             *config.Config
 }
 
 // NewAuthMiddleware 创建认证中间件
-func NewAuthMiddleware(clientRepo repository.ClientRepository, signatureValidator SignatureValidator, cfg *config.Config) *AuthMiddleware {
+// oauthService 为空时不启用 OAuth2 访问令牌认证，authGuard 为空时不启用认证失败防护
+func NewAuthMiddleware(clientRepo repository.ClientRepository, credentialRepo repository.CredentialRepository,
+	signatureValidator SignatureValidator, oauthService *service.OAuthService, authGuard *service.AuthGuard,
+	cfg *config.Config) *AuthMiddleware {
+	var jwtAuthenticator *JWTAuthenticator
+	if cfg.Auth.JWT.Enabled {
+		jwtAuthenticator = NewJWTAuthenticator(clientRepo, cfg.Auth.JWT)
+	}
+
 	return &AuthMiddleware{
 		clientRepo:         clientRepo,
+		credentialRepo:     credentialRepo,
+		keyResolver:        service.NewKeyResolver(clientRepo, credentialRepo),
 		signatureValidator: signatureValidator,
+		jwtAuthenticator:   jwtAuthenticator,
+		oauthService:       oauthService,
+		authGuard:          authGuard,
 		config:             cfg,
 	}
 }
 
+// 认证方式
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+	AuthMethodOAuth  = "oauth"
+)
+
 // Authenticate 认证中间件处理函数
 func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 提取API密钥
-		apiKey := a.extractAPIKey(c)
-		if apiKey == "" {
-			errors.RespondWithError(c, http.StatusUnauthorized, errors.NewInvalidAPIKeyError())
-			return
-		}
-
 		// 创建超时上下文
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 		defer cancel()
 
-		// 根据API密钥查找客户
-		client, err := a.clientRepo.GetByAPIKey(ctx, apiKey)
-		if err != nil {
-			if strings.Contains(err.Error(), "not found") {
-				logger.Infof("Authentication failed: invalid API key %s", apiKey)
-				errors.RespondWithError(c, http.StatusUnauthorized, errors.NewInvalidAPIKeyError())
-				return
-			}
-			// 数据库错误，返回内部服务器错误
-			logger.Errorf("Database error during authentication: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    50000,
-				"message": "内部服务器错误",
-			})
-			c.Abort()
+		// 被封禁的IP或密钥直接拒绝，不再查询数据库
+		if a.rejectBanned(ctx, c) {
+			return
+		}
+
+		var (
+			client     *model.Client
+			credential *model.Credential
+			ok         bool
+		)
+
+		authMethod := AuthMethodAPIKey
+		bearerToken := a.extractBearerToken(c)
+		switch {
+		case bearerToken != "" && a.oauthService != nil && a.oauthService.IsGatewayToken(bearerToken):
+			// 网关签发的访问令牌，客户信息从缓存或数据库加载
+			authMethod = AuthMethodOAuth
+			client, ok = a.authenticateOAuth(ctx, c, bearerToken)
+		case bearerToken != "" && a.jwtAuthenticator != nil:
+			authMethod = AuthMethodJWT
+			client, ok = a.authenticateJWT(ctx, c, bearerToken)
+		default:
+			client, credential, ok = a.authenticateAPIKey(ctx, c)
+		}
+		if !ok {
 			return
 		}
 
@@ -68,10 +105,37 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			return
 		}
 
-		// 签名验证（如果启用）
-		if a.config.Auth.EnableSignature {
+		// 合同有效期，到期后台任务禁用客户之前同样拒绝
+		switch client.CheckValidity(time.Now()) {
+		case model.ClientValidityNotStarted:
+			logger.Infof("Authentication failed: client %s is not valid until %s", client.ID.Hex(), client.ValidFrom.Format(time.RFC3339))
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewClientNotYetValidError(client.ID.Hex(), *client.ValidFrom))
+			return
+		case model.ClientValidityExpired:
+			logger.Infof("Authentication failed: client %s expired at %s", client.ID.Hex(), client.ValidUntil.Format(time.RFC3339))
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewClientExpiredError(client.ID.Hex(), *client.ValidUntil))
+			return
+		}
+
+		// IP 访问控制，客户端 IP 由可信代理配置决定
+		clientIP := c.ClientIP()
+		if rule := client.CheckIP(clientIP); rule != model.IPRuleAllowed {
+			logger.Infof("Authentication failed: IP %s of client %s rejected by %s", clientIP, client.ID.Hex(), rule)
+			metrics.GetMetrics().IPRejections.WithLabelValues(clientMetricLabel(client), rule).Inc()
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewIPNotAllowedError(clientIP, client.ID.Hex()))
+			return
+		}
+
+		// 签名验证（如果启用），JWT 和访问令牌本身已携带签名，无需再校验请求签名
+		if a.config.Auth.EnableSignature && authMethod == AuthMethodAPIKey {
 			if err := a.signatureValidator.ValidateSignature(c.Request, client); err != nil {
+				if strings.Contains(err.Error(), "nonce store unavailable") {
+					logger.Errorf("Nonce check failed for client %s: %v", client.ID.Hex(), err)
+					a.respondInternalError(c)
+					return
+				}
 				logger.Infof("Signature validation failed for client %s: %v", client.ID.Hex(), err)
+				a.recordAuthFailure(ctx, c, a.extractAPIKey(c), "invalid_signature")
 				a.handleSignatureError(c, err)
 				return
 			}
@@ -80,13 +144,160 @@ func (a *AuthMiddleware) Authenticate() gin.HandlerFunc {
 
 		// 将客户信息存储到上下文中，供后续中间件使用
 		c.Set("client", client)
-		c.Set("api_key", apiKey)
+		c.Set("auth_method", authMethod)
+		if authMethod == AuthMethodAPIKey {
+			c.Set("api_key", client.APIKey)
+		}
+		if credential != nil {
+			c.Set("credential", credential)
+			a.touchCredential(credential)
+		}
 
-		logger.Infof("Authentication successful for client %s (%s)", client.ID.Hex(), client.Name)
+		logger.Infof("Authentication successful for client %s (%s) via %s", client.ID.Hex(), client.Name, authMethod)
 		c.Next()
 	}
 }
 
+// authenticateAPIKey 使用 X-API-Key 认证，失败时直接写入响应
+func (a *AuthMiddleware) authenticateAPIKey(ctx context.Context, c *gin.Context) (*model.Client, *model.Credential, bool) {
+	// 提取API密钥
+	apiKey := a.extractAPIKey(c)
+	if apiKey == "" {
+		errors.RespondWithError(c, http.StatusUnauthorized, errors.NewInvalidAPIKeyError())
+		return nil, nil, false
+	}
+
+	// 根据API密钥查找客户
+	client, credential, err := a.keyResolver.Resolve(ctx, apiKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			logger.Infof("Authentication failed: invalid API key %s", secrets.KeyPrefix(apiKey))
+			a.recordAuthFailure(ctx, c, apiKey, "invalid_key")
+			errors.RespondWithError(c, http.StatusUnauthorized, errors.NewInvalidAPIKeyError())
+			return nil, nil, false
+		}
+		// 数据库错误，返回内部服务器错误
+		logger.Errorf("Database error during authentication: %v", err)
+		a.respondInternalError(c)
+		return nil, nil, false
+	}
+
+	return client, credential, true
+}
+
+// authenticateJWT 使用 Bearer JWT 认证，失败时直接写入响应
+func (a *AuthMiddleware) authenticateJWT(ctx context.Context, c *gin.Context, token string) (*model.Client, bool) {
+	client, err := a.jwtAuthenticator.Authenticate(ctx, token)
+	if err != nil {
+		if !strings.Contains(err.Error(), "token") {
+			logger.Errorf("Database error during JWT authentication: %v", err)
+			a.respondInternalError(c)
+			return nil, false
+		}
+		logger.Infof("JWT authentication failed: %v", err)
+		a.recordAuthFailure(ctx, c, "", "invalid_token")
+		a.handleTokenError(c, err)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// authenticateOAuth 使用网关签发的访问令牌认证，失败时直接写入响应
+func (a *AuthMiddleware) authenticateOAuth(ctx context.Context, c *gin.Context, token string) (*model.Client, bool) {
+	claims, err := a.oauthService.ValidateToken(ctx, token)
+	if err != nil {
+		if !strings.Contains(err.Error(), "token") {
+			logger.Errorf("Revocation check failed during OAuth authentication: %v", err)
+			a.respondInternalError(c)
+			return nil, false
+		}
+		logger.Infof("OAuth token authentication failed: %v", err)
+		a.handleTokenError(c, err)
+		return nil, false
+	}
+
+	// 以数据库中的客户为准，令牌签发后被禁用的客户或被吊销的凭证同样拒绝
+	client, err := a.oauthService.ClientForToken(ctx, claims)
+	if err != nil {
+		if !strings.Contains(err.Error(), "token") {
+			logger.Errorf("Database error during OAuth authentication: %v", err)
+			a.respondInternalError(c)
+			return nil, false
+		}
+		logger.Infof("OAuth token authentication failed: %v", err)
+		a.handleTokenError(c, err)
+		return nil, false
+	}
+
+	c.Set("token_claims", claims)
+	return client, true
+}
+
+// rejectBanned 检查客户端IP和API密钥是否被封禁，被封禁时写入响应并返回 true
+func (a *AuthMiddleware) rejectBanned(ctx context.Context, c *gin.Context) bool {
+	if a.authGuard == nil {
+		return false
+	}
+
+	ban, err := a.authGuard.Check(ctx, c.ClientIP(), a.extractAPIKey(c))
+	if err != nil {
+		// 防护存储不可用时放行，避免影响正常客户
+		logger.Errorf("Failed to check auth bans: %v", err)
+		return false
+	}
+	if ban == nil {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(ban.ExpiresAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	subject := ban.Subject
+	if ban.KeyPrefix != "" {
+		subject = ban.KeyPrefix
+	}
+	logger.Infof("Authentication rejected: %s %s is banned until %s", ban.Scope, subject, ban.ExpiresAt.Format(time.RFC3339))
+	c.Header(RetryAfterHeader, strconv.Itoa(retryAfter))
+	errors.RespondWithError(c, http.StatusTooManyRequests, errors.NewAuthBannedError(ban.Scope, retryAfter))
+	return true
+}
+
+// recordAuthFailure 记录一次认证失败，按IP和完整密钥计数，apiKey 为空时仅按IP计数
+func (a *AuthMiddleware) recordAuthFailure(ctx context.Context, c *gin.Context, apiKey, reason string) {
+	if a.authGuard == nil {
+		return
+	}
+	a.authGuard.RecordFailure(ctx, c.ClientIP(), apiKey, reason)
+}
+
+// respondInternalError 返回内部服务器错误
+func (a *AuthMiddleware) respondInternalError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"code":    50000,
+		"message": "内部服务器错误",
+	})
+	c.Abort()
+}
+
+// touchCredential 异步更新凭证的最后使用时间
+func (a *AuthMiddleware) touchCredential(credential *model.Credential) {
+	now := time.Now()
+	if credential.LastUsedAt != nil && now.Sub(*credential.LastUsedAt) < lastUsedUpdateInterval {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := a.credentialRepo.UpdateLastUsed(ctx, credential.ID, now); err != nil {
+			logger.Errorf("Failed to update last used time of credential %s: %v", credential.ID.Hex(), err)
+		}
+	}()
+}
+
 // extractAPIKey 从请求中提取API密钥
 func (a *AuthMiddleware) extractAPIKey(c *gin.Context) string {
 	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
@@ -95,6 +306,62 @@ func (a *AuthMiddleware) extractAPIKey(c *gin.Context) string {
 	return ""
 }
 
+// extractBearerToken 从 Authorization 头中提取 Bearer 令牌
+func (a *AuthMiddleware) extractBearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) > 7 && strings.EqualFold(authHeader[:7], "Bearer ") {
+		return strings.TrimSpace(authHeader[7:])
+	}
+	return ""
+}
+
+// handleTokenError 处理JWT验证错误
+func (a *AuthMiddleware) handleTokenError(c *gin.Context, err error) {
+	errMsg := err.Error()
+
+	switch {
+	case strings.Contains(errMsg, "malformed token"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40110,
+			"message": "Token validation failed",
+			"error":   "malformed token",
+		})
+	case strings.Contains(errMsg, "token expired"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40111,
+			"message": "Token validation failed",
+			"error":   "token expired",
+		})
+	case strings.Contains(errMsg, "invalid token signature"),
+		strings.Contains(errMsg, "unsupported token algorithm"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40112,
+			"message": "Token validation failed",
+			"error":   "invalid token signature",
+		})
+	case strings.Contains(errMsg, "token revoked"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40114,
+			"message": "Token validation failed",
+			"error":   "token revoked",
+		})
+	case strings.Contains(errMsg, "invalid token claims"),
+		strings.Contains(errMsg, "token not yet valid"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40113,
+			"message": "Token validation failed",
+			"error":   "invalid token claims",
+		})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40100,
+			"message": "Token validation failed",
+			"error":   "token validation error",
+		})
+	}
+	c.Abort()
+}
+
 // handleSignatureError 处理签名验证错误
 func (a *AuthMiddleware) handleSignatureError(c *gin.Context, err error) {
 	errMsg := err.Error()
@@ -124,6 +391,37 @@ func (a *AuthMiddleware) handleSignatureError(c *gin.Context, err error) {
 			"message": "Signature validation failed",
 			"error":   "timestamp expired",
 		})
+	case strings.Contains(errMsg, "missing nonce"),
+		strings.Contains(errMsg, "invalid nonce"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40106,
+			"message": "Signature validation failed",
+			"error":   "missing or invalid nonce",
+		})
+	case strings.Contains(errMsg, "nonce replayed"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40107,
+			"message": "Signature validation failed",
+			"error":   "nonce already used",
+		})
+	case strings.Contains(errMsg, "unsupported signature version"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40108,
+			"message": "Signature validation failed",
+			"error":   "unsupported signature version",
+		})
+	case strings.Contains(errMsg, "invalid signed headers"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40109,
+			"message": "Signature validation failed",
+			"error":   errMsg,
+		})
+	case strings.Contains(errMsg, "invalid key id"):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40115,
+			"message": "Signature validation failed",
+			"error":   "invalid key id",
+		})
 	case strings.Contains(errMsg, "invalid signature"):
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    40105,
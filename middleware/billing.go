@@ -1,32 +1,76 @@
 package middleware
 
 import (
+	"api-gateway/config"
 	"api-gateway/errors"
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/routescope"
 	"api-gateway/repository"
+	"api-gateway/service"
 	"context"
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RemainingCallsHeader 剩余调用次数响应头，按本次调用扣费后的余额计算
+const RemainingCallsHeader = "X-Remaining-Calls"
+
 // BillingMiddleware 计费中间件
+// 每次成功调用按路由价格扣减调用次数，未配置价格的路由扣减1次
+// 代理之前原子预扣，成功时确认，失败时退回，并发请求不会透支余额
 type BillingMiddleware struct {
 	clientRepo repository.ClientRepository
 	logRepo    repository.CallLogRepository
+	billing    *service.BillingService
+	quota      *service.QuotaService
+	prices     []config.RoutePrice // 路由价格，按顺序匹配第一个
 }
 
-// NewBillingMiddleware 创建计费中间件
-func NewBillingMiddleware(clientRepo repository.ClientRepository, logRepo repository.CallLogRepository) *BillingMiddleware {
+// NewBillingMiddleware 创建计费中间件，价格配置无效时返回错误
+func NewBillingMiddleware(clientRepo repository.ClientRepository, logRepo repository.CallLogRepository, billing *service.BillingService, quota *service.QuotaService, cfg config.PricingConfig) (*BillingMiddleware, error) {
+	for _, price := range cfg.Prices {
+		if !strings.HasPrefix(price.Route, "/") || strings.Contains(strings.TrimSuffix(price.Route, "*"), "*") {
+			return nil, fmt.Errorf("invalid pricing config: invalid route pattern %q", price.Route)
+		}
+		if price.Cost <= 0 {
+			return nil, fmt.Errorf("invalid pricing config %s: cost must be positive", price.Route)
+		}
+	}
+
 	return &BillingMiddleware{
 		clientRepo: clientRepo,
 		logRepo:    logRepo,
+		billing:    billing,
+		quota:      quota,
+		prices:     cfg.Prices,
+	}, nil
+}
+
+// CostFor 返回客户调用路由需要扣减的次数，按顺序匹配路由、版本和套餐，未匹配时为1
+func (b *BillingMiddleware) CostFor(client *model.Client, route string) int {
+	for _, price := range b.prices {
+		if price.Version != "" && price.Version != client.Version {
+			continue
+		}
+		if price.Plan != "" && price.Plan != client.Plan {
+			continue
+		}
+		if routescope.MatchAny([]string{price.Route}, route) {
+			return price.Cost
+		}
 	}
+	return 1
 }
 
-// CheckCalls 检查调用次数（不扣减）
+// CheckCalls 预扣本次调用的价格，余额不足时返回 402
+// 后续中间件中止请求（如配额超限）或处理出错时，未确认的预扣在这里退回
 func (b *BillingMiddleware) CheckCalls() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从上下文中获取客户信息（由认证中间件设置）
@@ -50,68 +94,120 @@ func (b *BillingMiddleware) CheckCalls() gin.HandlerFunc {
 			return
 		}
 
-		// 检查剩余调用次数
-		if !client.HasCallsRemaining() {
-			logger.Infof("Billing check failed: client %s has insufficient calls (remaining: %d)",
-				client.ID.Hex(), client.CallCount)
-			errors.RespondWithError(c, http.StatusPaymentRequired,
-				errors.NewInsufficientCallsError(client.CallCount, client.ID.Hex()))
+		// 原子预扣本次调用的价格
+		cost := b.CostFor(client, c.Request.URL.Path)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		reservation, remaining, err := b.billing.Reserve(ctx, client, c.Request.URL.Path, cost)
+		cancel()
+		if err != nil {
+			if stderrors.Is(err, service.ErrInsufficientCalls) {
+				// 缓存的余额可能已过期，不超过本次价格时才返回
+				remaining = client.CallCount
+				if remaining >= cost || remaining < 0 {
+					remaining = 0
+				}
+				logger.Infof("Billing check failed: client %s has insufficient calls (remaining: %d, cost: %d)",
+					client.ID.Hex(), remaining, cost)
+				c.Header(RemainingCallsHeader, strconv.Itoa(remaining))
+				errors.RespondWithError(c, http.StatusPaymentRequired,
+					errors.NewInsufficientCallsError(remaining, cost, client.ID.Hex()))
+				return
+			}
+
+			logger.Errorf("Failed to reserve %d calls for client %s: %v", cost, client.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    50000,
+				"message": "内部服务器错误：扣费失败",
+			})
+			c.Abort()
 			return
 		}
+		defer b.releaseUnsettled(c, reservation)
+
+		// 响应头返回预扣后的余额
+		client.CallCount = remaining
+		c.Header(RemainingCallsHeader, strconv.Itoa(remaining))
 
 		// 记录调用开始时间，用于后续日志记录
 		c.Set("billing_start_time", time.Now())
 		c.Set("billing_checked", true) // 标记已检查过次数
+		c.Set("billing_cost", cost)
+		c.Set("billing_reservation", reservation)
 
-		logger.Infof("Billing check passed: client %s has %d calls remaining",
-			client.ID.Hex(), client.CallCount)
+		logger.Infof("Billing check passed: reserved %d calls from client %s, %d calls remaining",
+			cost, client.ID.Hex(), remaining)
 		c.Next()
 	}
 }
 
-// DeductCalls 扣减调用次数（仅在响应成功时调用）
+// releaseUnsettled 退回请求结束时仍未确认的预扣
+// 响应成功后确认失败（如数据库暂时不可用）的预扣不退回，由清理任务处理
+func (b *BillingMiddleware) releaseUnsettled(c *gin.Context, reservation *model.CallReservation) {
+	if reservation.Status != model.ReservationHeld || c.GetBool("billing_commit_attempted") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.billing.Release(ctx, reservation); err != nil {
+		// 清理任务会在预扣过期后退回
+		logger.Errorf("Failed to release call reservation %s: %v", reservation.ID.Hex(), err)
+		return
+	}
+	logger.Infof("Released %d calls of unsettled reservation %s", reservation.Cost, reservation.ID.Hex())
+}
+
+// DeductCalls 结算预扣：响应成功时确认扣费，否则退回预扣和已计入的调用配额
 func (b *BillingMiddleware) DeductCalls() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		// 只有在响应状态码为200时才扣减次数
-		if c.Writer.Status() != http.StatusOK {
-			logger.Infof("Request failed with status %d, skipping billing deduction", c.Writer.Status())
-			return
-		}
-
-		// 检查是否已经检查过次数
-		if checked, exists := c.Get("billing_checked"); !exists || !checked.(bool) {
+		// 检查是否已经预扣
+		value, exists := c.Get("billing_reservation")
+		reservation, ok := value.(*model.CallReservation)
+		if !exists || !ok {
 			logger.Errorf("Billing deduction called without prior check")
 			return
 		}
 
-		// 从上下文中获取客户信息
-		clientInterface, exists := c.Get("client")
-		if !exists {
-			logger.Error("Client information not found during billing deduction")
-			return
-		}
-
-		client, ok := clientInterface.(*model.Client)
-		if !ok {
-			logger.Error("Invalid client information type during billing deduction")
-			return
-		}
-
 		// 创建超时上下文
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		// 原子性地扣减调用次数
-		err := b.clientRepo.DeductCallCount(ctx, client.ID)
-		if err != nil {
-			// 扣减失败，记录错误但不影响响应（因为请求已经成功）
-			logger.Errorf("Failed to deduct call count for client %s: %v", client.ID.Hex(), err)
+		// 只有在响应状态码为200时才扣费
+		if c.Writer.Status() != http.StatusOK {
+			b.releaseQuota(ctx, c)
+			if err := b.billing.Release(ctx, reservation); err != nil {
+				// 清理任务会在预扣过期后退回
+				logger.Errorf("Failed to release call reservation %s: %v", reservation.ID.Hex(), err)
+				return
+			}
+			logger.Infof("Request failed with status %d, released %d reserved calls", c.Writer.Status(), reservation.Cost)
 			return
 		}
 
-		logger.Infof("Billing deduction successful: deducted 1 call from client %s", client.ID.Hex())
+		// 调用已成功，确认失败时也不能退回
+		c.Set("billing_commit_attempted", true)
+		if err := b.billing.Commit(ctx, reservation); err != nil {
+			// 确认失败，记录错误但不影响响应（因为请求已经成功）
+			logger.Errorf("Failed to commit call reservation %s for client %s: %v",
+				reservation.ID.Hex(), reservation.ClientID.Hex(), err)
+			return
+		}
+
+		logger.Infof("Billing deduction successful: deducted %d calls from client %s", reservation.Cost, reservation.ClientID.Hex())
+	}
+}
+
+// releaseQuota 退回 CheckQuota 计入的调用配额
+func (b *BillingMiddleware) releaseQuota(ctx context.Context, c *gin.Context) {
+	if b.quota == nil {
+		return
+	}
+	value, exists := c.Get("quota_result")
+	if result, ok := value.(*service.QuotaResult); exists && ok {
+		b.quota.Release(ctx, result)
 	}
 }
 
@@ -140,11 +236,12 @@ func (b *BillingMiddleware) CheckAndDeduct() gin.HandlerFunc {
 		}
 
 		// 检查剩余调用次数
-		if !client.HasCallsRemaining() {
-			logger.Infof("Billing check failed: client %s has insufficient calls (remaining: %d)",
-				client.ID.Hex(), client.CallCount)
+		cost := b.CostFor(client, c.Request.URL.Path)
+		if !client.HasCallsFor(cost) {
+			logger.Infof("Billing check failed: client %s has insufficient calls (remaining: %d, cost: %d)",
+				client.ID.Hex(), client.CallCount, cost)
 			errors.RespondWithError(c, http.StatusPaymentRequired,
-				errors.NewInsufficientCallsError(client.CallCount, client.ID.Hex()))
+				errors.NewInsufficientCallsError(client.CallCount, cost, client.ID.Hex()))
 			return
 		}
 
@@ -153,13 +250,13 @@ func (b *BillingMiddleware) CheckAndDeduct() gin.HandlerFunc {
 		defer cancel()
 
 		// 原子性地扣减调用次数
-		err := b.clientRepo.DeductCallCount(ctx, client.ID)
+		remaining, err := b.clientRepo.DeductCallCount(ctx, client.ID, cost)
 		if err != nil {
 			// 如果扣减失败，检查是否是因为余额不足
-			if err.Error() == "insufficient calls" {
+			if stderrors.Is(err, service.ErrInsufficientCalls) {
 				logger.Infof("Billing deduction failed: client %s has insufficient calls", client.ID.Hex())
 				errors.RespondWithError(c, http.StatusPaymentRequired,
-					errors.NewInsufficientCallsError(0, client.ID.Hex()))
+					errors.NewInsufficientCallsError(0, cost, client.ID.Hex()))
 				return
 			}
 
@@ -173,15 +270,17 @@ func (b *BillingMiddleware) CheckAndDeduct() gin.HandlerFunc {
 			return
 		}
 
-		// 更新上下文中的客户信息（减少一次调用）
-		client.DecrementCallCount()
+		// 更新上下文中的客户信息
+		client.CallCount = remaining
+		client.UpdatedAt = time.Now()
 		c.Set("client", client)
 
 		// 记录调用开始时间，用于后续日志记录
 		c.Set("billing_start_time", time.Now())
+		c.Set("billing_cost", cost)
 
-		logger.Infof("Billing successful: deducted 1 call from client %s (remaining: %d)",
-			client.ID.Hex(), client.CallCount)
+		logger.Infof("Billing successful: deducted %d calls from client %s (remaining: %d)",
+			cost, client.ID.Hex(), client.CallCount)
 		c.Next()
 	}
 }
@@ -0,0 +1,307 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"api-gateway/errors"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 跨域默认配置
+var (
+	defaultCORSMethods = []string{"GET", "POST"}
+	defaultCORSHeaders = []string{
+		"content-type", "authorization", "x-api-key", "x-signature", "x-timestamp", "x-nonce",
+		"x-signature-version", "x-signed-headers", "x-key-id", "x-request-id",
+		"x-async", "x-callback-url", "x-callback-method", "x-callback-auth",
+	}
+	defaultCORSExposedHeaders = []string{
+		RequestIDHeader, RetryAfterHeader, RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader,
+		RemainingCallsHeader,
+	}
+)
+
+// corsSafelistedHeaders 浏览器无需预检即可发送的请求头，始终允许
+var corsSafelistedHeaders = []string{"accept", "accept-language", "content-language"}
+
+// CORSMiddleware 浏览器跨域中间件
+// 预检请求在认证之前直接返回，不计费；浏览器端密钥未登记的来源由 CheckOrigin 在认证之后拒绝
+type CORSMiddleware struct {
+	clientRepo       repository.ClientRepository
+	allowedOrigins   []string
+	allowedMethods   []string
+	allowedHeaders   []string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+	cacheTTL         time.Duration
+
+	mu       sync.RWMutex
+	policies map[primitive.ObjectID]*model.CORSPolicy // 客户ID -> 跨域策略
+	loadedAt time.Time
+}
+
+// NewCORSMiddleware 创建跨域中间件，全局来源配置无效时返回错误
+func NewCORSMiddleware(clientRepo repository.ClientRepository, cfg config.CORSConfig) (*CORSMiddleware, error) {
+	m := &CORSMiddleware{
+		clientRepo:       clientRepo,
+		allowedMethods:   defaultCORSMethods,
+		allowedHeaders:   defaultCORSHeaders,
+		exposedHeaders:   strings.Join(defaultCORSExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+		maxAge:           "600",
+		cacheTTL:         30 * time.Second,
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		normalized, err := model.NormalizeOrigin(origin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS config: %w", err)
+		}
+		// 携带凭证时回显任意来源等于允许任意网站以用户身份调用接口
+		if normalized == "*" && cfg.AllowCredentials {
+			return nil, fmt.Errorf("invalid CORS config: allow_credentials cannot be used with origin *")
+		}
+		m.allowedOrigins = append(m.allowedOrigins, normalized)
+	}
+	if len(cfg.AllowedMethods) > 0 {
+		m.allowedMethods = nil
+		for _, method := range cfg.AllowedMethods {
+			m.allowedMethods = append(m.allowedMethods, strings.ToUpper(strings.TrimSpace(method)))
+		}
+	}
+	if len(cfg.AllowedHeaders) > 0 {
+		m.allowedHeaders = nil
+		for _, header := range cfg.AllowedHeaders {
+			m.allowedHeaders = append(m.allowedHeaders, strings.ToLower(strings.TrimSpace(header)))
+		}
+	}
+	if len(cfg.ExposedHeaders) > 0 {
+		m.exposedHeaders = strings.Join(cfg.ExposedHeaders, ", ")
+	}
+	if cfg.MaxAge > 0 {
+		m.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+	if cfg.PolicyCacheTTL > 0 {
+		m.cacheTTL = time.Duration(cfg.PolicyCacheTTL) * time.Second
+	}
+
+	return m, nil
+}
+
+// Handle 处理跨域请求头，预检请求在这里直接返回
+// 来源既不在全局配置中也不在任何客户的策略中时，不返回跨域响应头，由浏览器拦截
+func (m *CORSMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		methods, headers, allowed := m.resolve(c.Request.Context(), origin)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			m.handlePreflight(c, origin, methods, headers, allowed)
+			return
+		}
+
+		if allowed {
+			m.setOriginHeaders(c, origin)
+			if m.exposedHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", m.exposedHeaders)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Options 处理非预检的 OPTIONS 请求
+func (m *CORSMiddleware) Options() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Allow", "OPTIONS, "+strings.Join(m.allowedMethods, ", "))
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// CheckOrigin 浏览器端密钥的来源检查，需在认证之后使用
+// 配置了允许来源的客户只接受来自这些来源的浏览器请求；其他客户和不带 Origin 的请求不受限制
+func (m *CORSMiddleware) CheckOrigin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		clientInterface, _ := c.Get("client")
+		client, ok := clientInterface.(*model.Client)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		// 只有配置了允许来源的浏览器端密钥才检查来源，服务端密钥携带的 Origin 头不受限制
+		if !client.IsBrowserKey() {
+			c.Next()
+			return
+		}
+
+		if !model.MatchOrigin(client.CORS.AllowedOrigins, origin) {
+			logger.Infof("Origin %s of client %s is not allowed", origin, client.ID.Hex())
+			metrics.GetMetrics().OriginRejections.WithLabelValues(clientMetricLabel(client)).Inc()
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewOriginNotAllowedError(origin, client.ID.Hex()))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// handlePreflight 响应预检请求，请求的方法或请求头不允许时返回 403
+func (m *CORSMiddleware) handlePreflight(c *gin.Context, origin string, methods, headers []string, allowed bool) {
+	method := strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+	if !allowed || !containsFold(methods, method) {
+		logger.Infof("CORS preflight rejected: origin %s, method %s", origin, method)
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	for _, header := range strings.Split(c.GetHeader("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header == "" || containsFold(corsSafelistedHeaders, header) {
+			continue
+		}
+		if !containsFold(headers, header) {
+			logger.Infof("CORS preflight rejected: origin %s, header %s", origin, header)
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+
+	m.setOriginHeaders(c, origin)
+	c.Header("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	c.Header("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	c.Header("Access-Control-Max-Age", m.maxAge)
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// setOriginHeaders 回显请求来源，不使用 *，以便支持携带凭证
+func (m *CORSMiddleware) setOriginHeaders(c *gin.Context, origin string) {
+	c.Header("Access-Control-Allow-Origin", origin)
+	if m.allowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// resolve 返回来源允许的方法和请求头
+// 预检请求不携带密钥，无法确定客户，因此合并所有允许该来源的客户策略
+func (m *CORSMiddleware) resolve(ctx context.Context, origin string) ([]string, []string, bool) {
+	if model.MatchOrigin(m.allowedOrigins, origin) {
+		return m.allowedMethods, m.allowedHeaders, true
+	}
+
+	var methods, headers []string
+	allowed := false
+	for _, policy := range m.loadPolicies(ctx) {
+		if !model.MatchOrigin(m.policyOrigins(policy), origin) {
+			continue
+		}
+		allowed = true
+
+		policyMethods := policy.AllowedMethods
+		if len(policyMethods) == 0 {
+			policyMethods = m.allowedMethods
+		}
+		policyHeaders := policy.AllowedHeaders
+		if len(policyHeaders) == 0 {
+			policyHeaders = m.allowedHeaders
+		}
+		methods = mergeUnique(methods, policyMethods)
+		headers = mergeUnique(headers, policyHeaders)
+	}
+
+	return methods, headers, allowed
+}
+
+// policyOrigins 返回客户策略中可用于跨域响应的来源，允许携带凭证时忽略 *
+func (m *CORSMiddleware) policyOrigins(policy *model.CORSPolicy) []string {
+	if !m.allowCredentials {
+		return policy.AllowedOrigins
+	}
+
+	origins := make([]string, 0, len(policy.AllowedOrigins))
+	for _, origin := range policy.AllowedOrigins {
+		if origin != "*" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// loadPolicies 返回缓存的客户跨域策略，过期后重新加载；加载失败时继续使用旧数据
+func (m *CORSMiddleware) loadPolicies(ctx context.Context) map[primitive.ObjectID]*model.CORSPolicy {
+	m.mu.RLock()
+	if time.Since(m.loadedAt) < m.cacheTTL {
+		policies := m.policies
+		m.mu.RUnlock()
+		return policies
+	}
+	m.mu.RUnlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.loadedAt) < m.cacheTTL {
+		return m.policies
+	}
+	m.loadedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	clients, err := m.clientRepo.FindCORSPolicies(ctx)
+	if err != nil {
+		logger.Errorf("Failed to load client CORS policies: %v", err)
+		return m.policies
+	}
+
+	policies := make(map[primitive.ObjectID]*model.CORSPolicy, len(clients))
+	for _, client := range clients {
+		if client.CORS != nil {
+			policies[client.ID] = client.CORS
+		}
+	}
+	m.policies = policies
+	return policies
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeUnique(values, add []string) []string {
+	for _, value := range add {
+		if !containsFold(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
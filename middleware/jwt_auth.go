@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/jwt"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// minHS256KeyLength HS256 密钥最小长度（字节），与 SHA-256 输出长度一致
+const minHS256KeyLength = 32
+
+// JWTAuthenticator Bearer JWT 认证器
+type JWTAuthenticator struct {
+	clientRepo  repository.ClientRepository
+	keys        jwt.KeySet
+	algorithms  map[string]bool
+	clientClaim string
+	audience    string
+	issuer      string
+	clockSkew   time.Duration
+}
+
+// NewJWTAuthenticator 创建JWT认证器
+// JWKS 文件加载失败时仅记录错误，RS256/ES256 令牌将因找不到公钥而被拒绝
+func NewJWTAuthenticator(clientRepo repository.ClientRepository, cfg config.JWTConfig) *JWTAuthenticator {
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{jwt.AlgHS256, jwt.AlgRS256, jwt.AlgES256}
+	}
+	allowed := make(map[string]bool, len(algorithms))
+	for _, alg := range algorithms {
+		allowed[alg] = true
+	}
+
+	clientClaim := cfg.ClientClaim
+	if clientClaim == "" {
+		clientClaim = "sub"
+	}
+
+	clockSkew := time.Duration(cfg.ClockSkew) * time.Second
+	if cfg.ClockSkew == 0 {
+		clockSkew = 60 * time.Second // 默认允许60秒时钟偏差
+	}
+
+	keys := jwt.KeySet{}
+	if cfg.JWKSFile != "" {
+		loaded, err := jwt.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			logger.Errorf("Failed to load JWKS: %v", err)
+		} else {
+			keys = loaded
+			logger.Infof("Loaded %d JWKS keys from %s", len(keys), cfg.JWKSFile)
+		}
+	}
+
+	return &JWTAuthenticator{
+		clientRepo:  clientRepo,
+		keys:        keys,
+		algorithms:  allowed,
+		clientClaim: clientClaim,
+		audience:    cfg.Audience,
+		issuer:      cfg.Issuer,
+		clockSkew:   clockSkew,
+	}
+}
+
+// Authenticate 验证JWT并返回对应的客户
+func (j *JWTAuthenticator) Authenticate(ctx context.Context, rawToken string) (*model.Client, error) {
+	token, err := jwt.Parse(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !j.algorithms[token.Header.Alg] {
+		return nil, fmt.Errorf("unsupported token algorithm: %s", token.Header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := token.DecodeClaims(&claims); err != nil {
+		return nil, err
+	}
+	subject, _ := claims[j.clientClaim].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("invalid token claims: missing %s", j.clientClaim)
+	}
+
+	client, err := j.lookupClient(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// HS256 只能使用客户密钥，RS256/ES256 只能使用 JWKS 公钥，避免算法混淆
+	var key interface{}
+	switch token.Header.Alg {
+	case jwt.AlgHS256:
+		// 空密钥或过短的密钥可被轻易伪造签名，直接拒绝
+		if len(client.Secret) < minHS256KeyLength {
+			return nil, fmt.Errorf("invalid token signature: client secret is not usable for HS256")
+		}
+		key = []byte(client.Secret)
+	default:
+		publicKey, ok := j.keys[token.Header.Kid]
+		if !ok {
+			return nil, fmt.Errorf("invalid token signature: unknown key id %q", token.Header.Kid)
+		}
+		key = publicKey
+	}
+
+	if err := token.Verify(key); err != nil {
+		return nil, err
+	}
+
+	if err := token.Claims.ValidateClaims(jwt.ValidationOptions{
+		Audience:  j.audience,
+		Issuer:    j.issuer,
+		ClockSkew: j.clockSkew,
+	}); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// lookupClient 根据声明值查找客户，先按客户ID，再按API密钥
+func (j *JWTAuthenticator) lookupClient(ctx context.Context, subject string) (*model.Client, error) {
+	var (
+		client *model.Client
+		err    error
+	)
+	if id, parseErr := primitive.ObjectIDFromHex(subject); parseErr == nil {
+		client, err = j.clientRepo.GetByID(ctx, id)
+	} else {
+		client, err = j.clientRepo.GetByAPIKey(ctx, subject)
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("invalid token claims: unknown client")
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
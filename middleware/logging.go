@@ -4,6 +4,7 @@ import (
 	"api-gateway/errors"
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/secrets"
 	"api-gateway/repository"
 	"bytes"
 	"context"
@@ -161,13 +162,17 @@ func (l *LoggingMiddleware) LogAPICall() gin.HandlerFunc {
 		// 创建调用日志
 		callLog := model.NewCallLogWithParams(
 			client.ID,
-			client.APIKey,
+			secrets.KeyPrefix(client.APIKey), // 仅记录密钥前缀，避免明文密钥落库
 			c.Request.URL.Path,
 			lrw.GetStatusCode(),
 			duration,
 			requestBody,
 			responseBody,
 		)
+		// 只有成功的调用才扣费，扣减在日志中间件返回之后进行
+		if callLog.Status == http.StatusOK {
+			callLog.Cost = c.GetInt("billing_cost")
+		}
 
 		// 异步记录日志，避免影响响应性能
 		go func() {
@@ -124,6 +124,11 @@ func (m *PrometheusMiddleware) getClient(c *gin.Context) *model.Client {
 }
 
 func (m *PrometheusMiddleware) getClientLabel(client *model.Client) string {
+	return clientMetricLabel(client)
+}
+
+// clientMetricLabel 指标中的客户标签，格式: name-version
+func clientMetricLabel(client *model.Client) string {
 	if client == nil {
 		return "unknown-unknown"
 	}
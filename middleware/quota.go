@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"api-gateway/errors"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/service"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaMiddleware 按日、按月的调用配额中间件
+type QuotaMiddleware struct {
+	quotaService *service.QuotaService
+}
+
+// NewQuotaMiddleware 创建调用配额中间件
+func NewQuotaMiddleware(quotaService *service.QuotaService) *QuotaMiddleware {
+	return &QuotaMiddleware{
+		quotaService: quotaService,
+	}
+}
+
+// CheckQuota 计入调用配额，配额用完时返回 429 和重置时间，响应不成功时由 DeductCalls 退回
+// 计数存储不可用时放行，避免影响正常客户
+func (q *QuotaMiddleware) CheckQuota() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientInterface, _ := c.Get("client")
+		client, ok := clientInterface.(*model.Client)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		result, err := q.quotaService.Consume(ctx, client, c.Request.URL.Path)
+		if err != nil {
+			logger.Errorf("Failed to check quotas for client %s: %v", client.ID.Hex(), err)
+			c.Next()
+			return
+		}
+		for _, shadowed := range result.Shadowed {
+			logger.Infof("Shadow quota %s exceeded for client %s (%d per %s)", shadowed.Name, client.ID.Hex(), shadowed.Limit, shadowed.Period)
+			metrics.GetMetrics().ShadowViolations.WithLabelValues(clientMetricLabel(client), shadowed.Name).Inc()
+		}
+		if exceeded := result.Exceeded; exceeded != nil {
+			resetAfter := time.Until(exceeded.ResetAt)
+			logger.Infof("Quota %s exceeded for client %s (%d per %s)", exceeded.Name, client.ID.Hex(), exceeded.Limit, exceeded.Period)
+			setRateLimitHeaders(c, exceeded.Limit, 0, resetAfter)
+			setRetryAfter(c, resetAfter)
+			metrics.GetMetrics().QuotaUsed.WithLabelValues(clientMetricLabel(client), exceeded.Name).Set(float64(exceeded.Used))
+			metrics.GetMetrics().QuotaLimit.WithLabelValues(clientMetricLabel(client), exceeded.Name).Set(float64(exceeded.Limit))
+			errors.RespondWithError(c, http.StatusTooManyRequests, errors.NewQuotaExceededError(
+				client.ID.Hex(), exceeded.Name, exceeded.Limit, exceeded.Period, exceeded.ResetAt.Format(time.RFC3339)))
+			return
+		}
+
+		for _, quota := range result.Usage {
+			metrics.GetMetrics().QuotaUsed.WithLabelValues(clientMetricLabel(client), quota.Name).Set(float64(quota.Used))
+			metrics.GetMetrics().QuotaLimit.WithLabelValues(clientMetricLabel(client), quota.Name).Set(float64(quota.Limit))
+		}
+
+		// 请求失败时由 DeductCalls 退回
+		c.Set("quota_result", result)
+		c.Next()
+	}
+}
@@ -1,78 +1,113 @@
 package middleware
 
 import (
+	"api-gateway/config"
 	"api-gateway/errors"
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/pkg/ratelimit"
+	"context"
+	"fmt"
+	"math"
 	"net/http"
-	"sync"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// TokenBucket 令牌桶结构
-type TokenBucket struct {
-	capacity   int        // 桶容量
-	tokens     int        // 当前令牌数
-	refillRate int        // 每秒补充令牌数
-	lastRefill time.Time  // 上次补充时间
-	mutex      sync.Mutex // 互斥锁
-}
+// 共享限流后端不可用时的处理方式
+const (
+	RateLimitFailLocal  = "local"  // 使用本地令牌桶，每个实例单独限流
+	RateLimitFailOpen   = "open"   // 不限流
+	RateLimitFailClosed = "closed" // 拒绝请求
+)
 
-// NewTokenBucket 创建新的令牌桶
-func NewTokenBucket(capacity, refillRate int) *TokenBucket {
-	return &TokenBucket{
-		capacity:   capacity,
-		tokens:     capacity,
-		refillRate: refillRate,
-		lastRefill: time.Now(),
-	}
-}
+// 限流响应头（IETF RateLimit header fields 草案），Reset 为距离配额完全恢复的秒数
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
 
-// TakeToken 尝试获取令牌
-func (tb *TokenBucket) TakeToken() bool {
-	tb.mutex.Lock()
-	defer tb.mutex.Unlock()
+// rateLimitBackendTimeout 单次共享限流检查的超时时间，超时后按 fail_mode 处理
+const rateLimitBackendTimeout = 500 * time.Millisecond
 
-	// 补充令牌
-	now := time.Now()
-	elapsed := now.Sub(tb.lastRefill)
-	tokensToAdd := int(elapsed.Seconds()) * tb.refillRate
+// RateLimitMiddleware 限流中间件
+// 客户 QPS 与配置中的多维度规则一起检查，最严格的规则决定结果
+type RateLimitMiddleware struct {
+	local     *ratelimit.LocalLimiter // 本地令牌桶
+	limiter   ratelimit.Limiter       // 共享限流器，为空时只使用本地令牌桶
+	rules     []*ratelimit.Rule       // 配置中的限流规则
+	upstreams map[string]string       // API版本 -> 上游服务地址
+	costs     []ratelimit.RouteCost   // 路由权重
+
+	failMode      string
+	retryInterval time.Duration
+	backendDownAt atomic.Int64 // 共享限流器最近一次出错的时间（UnixNano），出错后一段时间内不再访问
+}
 
-	if tokensToAdd > 0 {
-		tb.tokens += tokensToAdd
-		if tb.tokens > tb.capacity {
-			tb.tokens = tb.capacity
-		}
-		tb.lastRefill = now
+// NewRateLimitMiddleware 创建限流中间件，limiter 为空时只使用本地令牌桶
+func NewRateLimitMiddleware(limiter ratelimit.Limiter, cfg *config.Config) (*RateLimitMiddleware, error) {
+	failMode := cfg.RateLimit.FailMode
+	if failMode == "" {
+		failMode = RateLimitFailLocal
 	}
-
-	// 尝试获取令牌
-	if tb.tokens > 0 {
-		tb.tokens--
-		return true
+	if failMode != RateLimitFailLocal && failMode != RateLimitFailOpen && failMode != RateLimitFailClosed {
+		return nil, fmt.Errorf("invalid rate limit fail mode: %s", failMode)
+	}
+	retryInterval := time.Duration(cfg.RateLimit.RetryInterval) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = 5 * time.Second
 	}
 
-	return false
-}
+	rules := make([]*ratelimit.Rule, 0, len(cfg.RateLimit.Rules))
+	names := make(map[string]bool, len(cfg.RateLimit.Rules))
+	for _, ruleCfg := range cfg.RateLimit.Rules {
+		if ruleCfg.Name == ratelimit.ClientQPSRule || names[ruleCfg.Name] {
+			return nil, fmt.Errorf("invalid rate limit rule: duplicate name %s", ruleCfg.Name)
+		}
+		names[ruleCfg.Name] = true
 
-// RateLimitMiddleware 限流中间件
-type RateLimitMiddleware struct {
-	buckets map[string]*TokenBucket // 客户端ID -> 令牌桶
-	mutex   sync.RWMutex            // 读写锁
-}
+		rule, err := ratelimit.NewRule(ruleCfg.Name, ruleCfg.Scope, ruleCfg.Routes, ruleCfg.Limit, ruleCfg.Window, ruleCfg.Burst, ruleCfg.Mode)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
 
-// NewRateLimitMiddleware 创建限流中间件
-func NewRateLimitMiddleware() *RateLimitMiddleware {
-	rl := &RateLimitMiddleware{
-		buckets: make(map[string]*TokenBucket),
+	costs := make([]ratelimit.RouteCost, 0, len(cfg.RateLimit.RouteCosts))
+	for _, costCfg := range cfg.RateLimit.RouteCosts {
+		cost, err := ratelimit.NewRouteCost(costCfg.Route, costCfg.Cost)
+		if err != nil {
+			return nil, err
+		}
+		costs = append(costs, cost)
 	}
 
-	// 启动清理协程，定期清理不活跃的令牌桶
-	go rl.cleanup()
+	// 上游维度按目标服务的 host 合并，多个版本指向同一服务时共享限额
+	upstreams := make(map[string]string, len(cfg.Targets))
+	for version, target := range cfg.Targets {
+		upstream := target.URL
+		if u, err := url.Parse(target.URL); err == nil && u.Host != "" {
+			upstream = u.Host
+		}
+		upstreams[version] = upstream
+	}
 
-	return rl
+	return &RateLimitMiddleware{
+		local:         ratelimit.NewLocalLimiter(),
+		limiter:       limiter,
+		rules:         rules,
+		upstreams:     upstreams,
+		costs:         costs,
+		failMode:      failMode,
+		retryInterval: retryInterval,
+	}, nil
 }
 
 // RateLimit 限流处理函数
@@ -99,14 +134,28 @@ func (rl *RateLimitMiddleware) RateLimit() gin.HandlerFunc {
 			return
 		}
 
-		// 获取或创建客户的令牌桶
-		bucket := rl.getOrCreateBucket(client.ID.Hex(), client.QPS)
+		req := ratelimit.Request{
+			ClientID: client.ID.Hex(),
+			Route:    c.Request.URL.Path,
+			IP:       c.ClientIP(),
+			Version:  client.Version,
+			Upstream: rl.upstreams[client.Version],
+		}
 
-		// 尝试获取令牌
-		if !bucket.TakeToken() {
-			logger.Infof("Rate limit exceeded for client %s (QPS: %d)", client.ID.Hex(), client.QPS)
-			errors.RespondWithError(c, http.StatusTooManyRequests,
-				errors.NewRateLimitExceededError(client.ID.Hex(), client.QPS))
+		cost := ratelimit.CostFor(rl.costs, req.Route)
+		decision, available := rl.evaluate(c.Request.Context(), rl.rulesFor(client), req, cost)
+		if !available {
+			errors.RespondWithError(c, http.StatusServiceUnavailable, errors.NewRateLimitUnavailableError())
+			return
+		}
+		// 共享限流器不可用且配置为放行时没有限流结果，不返回限流响应头
+		if decision.Result != nil {
+			setRateLimitHeaders(c, decision.Result.Limit, decision.Result.Remaining, decision.Result.ResetAfter)
+		}
+		exportRuleState(client, decision)
+		reportShadowViolations(client, decision)
+		if !decision.Allowed {
+			rl.reject(c, client, decision)
 			return
 		}
 
@@ -115,82 +164,243 @@ func (rl *RateLimitMiddleware) RateLimit() gin.HandlerFunc {
 	}
 }
 
-// getOrCreateBucket 获取或创建令牌桶
-func (rl *RateLimitMiddleware) getOrCreateBucket(clientID string, qps int) *TokenBucket {
-	rl.mutex.RLock()
-	bucket, exists := rl.buckets[clientID]
-	rl.mutex.RUnlock()
+// rulesFor 返回适用于客户的规则：客户 QPS 规则加上配置中的规则，客户单独设置的覆盖配置
+// 临时调整生效期间，客户 QPS 和客户维度的规则按临时设置计算
+func (rl *RateLimitMiddleware) rulesFor(client *model.Client) []*ratelimit.Rule {
+	now := time.Now()
+	qps, burst := client.EffectiveQPS(now)
+	temp := client.ActiveTemporaryLimit(now)
+
+	rules := make([]*ratelimit.Rule, 0, len(rl.rules)+1)
+	rules = append(rules, &ratelimit.Rule{
+		Name:   ratelimit.ClientQPSRule,
+		Scope:  ratelimit.ScopeClient,
+		Window: ratelimit.WindowSecond,
+		Limit:  ratelimit.Limit{Rate: qps, Period: time.Second, Burst: burst},
+	})
+
+	for _, rule := range rl.rules {
+		if !ratelimit.IsClientScope(rule.Scope) {
+			rules = append(rules, rule)
+			continue
+		}
+
+		override := client.RateLimitOverride(rule.Name)
+		if override != nil && override.Unlimited {
+			continue
+		}
+		if override == nil && temp == nil {
+			rules = append(rules, rule)
+			continue
+		}
 
-	if exists {
-		// 检查QPS是否发生变化，如果变化则更新令牌桶
-		bucket.mutex.Lock()
-		if bucket.refillRate != qps {
-			bucket.refillRate = qps
-			bucket.capacity = qps
-			if bucket.tokens > bucket.capacity {
-				bucket.tokens = bucket.capacity
+		adjusted := *rule
+		if override != nil {
+			if override.Mode != "" {
+				adjusted.Mode = override.Mode
+			}
+			if override.Limit > 0 {
+				adjusted.Limit = ratelimit.Limit{Rate: override.Limit, Period: rule.Period, Burst: override.Burst}
+			}
+			if override.Window != "" {
+				adjusted.Window = override.Window
+				adjusted.Period, _ = ratelimit.WindowDuration(override.Window)
 			}
 		}
-		bucket.mutex.Unlock()
-		return bucket
+		if temp != nil {
+			adjusted.Rate = temp.Scale(adjusted.Rate)
+			adjusted.Burst = temp.Scale(adjusted.Burst)
+		}
+		rules = append(rules, &adjusted)
 	}
 
-	// 创建新的令牌桶
-	rl.mutex.Lock()
-	defer rl.mutex.Unlock()
+	return rules
+}
+
+// evaluate 检查所有规则，available 为 false 表示共享限流器不可用且配置为拒绝
+func (rl *RateLimitMiddleware) evaluate(ctx context.Context, rules []*ratelimit.Rule, req ratelimit.Request, cost int) (*ratelimit.Decision, bool) {
+	if rl.limiter != nil && !rl.backendDown() {
+		ctx, cancel := context.WithTimeout(ctx, rateLimitBackendTimeout)
+		defer cancel()
 
-	// 双重检查，防止并发创建
-	if bucket, exists := rl.buckets[clientID]; exists {
-		return bucket
+		decision, err := ratelimit.Evaluate(ctx, rl.limiter, rules, req, cost)
+		if err == nil {
+			return decision, true
+		}
+		logger.Errorf("Shared rate limiter failed, falling back to %s mode for %s: %v", rl.failMode, rl.retryInterval, err)
+		metrics.GetMetrics().RateLimitErrors.WithLabelValues(rl.failMode).Inc()
+		rl.backendDownAt.Store(time.Now().UnixNano())
 	}
 
-	bucket = NewTokenBucket(qps, qps)
-	rl.buckets[clientID] = bucket
+	if rl.limiter != nil {
+		switch rl.failMode {
+		case RateLimitFailOpen:
+			return &ratelimit.Decision{Allowed: true}, true
+		case RateLimitFailClosed:
+			return nil, false
+		}
+	}
 
-	logger.Infof("Created new token bucket for client %s with QPS %d", clientID, qps)
-	return bucket
+	// 本地限流器不会出错
+	decision, _ := ratelimit.Evaluate(ctx, rl.local, rules, req, cost)
+	return decision, true
 }
 
-// cleanup 清理不活跃的令牌桶
-func (rl *RateLimitMiddleware) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute) // 每5分钟清理一次
-	defer ticker.Stop()
+// reject 返回限流错误，客户 QPS 规则沿用原有的错误格式
+func (rl *RateLimitMiddleware) reject(c *gin.Context, client *model.Client, decision *ratelimit.Decision) {
+	rule := decision.Rule
+	retryAfter := setRetryAfter(c, decision.Result.RetryAfter)
+	if rule.Name == ratelimit.ClientQPSRule {
+		logger.Infof("Rate limit exceeded for client %s (QPS: %d)", client.ID.Hex(), rule.Rate)
+		errors.RespondWithError(c, http.StatusTooManyRequests,
+			errors.NewRateLimitExceededError(client.ID.Hex(), rule.Rate))
+		return
+	}
 
-	for range ticker.C {
-		rl.mutex.Lock()
-		now := time.Now()
+	logger.Infof("Rate limit rule %s exceeded for client %s (%d per %s)", rule.Name, client.ID.Hex(), rule.Rate, rule.Window)
+	errors.RespondWithError(c, http.StatusTooManyRequests,
+		errors.NewRateLimitRuleExceededError(client.ID.Hex(), rule.Name, rule.Rate, rule.Window, retryAfter))
+}
 
-		for clientID, bucket := range rl.buckets {
-			bucket.mutex.Lock()
-			// 如果令牌桶超过10分钟没有活动，则删除
-			if now.Sub(bucket.lastRefill) > 10*time.Minute {
-				delete(rl.buckets, clientID)
-				logger.Debugf("Cleaned up inactive token bucket for client %s", clientID)
-			}
-			bucket.mutex.Unlock()
-		}
+// setRateLimitHeaders 设置 RateLimit-Limit、RateLimit-Remaining 和 RateLimit-Reset 响应头
+func setRateLimitHeaders(c *gin.Context, limit, remaining int, reset time.Duration) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header(RateLimitLimitHeader, strconv.Itoa(limit))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(remaining))
+	c.Header(RateLimitResetHeader, strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+}
 
-		rl.mutex.Unlock()
+// setRetryAfter 设置 Retry-After 响应头，返回向上取整的秒数，至少为 1
+func setRetryAfter(c *gin.Context, wait time.Duration) int {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
 	}
+	c.Header(RetryAfterHeader, strconv.Itoa(retryAfter))
+	return retryAfter
+}
+
+// backendDown 共享限流器最近出错后的重试间隔内返回 true
+func (rl *RateLimitMiddleware) backendDown() bool {
+	downAt := rl.backendDownAt.Load()
+	return downAt != 0 && time.Since(time.Unix(0, downAt)) < rl.retryInterval
 }
 
-// GetBucketStats 获取令牌桶统计信息（用于监控）
+// GetBucketStats 获取本地令牌桶统计信息（用于监控）
 func (rl *RateLimitMiddleware) GetBucketStats() map[string]map[string]interface{} {
-	rl.mutex.RLock()
-	defer rl.mutex.RUnlock()
+	return rl.local.Stats()
+}
+
+// RuleState 客户在一条限流规则上的当前状态
+type RuleState struct {
+	Rule       string `json:"rule"`
+	Scope      string `json:"scope"`
+	Window     string `json:"window"`
+	Mode       string `json:"mode"`
+	Limit      int    `json:"limit"`
+	Burst      int    `json:"burst"`
+	Remaining  int    `json:"remaining"`
+	ResetAfter int    `json:"reset_after"` // 配额完全恢复所需秒数
+}
 
-	stats := make(map[string]map[string]interface{})
+// ClientState 返回客户在客户维度规则上的当前状态，不消耗配额
+// client_route 规则按路由分别计数，不在这里列出
+func (rl *RateLimitMiddleware) ClientState(ctx context.Context, client *model.Client) ([]RuleState, error) {
+	inspector := rl.inspector()
+	req := ratelimit.Request{ClientID: client.ID.Hex()}
 
-	for clientID, bucket := range rl.buckets {
-		bucket.mutex.Lock()
-		stats[clientID] = map[string]interface{}{
-			"capacity":    bucket.capacity,
-			"tokens":      bucket.tokens,
-			"refill_rate": bucket.refillRate,
-			"last_refill": bucket.lastRefill,
+	states := make([]RuleState, 0, len(rl.rules)+1)
+	for _, rule := range rl.rulesFor(client) {
+		if rule.Scope != ratelimit.ScopeClient || rule.Mode == ratelimit.ModeOff {
+			continue
 		}
-		bucket.mutex.Unlock()
+		result, err := inspector.Peek(ctx, rule.Key(req), rule.Limit)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, RuleState{
+			Rule:       rule.Name,
+			Scope:      rule.Scope,
+			Window:     rule.Window,
+			Mode:       ruleMode(rule),
+			Limit:      rule.Rate,
+			Burst:      result.Limit,
+			Remaining:  result.Remaining,
+			ResetAfter: int(math.Ceil(result.ResetAfter.Seconds())),
+		})
 	}
+	return states, nil
+}
 
-	return stats
+// ResetClient 清除客户在所有客户维度规则上的限流状态，共享限流器和本地令牌桶都会清除
+func (rl *RateLimitMiddleware) ResetClient(ctx context.Context, client *model.Client) error {
+	clientID := client.ID.Hex()
+	req := ratelimit.Request{ClientID: clientID}
+
+	var patterns []string
+	for _, rule := range rl.rulesFor(client) {
+		switch rule.Scope {
+		case ratelimit.ScopeClient:
+			patterns = append(patterns, rule.Key(req))
+		case ratelimit.ScopeClientRoute:
+			patterns = append(patterns, "rule:"+rule.Name+":"+clientID+":*")
+		}
+	}
+
+	inspectors := []ratelimit.Inspector{rl.local}
+	if shared, ok := rl.limiter.(ratelimit.Inspector); ok {
+		inspectors = append(inspectors, shared)
+	}
+	for _, inspector := range inspectors {
+		for _, pattern := range patterns {
+			if err := inspector.Reset(ctx, pattern); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inspector 返回当前生效的限流器：共享限流器可用时使用共享限流器，否则使用本地令牌桶
+func (rl *RateLimitMiddleware) inspector() ratelimit.Inspector {
+	if rl.limiter != nil && !rl.backendDown() {
+		if shared, ok := rl.limiter.(ratelimit.Inspector); ok {
+			return shared
+		}
+	}
+	return rl.local
+}
+
+// exportRuleState 导出客户维度规则的限额和剩余配额
+func exportRuleState(client *model.Client, decision *ratelimit.Decision) {
+	label := clientMetricLabel(client)
+	for _, ruleResult := range decision.Results {
+		if ruleResult.Rule.Scope != ratelimit.ScopeClient {
+			continue
+		}
+		remaining := ruleResult.Result.Remaining
+		if remaining < 0 {
+			remaining = 0
+		}
+		metrics.GetMetrics().RateLimitLimit.WithLabelValues(label, ruleResult.Rule.Name).Set(float64(ruleResult.Result.Limit))
+		metrics.GetMetrics().RateLimitLeft.WithLabelValues(label, ruleResult.Rule.Name).Set(float64(remaining))
+	}
+}
+
+// reportShadowViolations 记录 shadow 模式下超限的规则，请求继续处理
+func reportShadowViolations(client *model.Client, decision *ratelimit.Decision) {
+	for _, shadowed := range decision.Shadowed {
+		rule := shadowed.Rule
+		logger.Infof("Shadow rate limit rule %s exceeded for client %s (%d per %s)", rule.Name, client.ID.Hex(), rule.Rate, rule.Window)
+		metrics.GetMetrics().ShadowViolations.WithLabelValues(clientMetricLabel(client), rule.Name).Inc()
+	}
+}
+
+func ruleMode(rule *ratelimit.Rule) string {
+	if rule.Mode == "" {
+		return ratelimit.ModeEnforce
+	}
+	return rule.Mode
 }
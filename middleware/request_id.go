@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求ID请求头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 调用方传入的请求ID最大长度
+const maxRequestIDLength = 128
+
+// RequestID 为每个请求分配请求ID，调用方已传入时沿用，并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"api-gateway/errors"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/routescope"
+	"api-gateway/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopeMiddleware 路由授权中间件，需在认证之后使用
+type ScopeMiddleware struct {
+	catalog *routescope.Catalog
+}
+
+// NewScopeMiddleware 创建路由授权中间件
+func NewScopeMiddleware(catalog *routescope.Catalog) *ScopeMiddleware {
+	return &ScopeMiddleware{
+		catalog: catalog,
+	}
+}
+
+// Authorize 检查客户是否被授权访问当前路由
+func (s *ScopeMiddleware) Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientInterface, exists := c.Get("client")
+		if !exists {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    50000,
+				"message": "内部服务器错误：客户信息未找到",
+			})
+			c.Abort()
+			return
+		}
+
+		client, ok := clientInterface.(*model.Client)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    50000,
+				"message": "内部服务器错误：客户信息类型错误",
+			})
+			c.Abort()
+			return
+		}
+
+		path := c.Request.URL.Path
+		if !s.catalog.Allowed(client.Scopes, path) {
+			logger.Infof("Authorization failed: client %s is not allowed to access %s", client.ID.Hex(), path)
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewRouteNotAllowedError(path, client.ID.Hex()))
+			return
+		}
+
+		// 访问令牌只能访问其 scope 覆盖的路由，在客户授权范围内进一步收窄
+		if claims, ok := c.Value("token_claims").(*service.AccessTokenClaims); ok && !s.tokenAllowed(claims, path) {
+			logger.Infof("Authorization failed: token of client %s with scope %q is not allowed to access %s", client.ID.Hex(), claims.Scope, path)
+			errors.RespondWithError(c, http.StatusForbidden, errors.NewScopeNotGrantedError(path, claims.Scope))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tokenAllowed 判断访问令牌的 scope 是否覆盖路径
+func (s *ScopeMiddleware) tokenAllowed(claims *service.AccessTokenClaims, path string) bool {
+	scopes := claims.Scopes()
+	for _, scope := range scopes {
+		if scope == service.OAuthScopeAll {
+			return true
+		}
+	}
+	return s.catalog.Covers(scopes, path)
+}
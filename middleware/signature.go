@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"api-gateway/model"
+	"api-gateway/repository"
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -16,74 +18,191 @@ import (
 // SignatureValidator 签名验证器接口
 type SignatureValidator interface {
 	ValidateSignature(req *http.Request, client *model.Client) error
-	GenerateSignature(method, path, timestamp, bodyHash, secret string) string
+	GenerateSignature(method, path, timestamp, nonce, bodyHash, secret string) string
 }
 
-// HMACSignatureValidator HMAC签名验证器实现
-type HMACSignatureValidator struct {
-	timeWindow time.Duration // 时间窗口，默认5分钟
+// nonce 长度限制
+const (
+	minNonceLength = 8
+	maxNonceLength = 128
+)
+
+// signatureChecker 各签名算法共用的时间戳、nonce 和签名版本校验
+type signatureChecker struct {
+	timeWindow   time.Duration              // 时间窗口，默认5分钟
+	nonceRepo    repository.NonceRepository // 为空时不做重放检查
+	requireNonce bool                       // 是否强制要求 X-Nonce
+	minVersion   int                        // 允许的最低签名版本，迁移完成后可设为2禁用v1
 }
 
-// NewHMACSignatureValidator 创建HMAC签名验证器
-func NewHMACSignatureValidator(timeWindow time.Duration) *HMACSignatureValidator {
+// signedRequest 从请求中提取的签名参数
+type signedRequest struct {
+	signature string
+	timestamp string
+	nonce     string
+	version   string
+	bodyHash  string
+}
+
+func newSignatureChecker(timeWindow time.Duration, nonceRepo repository.NonceRepository, requireNonce bool, minVersion int) signatureChecker {
 	if timeWindow == 0 {
 		timeWindow = 5 * time.Minute // 默认5分钟时间窗口
 	}
+	if minVersion <= 0 {
+		minVersion = 1
+	}
+	return signatureChecker{
+		timeWindow:   timeWindow,
+		nonceRepo:    nonceRepo,
+		requireNonce: requireNonce,
+		minVersion:   minVersion,
+	}
+}
+
+// HMACSignatureValidator HMAC签名验证器实现
+type HMACSignatureValidator struct {
+	signatureChecker
+}
+
+// NewHMACSignatureValidator 创建HMAC签名验证器
+// nonceRepo 为空时不记录 nonce，仅校验时间窗口
+func NewHMACSignatureValidator(timeWindow time.Duration, nonceRepo repository.NonceRepository, requireNonce bool, minVersion int) *HMACSignatureValidator {
 	return &HMACSignatureValidator{
-		timeWindow: timeWindow,
+		signatureChecker: newSignatureChecker(timeWindow, nonceRepo, requireNonce, minVersion),
 	}
 }
 
 // ValidateSignature 验证请求签名
 func (v *HMACSignatureValidator) ValidateSignature(req *http.Request, client *model.Client) error {
-	// 1. 提取请求头中的签名和时间戳
+	// 1. 提取签名参数，验证时间戳并计算请求体哈希
+	signed, err := v.parseSignedRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// 2. 按签名版本生成期望的签名
+	var expectedSignature string
+	switch signed.version {
+	case SignatureVersion1:
+		expectedSignature = v.GenerateSignature(req.Method, req.URL.Path, signed.timestamp, signed.nonce, signed.bodyHash, client.Secret)
+	case SignatureVersion2:
+		signedHeaders, err := parseSignedHeaders(req)
+		if err != nil {
+			return err
+		}
+		expectedSignature = GenerateSignatureV2(CanonicalRequest(req, signedHeaders, signed.bodyHash), signed.timestamp, signed.nonce, client.Secret)
+	}
+
+	// 3. 比较签名
+	if !hmac.Equal([]byte(signed.signature), []byte(expectedSignature)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	// 4. 签名有效后再记录 nonce，避免伪造请求占用 nonce
+	return v.checkNonce(req.Context(), client, signed.nonce)
+}
+
+// GenerateSignature 生成HMAC-SHA256签名
+// nonce 为空时使用不含 nonce 的旧签名字符串，兼容未升级的客户端
+func (v *HMACSignatureValidator) GenerateSignature(method, path, timestamp, nonce, bodyHash, secret string) string {
+	// 构建签名字符串: HTTP方法 + "\n" + URI路径 + "\n" + 时间戳 + ["\n" + nonce] + "\n" + 请求体哈希
+	message := fmt.Sprintf("%s\n%s\n%s\n%s", method, path, timestamp, bodyHash)
+	if nonce != "" {
+		message = fmt.Sprintf("%s\n%s\n%s\n%s\n%s", method, path, timestamp, nonce, bodyHash)
+	}
+
+	// 使用HMAC-SHA256生成签名
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(message))
+
+	// Base64编码输出
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// parseSignedRequest 提取签名请求头，验证时间戳并计算请求体哈希
+func (v *signatureChecker) parseSignedRequest(req *http.Request) (*signedRequest, error) {
 	signature := req.Header.Get("X-Signature")
 	if signature == "" {
-		return fmt.Errorf("missing signature")
+		return nil, fmt.Errorf("missing signature")
 	}
 
 	timestamp := req.Header.Get("X-Timestamp")
 	if timestamp == "" {
-		return fmt.Errorf("missing timestamp")
+		return nil, fmt.Errorf("missing timestamp")
+	}
+
+	nonce := req.Header.Get("X-Nonce")
+	if nonce == "" && v.requireNonce {
+		return nil, fmt.Errorf("missing nonce")
+	}
+	if nonce != "" && (len(nonce) < minNonceLength || len(nonce) > maxNonceLength) {
+		return nil, fmt.Errorf("invalid nonce length")
+	}
+
+	version, err := v.signatureVersion(req)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 验证时间戳
 	if err := v.validateTimestamp(timestamp); err != nil {
-		return err
+		return nil, err
 	}
 
-	// 3. 计算请求体哈希
 	bodyHash, err := v.calculateBodyHash(req)
 	if err != nil {
-		return fmt.Errorf("failed to calculate body hash: %w", err)
+		return nil, fmt.Errorf("failed to calculate body hash: %w", err)
 	}
 
-	// 4. 生成期望的签名
-	expectedSignature := v.GenerateSignature(req.Method, req.URL.Path, timestamp, bodyHash, client.Secret)
+	return &signedRequest{
+		signature: signature,
+		timestamp: timestamp,
+		nonce:     nonce,
+		version:   version,
+		bodyHash:  bodyHash,
+	}, nil
+}
 
-	// 5. 比较签名
-	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		return fmt.Errorf("invalid signature")
+// signatureVersion 读取 X-Signature-Version，未指定时为v1
+func (v *signatureChecker) signatureVersion(req *http.Request) (string, error) {
+	version := req.Header.Get("X-Signature-Version")
+	if version == "" {
+		version = SignatureVersion1
 	}
 
-	return nil
+	switch version {
+	case SignatureVersion1, SignatureVersion2:
+		if n, _ := strconv.Atoi(version); n < v.minVersion {
+			return "", fmt.Errorf("unsupported signature version: %s", version)
+		}
+		return version, nil
+	default:
+		return "", fmt.Errorf("unsupported signature version: %s", version)
+	}
 }
 
-// GenerateSignature 生成HMAC-SHA256签名
-func (v *HMACSignatureValidator) GenerateSignature(method, path, timestamp, bodyHash, secret string) string {
-	// 构建签名字符串: HTTP方法 + "\n" + URI路径 + "\n" + 时间戳 + "\n" + 请求体哈希
-	message := fmt.Sprintf("%s\n%s\n%s\n%s", method, path, timestamp, bodyHash)
+// checkNonce 检查 nonce 是否在时间窗口内被使用过，nonce 为空时不检查
+func (v *signatureChecker) checkNonce(ctx context.Context, client *model.Client, nonce string) error {
+	if nonce == "" || v.nonceRepo == nil {
+		return nil
+	}
 
-	// 使用HMAC-SHA256生成签名
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(message))
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
 
-	// Base64编码输出
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	// 时间戳允许前后偏差一个窗口，nonce 需保留两个窗口
+	fresh, err := v.nonceRepo.Remember(ctx, client.ID.Hex()+":"+nonce, 2*v.timeWindow)
+	if err != nil {
+		return fmt.Errorf("nonce store unavailable: %w", err)
+	}
+	if !fresh {
+		return fmt.Errorf("nonce replayed")
+	}
+
+	return nil
 }
 
 // validateTimestamp 验证时间戳
-func (v *HMACSignatureValidator) validateTimestamp(timestampStr string) error {
+func (v *signatureChecker) validateTimestamp(timestampStr string) error {
 	// 解析时间戳
 	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
 	if err != nil {
@@ -108,7 +227,7 @@ func (v *HMACSignatureValidator) validateTimestamp(timestampStr string) error {
 }
 
 // calculateBodyHash 计算请求体的SHA256哈希
-func (v *HMACSignatureValidator) calculateBodyHash(req *http.Request) (string, error) {
+func (v *signatureChecker) calculateBodyHash(req *http.Request) (string, error) {
 	if req.Body == nil {
 		// 空请求体的哈希
 		return fmt.Sprintf("%x", sha256.Sum256([]byte{})), nil
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"api-gateway/model"
+	"api-gateway/repository"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 公钥签名待签名字符串的算法标识
+const (
+	signatureEd25519Algorithm = "GW2-ED25519"
+	signatureECDSAAlgorithm   = "GW2-ECDSA-P256-SHA256"
+)
+
+// AsymmetricSignatureValidator 公钥签名验证器
+// 客户使用私钥对 v2 规范化请求签名，网关使用登记的公钥验证，无需保存客户密钥
+type AsymmetricSignatureValidator struct {
+	signatureChecker
+	keys sync.Map // 公钥指纹 -> 解析后的公钥
+}
+
+// NewAsymmetricSignatureValidator 创建公钥签名验证器
+// 公钥签名只支持 v2 规范化请求
+func NewAsymmetricSignatureValidator(timeWindow time.Duration, nonceRepo repository.NonceRepository, requireNonce bool) *AsymmetricSignatureValidator {
+	return &AsymmetricSignatureValidator{
+		signatureChecker: newSignatureChecker(timeWindow, nonceRepo, requireNonce, 2),
+	}
+}
+
+// ValidateSignature 验证请求签名
+// X-Key-Id 指定使用的公钥，客户只登记了一个公钥时可省略
+func (v *AsymmetricSignatureValidator) ValidateSignature(req *http.Request, client *model.Client) error {
+	signed, err := v.parseSignedRequest(req)
+	if err != nil {
+		return err
+	}
+
+	signatureType := client.EffectiveSignatureType()
+	publicKey := client.FindPublicKey(req.Header.Get("X-Key-Id"), signatureType)
+	if publicKey == nil {
+		return fmt.Errorf("invalid key id: no matching %s public key", signatureType)
+	}
+	key, err := v.parsedKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signed.signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature")
+	}
+
+	signedHeaders, err := parseSignedHeaders(req)
+	if err != nil {
+		return err
+	}
+	stringToSign := AsymmetricStringToSign(signatureType, CanonicalRequest(req, signedHeaders, signed.bodyHash), signed.timestamp, signed.nonce)
+
+	if !verifyAsymmetric(key, []byte(stringToSign), signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return v.checkNonce(req.Context(), client, signed.nonce)
+}
+
+// parsedKey 返回解析后的公钥，按指纹缓存
+func (v *AsymmetricSignatureValidator) parsedKey(publicKey *model.PublicKey) (crypto.PublicKey, error) {
+	if key, ok := v.keys.Load(publicKey.Fingerprint); ok {
+		return key, nil
+	}
+
+	key, err := publicKey.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid key id: %w", err)
+	}
+	v.keys.Store(publicKey.Fingerprint, key)
+	return key, nil
+}
+
+// AsymmetricStringToSign 构建公钥签名的待签名字符串
+// 算法标识 \n 时间戳 \n nonce \n hex(sha256(规范化请求))
+func AsymmetricStringToSign(signatureType, canonicalRequest, timestamp, nonce string) string {
+	algorithm := signatureEd25519Algorithm
+	if signatureType == model.SignatureTypeECDSA {
+		algorithm = signatureECDSAAlgorithm
+	}
+
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	return fmt.Sprintf("%s\n%s\n%s\n%x", algorithm, timestamp, nonce, digest)
+}
+
+// verifyAsymmetric Ed25519 直接验证待签名字符串，ECDSA 验证其 SHA-256 摘要（ASN.1 DER 编码签名）
+func verifyAsymmetric(key crypto.PublicKey, message, signature []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, message, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	default:
+		return false
+	}
+}
+
+// ClientSignatureValidator 按客户的 signature_type 选择签名验证器，未设置时使用 HMAC
+type ClientSignatureValidator struct {
+	hmac       *HMACSignatureValidator
+	asymmetric *AsymmetricSignatureValidator
+}
+
+// NewClientSignatureValidator 创建按客户选择算法的签名验证器
+func NewClientSignatureValidator(hmac *HMACSignatureValidator, asymmetric *AsymmetricSignatureValidator) *ClientSignatureValidator {
+	return &ClientSignatureValidator{
+		hmac:       hmac,
+		asymmetric: asymmetric,
+	}
+}
+
+// ValidateSignature 验证请求签名
+func (v *ClientSignatureValidator) ValidateSignature(req *http.Request, client *model.Client) error {
+	switch client.EffectiveSignatureType() {
+	case model.SignatureTypeEd25519, model.SignatureTypeECDSA:
+		return v.asymmetric.ValidateSignature(req, client)
+	default:
+		return v.hmac.ValidateSignature(req, client)
+	}
+}
+
+// GenerateSignature 生成HMAC签名，公钥签名由客户使用私钥生成
+func (v *ClientSignatureValidator) GenerateSignature(method, path, timestamp, nonce, bodyHash, secret string) string {
+	return v.hmac.GenerateSignature(method, path, timestamp, nonce, bodyHash, secret)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// 签名版本
+const (
+	SignatureVersion1 = "1" // 方法 + 路径 + 时间戳 + [nonce] + 请求体哈希
+	SignatureVersion2 = "2" // 规范化请求，覆盖查询参数和指定请求头
+)
+
+// signatureV2Algorithm v2 待签名字符串的算法标识
+const signatureV2Algorithm = "GW2-HMAC-SHA256"
+
+// mustSignHeaders v2 中出现时必须签名的请求头，防止在传输中被篡改
+var mustSignHeaders = []string{"content-type", "x-async", "x-callback-url", "x-callback-method", "x-callback-auth"}
+
+// parseSignedHeaders 解析 X-Signed-Headers（分号分隔），返回排序后的小写头名
+func parseSignedHeaders(req *http.Request) ([]string, error) {
+	raw := req.Header.Get("X-Signed-Headers")
+	if raw == "" {
+		return nil, fmt.Errorf("invalid signed headers: missing X-Signed-Headers")
+	}
+
+	seen := make(map[string]bool)
+	var headers []string
+	for _, name := range strings.Split(raw, ";") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+
+	for _, name := range mustSignHeaders {
+		if req.Header.Get(name) != "" && !seen[name] {
+			return nil, fmt.Errorf("invalid signed headers: %s must be signed", name)
+		}
+	}
+
+	return headers, nil
+}
+
+// CanonicalRequest 构建 v2 规范化请求：
+// 方法 \n 路径 \n 排序后的查询串 \n 规范化请求头 \n 签名头列表 \n 请求体哈希
+func CanonicalRequest(req *http.Request, signedHeaders []string, bodyHash string) string {
+	var b strings.Builder
+
+	b.WriteString(req.Method)
+	b.WriteString("\n")
+	b.WriteString(canonicalPath(req.URL))
+	b.WriteString("\n")
+	b.WriteString(canonicalQuery(req.URL.Query()))
+	b.WriteString("\n")
+	for _, name := range signedHeaders {
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(canonicalHeaderValue(req, name))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteString("\n")
+	b.WriteString(bodyHash)
+
+	return b.String()
+}
+
+// GenerateSignatureV2 生成 v2 签名
+// 待签名字符串: GW2-HMAC-SHA256 \n 时间戳 \n nonce \n hex(sha256(规范化请求))
+func GenerateSignatureV2(canonicalRequest, timestamp, nonce, secret string) string {
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := fmt.Sprintf("%s\n%s\n%s\n%x", signatureV2Algorithm, timestamp, nonce, digest)
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// canonicalPath 返回转义后的请求路径，空路径视为 "/"
+func canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// canonicalQuery 按参数名、参数值排序并使用 RFC 3986 编码
+func canonicalQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			pairs = append(pairs, rfc3986Escape(key)+"="+rfc3986Escape(val))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaderValue 多个值以逗号连接，去除首尾空白并合并连续空白
+func canonicalHeaderValue(req *http.Request, name string) string {
+	if name == "host" {
+		return strings.TrimSpace(req.Host)
+	}
+
+	values := req.Header.Values(name)
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		normalized[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(normalized, ",")
+}
+
+// rfc3986Escape 与 url.QueryEscape 相同，但空格编码为 %20
+func rfc3986Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
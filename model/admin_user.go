@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Admin roles
+const (
+	AdminRoleViewer     = "viewer"     // 只读
+	AdminRoleOperator   = "operator"   // 客户和密钥运维
+	AdminRoleFinance    = "finance"    // 充值
+	AdminRoleSuperAdmin = "superadmin" // 全部权限，包括管理员账号
+)
+
+// Admin permissions
+const (
+	PermClientsRead   = "clients:read"
+	PermClientsWrite  = "clients:write"  // 创建客户、修改QPS、IP规则、路由授权
+	PermClientsStatus = "clients:status" // 启用/禁用客户
+	PermBillingWrite  = "billing:write"  // 充值
+	PermKeysRead      = "keys:read"
+	PermKeysManage    = "keys:manage" // 签发、轮换、吊销密钥
+	PermLogsRead      = "logs:read"
+	PermStatsRead     = "stats:read"
+	PermAuditRead     = "audit:read"    // 查询和导出审计日志
+	PermBansManage    = "bans:manage"   // 查看和解除认证失败封禁
+	PermAdminsManage  = "admins:manage" // 管理员账号管理
+)
+
+var viewerPermissions = []string{PermClientsRead, PermKeysRead, PermLogsRead, PermStatsRead}
+
+// rolePermissions 角色 -> 权限
+var rolePermissions = map[string]map[string]bool{
+	AdminRoleViewer:   permissionSet(viewerPermissions),
+	AdminRoleOperator: permissionSet(viewerPermissions, PermClientsWrite, PermClientsStatus, PermKeysManage, PermBansManage),
+	AdminRoleFinance:  permissionSet(viewerPermissions, PermBillingWrite, PermAuditRead),
+	AdminRoleSuperAdmin: permissionSet(viewerPermissions, PermClientsWrite, PermClientsStatus, PermKeysManage,
+		PermBillingWrite, PermAuditRead, PermBansManage, PermAdminsManage),
+}
+
+// AdminUser status constants
+const (
+	AdminUserStatusDisabled = 0 // 禁用
+	AdminUserStatusActive   = 1 // 正常
+)
+
+// AdminUser represents an operator of the admin API
+type AdminUser struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Username     string             `json:"username" bson:"username"`
+	PasswordHash string             `json:"-" bson:"password_hash,omitempty"` // bcrypt 哈希
+	TokenHash    string             `json:"-" bson:"token_hash,omitempty"`    // 访问令牌 SHA-256 哈希
+	TokenPrefix  string             `json:"token_prefix,omitempty" bson:"token_prefix,omitempty"`
+	Role         string             `json:"role" bson:"role"`
+	Status       int                `json:"status" bson:"status"` // 0:禁用 1:正常
+	LastLoginAt  *time.Time         `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// IsValidAdminRole returns true if the role is known
+func IsValidAdminRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsActive returns true if the admin user is active
+func (u *AdminUser) IsActive() bool {
+	return u.Status == AdminUserStatusActive
+}
+
+// HasPermission returns true if the admin user's role grants the permission
+func (u *AdminUser) HasPermission(permission string) bool {
+	return rolePermissions[u.Role][permission]
+}
+
+func permissionSet(base []string, extra ...string) map[string]bool {
+	set := make(map[string]bool, len(base)+len(extra))
+	for _, p := range base {
+		set[p] = true
+	}
+	for _, p := range extra {
+		set[p] = true
+	}
+	return set
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audit actions
+const (
+	AuditActionClientCreate         = "client.create"
+	AuditActionClientStatus         = "client.status"
+	AuditActionClientQPS            = "client.qps"
+	AuditActionClientPlan           = "client.plan"
+	AuditActionClientRecharge       = "client.recharge"
+	AuditActionClientIPRules        = "client.ip_rules"
+	AuditActionClientCORS           = "client.cors"
+	AuditActionClientRateLimits     = "client.rate_limits"
+	AuditActionClientQuotas         = "client.quotas"
+	AuditActionClientLimiterReset   = "client.limiter.reset"
+	AuditActionClientTemporaryLimit = "client.temporary_limit"
+	AuditActionClientValidity       = "client.validity"
+	AuditActionClientScopeGrant     = "client.scopes.grant"
+	AuditActionClientScopeRevoke    = "client.scopes.revoke"
+	AuditActionPublicKeyAdd         = "client.public_key.add"
+	AuditActionPublicKeyRemove      = "client.public_key.remove"
+	AuditActionSignatureType        = "client.signature_type"
+	AuditActionCredentialIssue      = "credential.issue"
+	AuditActionCredentialRotate     = "credential.rotate"
+	AuditActionCredentialRevoke     = "credential.revoke"
+	AuditActionAdminUserCreate      = "admin_user.create"
+	AuditActionAdminUserRole        = "admin_user.role"
+	AuditActionAdminUserStatus      = "admin_user.status"
+	AuditActionAdminUserToken       = "admin_user.token"
+	AuditActionAuthUnban            = "auth.unban"
+)
+
+// AuditLog represents one administrative action. Audit logs are append-only.
+type AuditLog struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Actor     string                 `json:"actor" bson:"actor"`                               // 操作人用户名
+	ActorID   primitive.ObjectID     `json:"actor_id" bson:"actor_id"`                         // 操作人ID
+	ActorRole string                 `json:"actor_role" bson:"actor_role"`                     // 操作时的角色
+	Action    string                 `json:"action" bson:"action"`                             // 操作类型，如 client.recharge
+	ClientID  *primitive.ObjectID    `json:"client_id,omitempty" bson:"client_id,omitempty"`   // 目标客户
+	TargetID  string                 `json:"target_id,omitempty" bson:"target_id,omitempty"`   // 其他目标（密钥、管理员）ID
+	Before    map[string]interface{} `json:"before,omitempty" bson:"before,omitempty"`         // 修改前的值
+	After     map[string]interface{} `json:"after,omitempty" bson:"after,omitempty"`           // 修改后的值
+	IP        string                 `json:"ip" bson:"ip"`                                     // 来源IP
+	RequestID string                 `json:"request_id,omitempty" bson:"request_id,omitempty"` // 请求ID
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Auth ban scopes
+const (
+	AuthBanScopeIP  = "ip"  // 按客户端IP封禁
+	AuthBanScopeKey = "key" // 按完整API密钥的哈希封禁，公开的密钥前缀不能用于封禁他人
+)
+
+// AuthBan represents a temporary ban after too many failed authentications
+type AuthBan struct {
+	Scope     string    `json:"scope"`
+	Subject   string    `json:"subject"`              // IP 或API密钥哈希
+	KeyPrefix string    `json:"key_prefix,omitempty"` // API密钥展示前缀，仅用于识别
+	Strikes   int       `json:"strikes"`              // 第几次被封禁，决定封禁时长
+	Failures  int64     `json:"failures"`             // 触发封禁时窗口内的失败次数
+	BannedAt  time.Time `json:"banned_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsValidAuthBanScope returns true if the scope is known
+func IsValidAuthBanScope(scope string) bool {
+	return scope == AuthBanScopeIP || scope == AuthBanScopeKey
+}
+
+// AuthBanKey returns the storage key of a ban subject
+func AuthBanKey(scope, subject string) string {
+	return scope + ":" + subject
+}
@@ -14,6 +14,7 @@ type CallLog struct {
 	Path         string             `json:"path" bson:"path"`
 	Status       int                `json:"status" bson:"status"`                         // HTTP状态码
 	Duration     int64              `json:"duration" bson:"duration"`                     // 响应时间(ms)
+	Cost         int                `json:"cost,omitempty" bson:"cost,omitempty"`         // 扣减的调用次数，失败的调用不扣减
 	RequestBody  string             `json:"request_body" bson:"request_body,omitempty"`   // 请求参数
 	ResponseBody string             `json:"response_body" bson:"response_body,omitempty"` // 响应参数
 	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
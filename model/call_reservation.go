@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Call reservation states
+const (
+	ReservationHeld      = "held"      // 已从余额中预扣，等待调用结果
+	ReservationCommitted = "committed" // 调用成功，预扣的次数计为消费
+	ReservationReleased  = "released"  // 调用失败，预扣的次数已退回
+	ReservationExpired   = "expired"   // 超时未确认（如网关崩溃），预扣的次数已退回；之后调用成功时重新扣费并转为 committed
+	ReservationUnpaid    = "unpaid"    // 超时退回后调用成功，但余额不足以重新扣费，留待对账
+
+	ReservationRefunding    = "refunding"     // 正在退回，只有转为该状态的一方执行退回
+	ReservationRefundFailed = "refund_failed" // 退回失败，由清理任务重试
+)
+
+// CallReservation holds the cost of a call from the client balance while the call is proxied.
+// 预扣在代理之前原子完成，并发请求不会透支余额
+type CallReservation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ClientID  primitive.ObjectID `json:"client_id" bson:"client_id"`
+	Route     string             `json:"route" bson:"route"`
+	Cost      int                `json:"cost" bson:"cost"`
+	Status    string             `json:"status" bson:"status"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"` // 超过该时间仍未确认时由清理任务退回
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -10,15 +10,37 @@ import (
 type Client struct {
 	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name       string             `json:"name" bson:"name"`
-	APIKey     string             `json:"api_key" bson:"api_key"`
-	Secret     string             `json:"-" bson:"secret"`                // 签名密钥，不返回给客户端
-	Version    string             `json:"version" bson:"version"`         // 绑定的API版本
-	CallCount  int                `json:"call_count" bson:"call_count"`   // 剩余调用次数
-	TotalCount int                `json:"total_count" bson:"total_count"` // 总购买次数
-	QPS        int                `json:"qps" bson:"qps"`                 // 每秒请求数限制
-	Status     int                `json:"status" bson:"status"`           // 0:禁用 1:正常
+	APIKey     string             `json:"api_key,omitempty" bson:"api_key,omitempty"` // 明文密钥仅存在于内存中，存储时替换为哈希
+	Secret     string             `json:"-" bson:"secret,omitempty"`                  // 签名密钥，不返回给客户端；启用加密后不落库
+	Version    string             `json:"version" bson:"version"`                     // 绑定的API版本
+	CallCount  int                `json:"call_count" bson:"call_count"`               // 剩余调用次数
+	TotalCount int                `json:"total_count" bson:"total_count"`             // 总购买次数
+	QPS        int                `json:"qps" bson:"qps"`                             // 每秒请求数限制
+	Burst      int                `json:"burst,omitempty" bson:"burst,omitempty"`     // 允许的突发请求数，为0时等于 QPS
+	Status     int                `json:"status" bson:"status"`                       // 0:禁用 1:正常
+	Plan       string             `json:"plan,omitempty" bson:"plan,omitempty"`       // 计费套餐，按套餐匹配路由价格
 	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+
+	IPAllowlist []string    `json:"ip_allowlist,omitempty" bson:"ip_allowlist,omitempty"` // 允许访问的 IP/CIDR，为空时不限制
+	IPDenylist  []string    `json:"ip_denylist,omitempty" bson:"ip_denylist,omitempty"`   // 禁止访问的 IP/CIDR，优先于白名单
+	Scopes      []string    `json:"scopes,omitempty" bson:"scopes,omitempty"`             // 允许访问的产品或路由模式，为空时按默认策略
+	CORS        *CORSPolicy `json:"cors,omitempty" bson:"cors,omitempty"`                 // 浏览器跨域访问策略，为空时使用全局配置
+
+	RateLimits     []RateLimitOverride `json:"rate_limits,omitempty" bson:"rate_limits,omitempty"`         // 对配置中限流规则的单独设置
+	Quotas         []Quota             `json:"quotas,omitempty" bson:"quotas,omitempty"`                   // 按日、按月的调用配额，覆盖配置中的同名配额
+	TemporaryLimit *TemporaryLimit     `json:"temporary_limit,omitempty" bson:"temporary_limit,omitempty"` // 临时限流调整，到期后自动失效
+
+	SignatureType string      `json:"signature_type,omitempty" bson:"signature_type,omitempty"` // 请求签名方式，为空时使用 HMAC
+	PublicKeys    []PublicKey `json:"public_keys,omitempty" bson:"public_keys,omitempty"`       // 公钥签名使用的已登记公钥
+
+	ValidFrom        *time.Time `json:"valid_from,omitempty" bson:"valid_from,omitempty"`                 // 合同开始时间，为空时不限制
+	ValidUntil       *time.Time `json:"valid_until,omitempty" bson:"valid_until,omitempty"`               // 合同结束时间，为空时长期有效
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty" bson:"expiry_notified_at,omitempty"` // 已发送到期提醒的时间，修改有效期后清空
+
+	APIKeyHash      string `json:"-" bson:"api_key_hash,omitempty"`                // API密钥查找哈希
+	APIKeyPrefix    string `json:"api_key_prefix" bson:"api_key_prefix,omitempty"` // API密钥展示前缀
+	EncryptedSecret string `json:"-" bson:"encrypted_secret,omitempty"`            // 加密后的签名密钥
 }
 
 // ClientStatus constants
@@ -27,6 +49,13 @@ const (
 	ClientStatusActive   = 1 // 正常
 )
 
+// Client validity states
+const (
+	ClientValidityActive     = ""            // 在有效期内
+	ClientValidityNotStarted = "not_started" // 合同尚未开始
+	ClientValidityExpired    = "expired"     // 合同已到期
+)
+
 // NewClient creates a new client with default values
 func NewClient(name, apiKey, secret, version string, initialCallCount int) *Client {
 	now := time.Now()
@@ -49,11 +78,27 @@ func (c *Client) IsActive() bool {
 	return c.Status == ClientStatusActive
 }
 
+// CheckValidity returns the contract validity state of the client at the given time
+func (c *Client) CheckValidity(now time.Time) string {
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return ClientValidityNotStarted
+	}
+	if c.ValidUntil != nil && !now.Before(*c.ValidUntil) {
+		return ClientValidityExpired
+	}
+	return ClientValidityActive
+}
+
 // HasCallsRemaining returns true if the client has remaining calls
 func (c *Client) HasCallsRemaining() bool {
 	return c.CallCount > 0
 }
 
+// HasCallsFor returns true if the remaining calls cover the cost of a call
+func (c *Client) HasCallsFor(cost int) bool {
+	return c.CallCount >= cost && c.CallCount > 0
+}
+
 // DecrementCallCount decreases the call count by 1
 func (c *Client) DecrementCallCount() {
 	if c.CallCount > 0 {
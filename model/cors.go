@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CORSPolicy is the browser access policy of a client.
+// 配置了允许来源的客户视为浏览器端密钥，带 Origin 头的请求必须来自允许的来源
+type CORSPolicy struct {
+	AllowedOrigins []string `json:"allowed_origins" bson:"allowed_origins"`                     // 允许的来源，如 https://app.example.com、https://*.example.com
+	AllowedMethods []string `json:"allowed_methods,omitempty" bson:"allowed_methods,omitempty"` // 为空时使用全局配置
+	AllowedHeaders []string `json:"allowed_headers,omitempty" bson:"allowed_headers,omitempty"` // 为空时使用全局配置
+}
+
+// NormalizeCORSPolicy validates and normalizes a CORS policy, nil or empty policies return nil
+func NormalizeCORSPolicy(policy *CORSPolicy) (*CORSPolicy, error) {
+	if policy == nil || (len(policy.AllowedOrigins) == 0 && len(policy.AllowedMethods) == 0 && len(policy.AllowedHeaders) == 0) {
+		return nil, nil
+	}
+
+	origins := make([]string, 0, len(policy.AllowedOrigins))
+	for _, origin := range policy.AllowedOrigins {
+		normalized, err := NormalizeOrigin(origin)
+		if err != nil {
+			return nil, err
+		}
+		origins = appendUnique(origins, normalized)
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("invalid CORS policy: allowed_origins is required")
+	}
+
+	methods := make([]string, 0, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" || strings.ContainsAny(method, " ,") {
+			return nil, fmt.Errorf("invalid CORS method: %q", method)
+		}
+		methods = appendUnique(methods, method)
+	}
+
+	headers := make([]string, 0, len(policy.AllowedHeaders))
+	for _, header := range policy.AllowedHeaders {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header == "" || strings.ContainsAny(header, " ,:") {
+			return nil, fmt.Errorf("invalid CORS header: %q", header)
+		}
+		headers = appendUnique(headers, header)
+	}
+
+	return &CORSPolicy{
+		AllowedOrigins: origins,
+		AllowedMethods: methods,
+		AllowedHeaders: headers,
+	}, nil
+}
+
+// NormalizeOrigin validates an origin pattern and returns it in lower case.
+// 支持 * 、完整来源（scheme://host[:port]）和通配子域名（scheme://*.example.com）
+func NormalizeOrigin(origin string) (string, error) {
+	origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+	if origin == "*" {
+		return origin, nil
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") ||
+		u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("invalid CORS origin: %s", origin)
+	}
+	return origin, nil
+}
+
+// MatchOrigin returns true if the request origin matches one of the origin patterns
+func MatchOrigin(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		// https://*.example.com 匹配 https://app.example.com，不匹配 https://example.com
+		if i := strings.Index(pattern, "://*."); i >= 0 {
+			prefix, suffix := pattern[:i+3], pattern[i+4:]
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+				len(origin) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsBrowserKey returns true if the client restricts browser access to an origin allowlist
+func (c *Client) IsBrowserKey() bool {
+	return c.CORS != nil && len(c.CORS.AllowedOrigins) > 0
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
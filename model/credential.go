@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CredentialStatus constants
+const (
+	CredentialStatusRevoked = 0 // 已吊销
+	CredentialStatusActive  = 1 // 正常
+)
+
+// PrimaryCredentialLabel 由客户端主密钥迁移而来的凭证标签
+const PrimaryCredentialLabel = "primary"
+
+// Credential represents one API key / secret pair belonging to a client
+type Credential struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ClientID   primitive.ObjectID `json:"client_id" bson:"client_id"`
+	Label      string             `json:"label" bson:"label"`
+	APIKey     string             `json:"api_key,omitempty" bson:"api_key,omitempty"`           // 明文密钥仅存在于内存中，存储时替换为哈希
+	Secret     string             `json:"-" bson:"secret,omitempty"`                            // 签名密钥，不返回给客户端；启用加密后不落库
+	Status     int                `json:"status" bson:"status"`                                 // 0:已吊销 1:正常
+	ExpiresAt  *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`     // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"` // 最后使用时间
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+
+	APIKeyHash      string `json:"-" bson:"api_key_hash,omitempty"`                // API密钥查找哈希
+	APIKeyPrefix    string `json:"api_key_prefix" bson:"api_key_prefix,omitempty"` // API密钥展示前缀
+	EncryptedSecret string `json:"-" bson:"encrypted_secret,omitempty"`            // 加密后的签名密钥
+}
+
+// NewCredential creates a new active credential for a client
+func NewCredential(clientID primitive.ObjectID, label, apiKey, secret string, expiresAt *time.Time) *Credential {
+	now := time.Now()
+	return &Credential{
+		ClientID:  clientID,
+		Label:     label,
+		APIKey:    apiKey,
+		Secret:    secret,
+		Status:    CredentialStatusActive,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsValid returns true if the credential is active and not expired at the given time
+func (c *Credential) IsValid(now time.Time) bool {
+	if c.Status != CredentialStatusActive {
+		return false
+	}
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
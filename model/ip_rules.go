@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IP 规则匹配结果
+const (
+	IPRuleAllowed      = ""          // 允许访问
+	IPRuleDenylisted   = "denylist"  // 命中黑名单
+	IPRuleNotAllowlist = "allowlist" // 配置了白名单但未命中
+)
+
+// NormalizeIPRules 校验并规范化 IP 规则，单个 IP 转换为 /32 或 /128 的 CIDR
+func NormalizeIPRules(rules []string) ([]string, error) {
+	normalized := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		network, err := parseIPRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, network.String())
+	}
+	return normalized, nil
+}
+
+// CheckIP 检查客户端 IP 是否允许访问，返回未通过的规则类型
+// 黑名单优先；白名单为空时不限制
+func (c *Client) CheckIP(ip string) string {
+	if len(c.IPAllowlist) == 0 && len(c.IPDenylist) == 0 {
+		return IPRuleAllowed
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed != nil && matchIPRules(c.IPDenylist, parsed) {
+		return IPRuleDenylisted
+	}
+	if len(c.IPAllowlist) > 0 && (parsed == nil || !matchIPRules(c.IPAllowlist, parsed)) {
+		return IPRuleNotAllowlist
+	}
+
+	return IPRuleAllowed
+}
+
+func matchIPRules(rules []string, ip net.IP) bool {
+	for _, rule := range rules {
+		network, err := parseIPRule(rule)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIPRule(rule string) (*net.IPNet, error) {
+	rule = strings.TrimSpace(rule)
+	if strings.Contains(rule, "/") {
+		_, network, err := net.ParseCIDR(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %s", rule)
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(rule)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", rule)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Request signature types
+const (
+	SignatureTypeHMAC    = "hmac"    // 共享密钥 HMAC-SHA256（默认）
+	SignatureTypeEd25519 = "ed25519" // Ed25519 公钥签名
+	SignatureTypeECDSA   = "ecdsa"   // ECDSA P-256 + SHA-256 公钥签名
+)
+
+// PublicKey is a public key registered by a client for asymmetric request signatures.
+// 网关只保存公钥，私钥由客户自行保管
+type PublicKey struct {
+	KeyID       string    `json:"key_id" bson:"key_id"`
+	Algorithm   string    `json:"algorithm" bson:"algorithm"`     // ed25519 / ecdsa
+	PEM         string    `json:"public_key" bson:"pem"`          // PKIX PEM 格式公钥
+	Fingerprint string    `json:"fingerprint" bson:"fingerprint"` // DER 编码的 SHA-256
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// IsValidSignatureType returns true if the signature type is known
+func IsValidSignatureType(signatureType string) bool {
+	switch signatureType {
+	case SignatureTypeHMAC, SignatureTypeEd25519, SignatureTypeECDSA:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewPublicKey parses a PEM encoded PKIX public key.
+// Only Ed25519 and ECDSA P-256 keys are accepted; keyID defaults to the fingerprint prefix.
+func NewPublicKey(keyID, pemData string) (*PublicKey, error) {
+	_, algorithm, der, err := parsePublicKeyPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(der)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" {
+		keyID = fingerprint[:16]
+	}
+	if len(keyID) > 64 {
+		return nil, fmt.Errorf("invalid key id: longer than 64 characters")
+	}
+
+	return &PublicKey{
+		KeyID:       keyID,
+		Algorithm:   algorithm,
+		PEM:         string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Parse returns the parsed public key
+func (k *PublicKey) Parse() (crypto.PublicKey, error) {
+	key, _, _, err := parsePublicKeyPEM(k.PEM)
+	return key, err
+}
+
+// EffectiveSignatureType returns the signature type of the client, HMAC if unset
+func (c *Client) EffectiveSignatureType() string {
+	if c.SignatureType == "" {
+		return SignatureTypeHMAC
+	}
+	return c.SignatureType
+}
+
+// FindPublicKey returns the registered public key with the given ID and algorithm.
+// keyID 为空且只有一个匹配算法的公钥时返回该公钥
+func (c *Client) FindPublicKey(keyID, algorithm string) *PublicKey {
+	var match *PublicKey
+	for i := range c.PublicKeys {
+		key := &c.PublicKeys[i]
+		if key.Algorithm != algorithm {
+			continue
+		}
+		if keyID != "" {
+			if key.KeyID == keyID {
+				return key
+			}
+			continue
+		}
+		if match != nil {
+			return nil // 多个公钥时必须指定 key id
+		}
+		match = key
+	}
+	return match
+}
+
+// HasPublicKey returns true if the client has registered a public key of the algorithm
+func (c *Client) HasPublicKey(algorithm string) bool {
+	for _, key := range c.PublicKeys {
+		if key.Algorithm == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePublicKeyPEM(pemData string) (crypto.PublicKey, string, []byte, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemData)))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, "", nil, fmt.Errorf("invalid public key: expected PEM encoded PUBLIC KEY")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, SignatureTypeEd25519, block.Bytes, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, "", nil, fmt.Errorf("invalid public key: only ECDSA P-256 is supported")
+		}
+		return k, SignatureTypeECDSA, block.Bytes, nil
+	default:
+		return nil, "", nil, fmt.Errorf("invalid public key: unsupported key type")
+	}
+}
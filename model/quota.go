@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quota periods
+const (
+	QuotaPeriodDay   = "day"   // 自然日
+	QuotaPeriodMonth = "month" // 自然月
+)
+
+// Quota is a calendar window call quota, such as 10,000 calls per day.
+// 配置中的配额适用于所有客户，客户的同名配额覆盖配置
+type Quota struct {
+	Name      string   `json:"name" bson:"name"`
+	Period    string   `json:"period" bson:"period"`                     // day / month
+	Limit     int      `json:"limit,omitempty" bson:"limit,omitempty"`   // 窗口内允许的调用次数
+	Routes    []string `json:"routes,omitempty" bson:"routes,omitempty"` // 适用的路由模式，为空时适用所有路由
+	Mode      string   `json:"mode,omitempty" bson:"mode,omitempty"`     // enforce / shadow / off，默认 enforce
+	Unlimited bool     `json:"unlimited,omitempty" bson:"unlimited,omitempty"`
+}
+
+// IsValidQuotaPeriod returns true if the quota period is known
+func IsValidQuotaPeriod(period string) bool {
+	return period == QuotaPeriodDay || period == QuotaPeriodMonth
+}
+
+// ValidateQuotas validates a list of quotas
+func ValidateQuotas(quotas []Quota) error {
+	seen := make(map[string]bool, len(quotas))
+	for _, quota := range quotas {
+		if quota.Name == "" || strings.Contains(quota.Name, ":") {
+			return fmt.Errorf("invalid quota: name is required and must not contain ':'")
+		}
+		if seen[quota.Name] {
+			return fmt.Errorf("invalid quota: duplicate name %s", quota.Name)
+		}
+		seen[quota.Name] = true
+
+		if !isValidRuleMode(quota.Mode) {
+			return fmt.Errorf("invalid quota %s: unknown mode %q", quota.Name, quota.Mode)
+		}
+		if quota.Unlimited {
+			continue
+		}
+		if !IsValidQuotaPeriod(quota.Period) {
+			return fmt.Errorf("invalid quota %s: unknown period %q", quota.Name, quota.Period)
+		}
+		if quota.Limit <= 0 {
+			return fmt.Errorf("invalid quota %s: limit must be positive", quota.Name)
+		}
+		for _, route := range quota.Routes {
+			if !strings.HasPrefix(route, "/") || strings.Contains(strings.TrimSuffix(route, "*"), "*") {
+				return fmt.Errorf("invalid quota %s: invalid route pattern %q", quota.Name, route)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxTemporaryLimitDuration is the longest duration of a temporary rate limit adjustment
+const MaxTemporaryLimitDuration = 7 * 24 * time.Hour
+
+// RateLimitOverride is a per-client override of a configured rate limit rule
+type RateLimitOverride struct {
+	Rule      string `json:"rule" bson:"rule"`                         // 配置中的规则名
+	Limit     int    `json:"limit,omitempty" bson:"limit,omitempty"`   // 窗口内允许的请求数
+	Window    string `json:"window,omitempty" bson:"window,omitempty"` // 为空时沿用规则的窗口
+	Burst     int    `json:"burst,omitempty" bson:"burst,omitempty"`   // 为空时等于 limit
+	Mode      string `json:"mode,omitempty" bson:"mode,omitempty"`     // enforce / shadow / off，为空时沿用规则的模式
+	Unlimited bool   `json:"unlimited,omitempty" bson:"unlimited,omitempty"`
+}
+
+// ValidateRateLimitOverrides validates per-client rate limit overrides
+func ValidateRateLimitOverrides(overrides []RateLimitOverride) error {
+	seen := make(map[string]bool, len(overrides))
+	for _, override := range overrides {
+		if override.Rule == "" {
+			return fmt.Errorf("invalid rate limit override: rule is required")
+		}
+		if seen[override.Rule] {
+			return fmt.Errorf("invalid rate limit override: duplicate rule %s", override.Rule)
+		}
+		seen[override.Rule] = true
+
+		if !isValidRuleMode(override.Mode) {
+			return fmt.Errorf("invalid rate limit override %s: unknown mode %q", override.Rule, override.Mode)
+		}
+		if override.Unlimited {
+			continue
+		}
+		// 只设置 mode 时沿用规则的限额
+		if override.Limit < 0 || (override.Limit == 0 && override.Mode == "") {
+			return fmt.Errorf("invalid rate limit override %s: limit must be positive", override.Rule)
+		}
+		if override.Burst < 0 {
+			return fmt.Errorf("invalid rate limit override %s: burst must not be negative", override.Rule)
+		}
+		switch override.Window {
+		case "", "second", "minute", "hour":
+		default:
+			return fmt.Errorf("invalid rate limit override %s: unknown window %q", override.Rule, override.Window)
+		}
+	}
+	return nil
+}
+
+// TemporaryLimit is a temporary rate limit adjustment of a client, such as double QPS during an exam.
+// 到期后自动失效，无需手动删除
+type TemporaryLimit struct {
+	QPS        int       `json:"qps,omitempty" bson:"qps,omitempty"`               // 临时 QPS，为0时按倍数计算
+	Burst      int       `json:"burst,omitempty" bson:"burst,omitempty"`           // 临时突发请求数，为0时按倍数计算
+	Multiplier float64   `json:"multiplier,omitempty" bson:"multiplier,omitempty"` // QPS 和客户维度限流规则的倍数
+	Reason     string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedBy  string    `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ValidateTemporaryLimit validates a temporary rate limit adjustment
+func ValidateTemporaryLimit(limit *TemporaryLimit, now time.Time) error {
+	if limit.QPS < 0 || limit.Burst < 0 || limit.Multiplier < 0 {
+		return fmt.Errorf("invalid temporary limit: qps, burst and multiplier must not be negative")
+	}
+	if limit.QPS == 0 && limit.Multiplier == 0 {
+		return fmt.Errorf("invalid temporary limit: qps or multiplier is required")
+	}
+	if limit.Multiplier > 100 {
+		return fmt.Errorf("invalid temporary limit: multiplier must not exceed 100")
+	}
+	if !limit.ExpiresAt.After(now) {
+		return fmt.Errorf("invalid temporary limit: expires_at must be in the future")
+	}
+	if limit.ExpiresAt.Sub(now) > MaxTemporaryLimitDuration {
+		return fmt.Errorf("invalid temporary limit: duration must not exceed %s", MaxTemporaryLimitDuration)
+	}
+	return nil
+}
+
+// ActiveTemporaryLimit returns the temporary limit of the client if it has not expired
+func (c *Client) ActiveTemporaryLimit(now time.Time) *TemporaryLimit {
+	if c.TemporaryLimit == nil || !now.Before(c.TemporaryLimit.ExpiresAt) {
+		return nil
+	}
+	return c.TemporaryLimit
+}
+
+// EffectiveQPS returns the QPS and burst size of the client with the active temporary limit applied
+func (c *Client) EffectiveQPS(now time.Time) (int, int) {
+	temp := c.ActiveTemporaryLimit(now)
+	if temp == nil {
+		return c.QPS, c.Burst
+	}
+
+	qps, burst := c.QPS, c.Burst
+	if temp.Multiplier > 0 {
+		qps = temp.Scale(c.QPS)
+		burst = temp.Scale(c.Burst)
+	}
+	if temp.QPS > 0 {
+		qps = temp.QPS
+	}
+	if temp.Burst > 0 {
+		burst = temp.Burst
+	}
+	return qps, burst
+}
+
+// Scale multiplies a limit by the multiplier, the result is at least 1 for positive limits
+func (t *TemporaryLimit) Scale(limit int) int {
+	if t.Multiplier <= 0 || limit <= 0 {
+		return limit
+	}
+	return int(math.Max(1, math.Round(float64(limit)*t.Multiplier)))
+}
+
+// RateLimitOverride returns the client's override of the rule, nil if not overridden
+func (c *Client) RateLimitOverride(rule string) *RateLimitOverride {
+	for i := range c.RateLimits {
+		if c.RateLimits[i].Rule == rule {
+			return &c.RateLimits[i]
+		}
+	}
+	return nil
+}
+
+// isValidRuleMode returns true if the mode of a rate limit rule or quota is known
+func isValidRuleMode(mode string) bool {
+	switch mode {
+	case "", "enforce", "shadow", "off":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,209 @@
+// Package client 是调用 API 网关的 Go SDK，负责请求签名、同步/流式调用和异步任务
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config SDK 配置
+type Config struct {
+	BaseURL          string       // 网关地址，如 https://gateway.example.com
+	APIKey           string       // X-API-Key
+	Secret           string       // 签名密钥
+	SignatureVersion int          // 签名版本，默认 v2
+	DisableNonce     bool         // 不发送 X-Nonce，仅用于未开启防重放的网关
+	HTTPClient       *http.Client // 为空时使用默认客户端，超时由 context 控制
+	UserAgent        string
+}
+
+// Client 网关客户端，可并发使用
+type Client struct {
+	baseURL    string
+	signer     *Signer
+	httpClient *http.Client
+	userAgent  string
+}
+
+// New 创建网关客户端
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	if cfg.APIKey == "" || cfg.Secret == "" {
+		return nil, fmt.Errorf("API key and secret are required")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = "api-gateway-go-sdk/1.0"
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		signer: &Signer{
+			APIKey:  cfg.APIKey,
+			Secret:  cfg.Secret,
+			Version: cfg.SignatureVersion,
+			NoNonce: cfg.DisableNonce,
+		},
+		httpClient: httpClient,
+		userAgent:  userAgent,
+	}, nil
+}
+
+// Do 发送签名请求并返回原始响应，非 2xx 响应返回 *APIError
+// 调用方负责关闭响应体
+func (c *Client) Do(ctx context.Context, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if len(body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	if err := c.signer.Sign(req, body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, newAPIError(resp, respBody)
+	}
+
+	return resp, nil
+}
+
+// Call 同步调用：以 JSON 发送 in，并将响应解析到 out（out 为空时丢弃响应体）
+func (c *Client) Call(ctx context.Context, path string, in, out interface{}) error {
+	body, err := marshalBody(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Stream 流式调用，如 /api/essay/evaluate/stream，使用完毕后需调用 Close
+func (c *Client) Stream(ctx context.Context, path string, in interface{}) (*Stream, error) {
+	body, err := marshalBody(in)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "text/event-stream")
+
+	resp, err := c.Do(ctx, http.MethodPost, path, body, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stream{
+		body:   resp.Body,
+		reader: bufio.NewReader(resp.Body),
+		Header: resp.Header,
+	}, nil
+}
+
+// Stream 流式响应
+type Stream struct {
+	Header http.Header
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// Recv 读取下一个 Server-Sent Events 事件的 data 内容，多行 data 以换行连接
+// 流结束时返回 io.EOF
+func (s *Stream) Recv() ([]byte, error) {
+	var data [][]byte
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		trimmed := bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(trimmed) == 0 && len(line) > 0:
+			// 空行表示事件结束
+			if len(data) > 0 {
+				return bytes.Join(data, []byte("\n")), nil
+			}
+		case bytes.HasPrefix(trimmed, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(trimmed, []byte("data:")), []byte(" ")))
+		}
+
+		if err != nil {
+			if err == io.EOF && len(data) > 0 {
+				return bytes.Join(data, []byte("\n")), nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// Read 以原始字节读取流，适用于非 SSE 的分块响应
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Close 关闭流
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
+
+func marshalBody(in interface{}) ([]byte, error) {
+	switch v := in.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case json.RawMessage:
+		return v, nil
+	default:
+		body, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		return body, nil
+	}
+}
+
+// defaultPollInterval WaitTask 默认轮询间隔
+const defaultPollInterval = 2 * time.Second
@@ -0,0 +1,521 @@
+package client_test
+
+import (
+	"api-gateway/config"
+	"api-gateway/database"
+	"api-gateway/model"
+	"api-gateway/pkg/client"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/secrets"
+	"api-gateway/repository"
+	"api-gateway/router"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// gateway 使用内存仓库的完整网关，SetupRouter 注册的指标只能初始化一次，所有测试共用
+var (
+	gateway *httptest.Server
+	clients *memoryClientRepo
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runGateway(m))
+}
+
+func runGateway(m *testing.M) int {
+	logger.Init("client-test")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstream.Close()
+
+	dir, err := os.MkdirTemp("", "client-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "config.yaml")
+	configData := fmt.Sprintf(`
+auth:
+  enable_signature: true
+  signature_time_window: 300
+targets:
+  v1:
+    url: %s
+    timeout: 5000
+`, upstream.URL)
+	if err := os.WriteFile(configPath, []byte(configData), 0o600); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	os.Setenv("CONFIG_PATH", configPath)
+	if _, err := config.NewConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	clients = &memoryClientRepo{clients: make(map[primitive.ObjectID]*model.Client)}
+	dbManager := &database.DatabaseManager{
+		ClientRepo:      clients,
+		CallLogRepo:     &memoryCallLogRepo{},
+		CredentialRepo:  &memoryCredentialRepo{credentials: make(map[primitive.ObjectID]*model.Credential)},
+		ReservationRepo: &memoryReservationRepo{reservations: make(map[primitive.ObjectID]*model.CallReservation)},
+	}
+
+	r, err := router.SetupRouter(dbManager, nil, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	gateway = httptest.NewServer(r)
+	defer gateway.Close()
+
+	return m.Run()
+}
+
+func TestSignerAcceptedByGateway(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		header  http.Header
+	}{
+		{name: "default", version: 0},
+		{name: "v1", version: client.SignatureV1},
+		{name: "v2", version: client.SignatureV2},
+		{name: "v2 callback headers", version: client.SignatureV2, header: http.Header{
+			"X-Callback-Url":    {"https://example.com/callback"},
+			"X-Callback-Method": {"PUT"},
+			"X-Callback-Auth":   {"Bearer callback-token"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKey, secret, _ := newGatewayClient(t, 10, 100)
+			c := newSDKClient(t, apiKey, secret, tt.version)
+
+			resp, err := c.Do(context.Background(), http.MethodPost, "/api/math/process", []byte(`{"q":"1+1"}`), tt.header)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != `{"ok":true}` {
+				t.Errorf("response body = %s, want upstream response", body)
+			}
+		})
+	}
+}
+
+func TestSignerWrongSecretRejected(t *testing.T) {
+	for _, version := range []int{client.SignatureV1, client.SignatureV2} {
+		apiKey, _, _ := newGatewayClient(t, 10, 100)
+		c := newSDKClient(t, apiKey, randomHex(t, 32), version)
+
+		err := c.Call(context.Background(), "/api/math/process", map[string]string{"q": "1+1"}, nil)
+
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("v%d: Call() error = %v, want *APIError", version, err)
+		}
+		if !apiErr.IsAuthError() {
+			t.Errorf("v%d: StatusCode = %d, want 401", version, apiErr.StatusCode)
+		}
+	}
+}
+
+func TestInsufficientCallsError(t *testing.T) {
+	apiKey, secret, id := newGatewayClient(t, 0, 100)
+	c := newSDKClient(t, apiKey, secret, client.SignatureV2)
+
+	err := c.Call(context.Background(), "/api/math/process", map[string]string{"q": "1+1"}, nil)
+
+	if !errors.Is(err, client.ErrInsufficientCalls) {
+		t.Fatalf("Call() error = %v, want ErrInsufficientCalls", err)
+	}
+	if errors.Is(err, client.ErrInvalidAPIKey) {
+		t.Errorf("Call() error matches ErrInvalidAPIKey")
+	}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != http.StatusPaymentRequired {
+		t.Errorf("StatusCode = %d, want 402", apiErr.StatusCode)
+	}
+	if got := clients.callCount(id); got != 0 {
+		t.Errorf("call count = %d, want 0", got)
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	apiKey, secret, id := newGatewayClient(t, 10, 1)
+	c := newSDKClient(t, apiKey, secret, client.SignatureV2)
+
+	if err := c.Call(context.Background(), "/api/math/process", nil, nil); err != nil {
+		t.Fatalf("first Call() error = %v", err)
+	}
+	err := c.Call(context.Background(), "/api/math/process", nil, nil)
+
+	if !errors.Is(err, client.ErrRateLimitExceeded) {
+		t.Fatalf("second Call() error = %v, want ErrRateLimitExceeded", err)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter < time.Second {
+		t.Errorf("RetryAfter = %v, want at least 1s", apiErr.RetryAfter)
+	}
+	if !apiErr.IsRetryable() {
+		t.Errorf("IsRetryable() = false, want true")
+	}
+	// 被限流的请求不扣费
+	if got := clients.callCount(id); got != 9 {
+		t.Errorf("call count = %d, want 9", got)
+	}
+}
+
+func TestAPIErrorParsing(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     map[string]string
+		body       string
+		wantCode   int
+		wantRetry  time.Duration
+		wantDetail string
+	}{
+		{
+			name:      "retry after",
+			status:    http.StatusTooManyRequests,
+			header:    map[string]string{"Retry-After": "3"},
+			body:      `{"code":42903,"message":"banned"}`,
+			wantCode:  42903,
+			wantRetry: 3 * time.Second,
+		},
+		{
+			name:      "rate limit reset without retry after",
+			status:    http.StatusTooManyRequests,
+			header:    map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "7"},
+			body:      `{"code":42902,"message":"limited"}`,
+			wantCode:  42902,
+			wantRetry: 7 * time.Second,
+		},
+		{
+			name:      "reset ignored while remaining",
+			status:    http.StatusTooManyRequests,
+			header:    map[string]string{"RateLimit-Remaining": "2", "RateLimit-Reset": "7"},
+			body:      `{"code":42904,"message":"quota"}`,
+			wantCode:  42904,
+			wantRetry: 0,
+		},
+		{
+			name:       "auth error detail",
+			status:     http.StatusUnauthorized,
+			body:       `{"code":40101,"message":"Signature validation failed","error":"invalid signature"}`,
+			wantCode:   40101,
+			wantDetail: "invalid signature",
+		},
+		{
+			name:       "non gateway body",
+			status:     http.StatusBadGateway,
+			body:       "bad gateway",
+			wantDetail: "bad gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range tt.header {
+					w.Header().Set(name, value)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := client.New(client.Config{BaseURL: server.URL, APIKey: "key", Secret: "secret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.Call(context.Background(), "/api/math/process", nil, nil)
+
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Call() error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.wantCode {
+				t.Errorf("status, code = %d, %d, want %d, %d", apiErr.StatusCode, apiErr.Code, tt.status, tt.wantCode)
+			}
+			if apiErr.RetryAfter != tt.wantRetry {
+				t.Errorf("RetryAfter = %v, want %v", apiErr.RetryAfter, tt.wantRetry)
+			}
+			if apiErr.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", apiErr.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+// newGatewayClient 在网关中创建一个客户，返回 API 密钥、签名密钥和客户ID
+func newGatewayClient(t *testing.T, callCount, qps int) (string, string, primitive.ObjectID) {
+	t.Helper()
+
+	apiKey := randomHex(t, 16)
+	secret := randomHex(t, 32)
+	c := model.NewClient(t.Name(), apiKey, secret, "v1", callCount)
+	c.ID = primitive.NewObjectID()
+	c.QPS = qps
+	c.Burst = qps
+	clients.add(c)
+	return apiKey, secret, c.ID
+}
+
+func newSDKClient(t *testing.T, apiKey, secret string, version int) *client.Client {
+	t.Helper()
+
+	c, err := client.New(client.Config{
+		BaseURL:          gateway.URL,
+		APIKey:           apiKey,
+		Secret:           secret,
+		SignatureVersion: version,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func randomHex(t *testing.T, n int) string {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// memoryClientRepo 网关请求路径上用到的客户仓库方法，其余方法未实现
+type memoryClientRepo struct {
+	repository.ClientRepository
+
+	mu      sync.Mutex
+	clients map[primitive.ObjectID]*model.Client
+}
+
+func (r *memoryClientRepo) add(c *model.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *c
+	stored.APIKeyHash = secrets.HashAPIKey(c.APIKey)
+	stored.APIKeyPrefix = secrets.KeyPrefix(c.APIKey)
+	stored.APIKey = ""
+	r.clients[c.ID] = &stored
+}
+
+func (r *memoryClientRepo) callCount(id primitive.ObjectID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clients[id].CallCount
+}
+
+func (r *memoryClientRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client not found")
+	}
+	found := *c
+	return &found, nil
+}
+
+func (r *memoryClientRepo) GetByAPIKey(ctx context.Context, apiKey string) (*model.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := secrets.HashAPIKey(apiKey)
+	for _, c := range r.clients {
+		if c.APIKeyHash == hash {
+			found := *c
+			found.APIKey = apiKey
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("client not found")
+}
+
+func (r *memoryClientRepo) DeductCallCount(ctx context.Context, id primitive.ObjectID, amount int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.clients[id]
+	if !ok || c.CallCount < amount {
+		return 0, repository.ErrInsufficientCalls
+	}
+	c.CallCount -= amount
+	return c.CallCount, nil
+}
+
+func (r *memoryClientRepo) RefundCallCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.clients[id]
+	if !ok {
+		return fmt.Errorf("client not found")
+	}
+	c.CallCount += amount
+	return nil
+}
+
+// memoryCredentialRepo 内存凭证仓库，主密钥首次使用时迁移到这里
+type memoryCredentialRepo struct {
+	repository.CredentialRepository
+
+	mu          sync.Mutex
+	credentials map[primitive.ObjectID]*model.Credential
+}
+
+func (r *memoryCredentialRepo) Create(ctx context.Context, credential *model.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if credential.ID.IsZero() {
+		credential.ID = primitive.NewObjectID()
+	}
+	stored := *credential
+	if stored.APIKey != "" {
+		stored.APIKeyHash = secrets.HashAPIKey(stored.APIKey)
+		stored.APIKey = ""
+	}
+	for _, existing := range r.credentials {
+		if existing.APIKeyHash == stored.APIKeyHash {
+			return fmt.Errorf("duplicate api_key_hash")
+		}
+	}
+	r.credentials[stored.ID] = &stored
+	return nil
+}
+
+func (r *memoryCredentialRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Credential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, fmt.Errorf("credential not found")
+	}
+	found := *credential
+	return &found, nil
+}
+
+func (r *memoryCredentialRepo) GetByAPIKey(ctx context.Context, apiKey string) (*model.Credential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := secrets.HashAPIKey(apiKey)
+	for _, credential := range r.credentials {
+		if credential.APIKeyHash == hash {
+			found := *credential
+			found.APIKey = apiKey
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("credential not found")
+}
+
+func (r *memoryCredentialRepo) ListByClientID(ctx context.Context, clientID primitive.ObjectID) ([]*model.Credential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []*model.Credential
+	for _, credential := range r.credentials {
+		if credential.ClientID == clientID {
+			copied := *credential
+			found = append(found, &copied)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryCredentialRepo) UpdateLastUsed(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if credential, ok := r.credentials[id]; ok {
+		credential.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+// memoryCallLogRepo 丢弃调用日志
+type memoryCallLogRepo struct {
+	repository.CallLogRepository
+}
+
+func (r *memoryCallLogRepo) Create(ctx context.Context, log *model.CallLog) error {
+	return nil
+}
+
+// memoryReservationRepo 内存预扣记录仓库
+type memoryReservationRepo struct {
+	mu           sync.Mutex
+	reservations map[primitive.ObjectID]*model.CallReservation
+}
+
+func (r *memoryReservationRepo) Create(ctx context.Context, reservation *model.CallReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reservation.ID.IsZero() {
+		reservation.ID = primitive.NewObjectID()
+	}
+	stored := *reservation
+	r.reservations[stored.ID] = &stored
+	return nil
+}
+
+func (r *memoryReservationRepo) Transition(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservation, ok := r.reservations[id]
+	if !ok || reservation.Status != from {
+		return false, nil
+	}
+	reservation.Status = to
+	return true, nil
+}
+
+func (r *memoryReservationRepo) FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.CallReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []*model.CallReservation
+	for _, reservation := range r.reservations {
+		if reservation.Status == model.ReservationHeld && reservation.ExpiresAt.Before(before) && len(found) < limit {
+			copied := *reservation
+			found = append(found, &copied)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	apierrors "api-gateway/errors"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 网关错误码，与 errors 包一致
+var (
+	ErrInvalidAPIKey      = &APIError{Code: apierrors.ErrInvalidAPIKey}
+	ErrClientDisabled     = &APIError{Code: apierrors.ErrClientDisabled}
+	ErrClientExpired      = &APIError{Code: apierrors.ErrClientExpired}
+	ErrClientNotYetValid  = &APIError{Code: apierrors.ErrClientNotValid}
+	ErrUnsupportedVersion = &APIError{Code: apierrors.ErrUnsupportedVersion}
+	ErrInsufficientCalls  = &APIError{Code: apierrors.ErrInsufficientCalls}
+	ErrIPNotAllowed       = &APIError{Code: apierrors.ErrIPNotAllowed}
+	ErrRouteNotAllowed    = &APIError{Code: apierrors.ErrRouteNotAllowed}
+	ErrOriginNotAllowed   = &APIError{Code: apierrors.ErrOriginNotAllowed}
+	ErrCallLimitExceeded  = &APIError{Code: apierrors.ErrCallLimitExceeded}
+	ErrRateLimitExceeded  = &APIError{Code: apierrors.ErrRateLimitExceeded}
+	ErrAuthBanned         = &APIError{Code: apierrors.ErrAuthBanned}
+	ErrQuotaExceeded      = &APIError{Code: apierrors.ErrQuotaExceeded}
+	ErrUpstreamTimeout    = &APIError{Code: apierrors.ErrUpstreamTimeout}
+	ErrUpstreamError      = &APIError{Code: apierrors.ErrUpstreamError}
+	ErrUpstreamOverloaded = &APIError{Code: apierrors.ErrUpstreamOverloaded}
+)
+
+// APIError 网关返回的错误
+// 使用 errors.Is(err, client.ErrInsufficientCalls) 判断错误类型
+type APIError struct {
+	StatusCode int                    // HTTP 状态码
+	Code       int                    // 网关错误码
+	Message    string                 // 错误信息
+	Detail     string                 // 签名、令牌等认证错误的具体原因
+	Data       map[string]interface{} // 附加数据，如 remaining_calls
+	RetryAfter time.Duration          // 限流或封禁时建议的重试间隔
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("gateway error %d (HTTP %d): %s: %s", e.Code, e.StatusCode, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("gateway error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Is 按错误码比较
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// IsAuthError 是否为认证失败（签名、令牌或 API 密钥错误）
+func (e *APIError) IsAuthError() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// IsRetryable 是否可以稍后重试（限流、封禁、上游超时或服务不可用）
+func (e *APIError) IsRetryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusServiceUnavailable ||
+		e.StatusCode == http.StatusGatewayTimeout
+}
+
+// errorBody 兼容 errors 包（code/message/data）和认证中间件（code/message/error）两种响应格式
+type errorBody struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Error   string                 `json:"error"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// newAPIError 从非 2xx 响应构建错误，响应体不是网关错误格式时保留原始内容
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var parsed errorBody
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Code != 0 {
+		apiErr.Code = parsed.Code
+		apiErr.Message = parsed.Message
+		apiErr.Detail = parsed.Error
+		apiErr.Data = parsed.Data
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		apiErr.Detail = string(body)
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("RateLimit-Remaining") == "0" {
+		// 没有 Retry-After 时按限流配额恢复时间重试
+		if seconds, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return apiErr
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名版本，与网关 X-Signature-Version 一致
+const (
+	SignatureV1 = 1 // 方法 + 路径 + 时间戳 + [nonce] + 请求体哈希
+	SignatureV2 = 2 // 规范化请求，覆盖查询参数和请求头
+)
+
+// signatureV2Algorithm v2 待签名字符串的算法标识
+const signatureV2Algorithm = "GW2-HMAC-SHA256"
+
+// signHeaders v2 中出现时一并签名的请求头，均为网关强制要求
+var signHeaders = []string{"content-type", "x-async", "x-callback-url", "x-callback-method", "x-callback-auth"}
+
+// Signer 按网关 HMACSignatureValidator 的规则为请求签名
+type Signer struct {
+	APIKey  string
+	Secret  string
+	Version int  // 默认 v2
+	NoNonce bool // 为 true 时不发送 X-Nonce（仅用于未开启防重放的旧网关）
+	Now     func() time.Time
+}
+
+// Sign 设置 X-API-Key、X-Timestamp、X-Nonce 和 X-Signature 等请求头
+// body 必须与请求实际发送的请求体一致
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	var nonce string
+	if !s.NoNonce {
+		var err error
+		if nonce, err = newNonce(); err != nil {
+			return fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		req.Header.Set("X-Nonce", nonce)
+	}
+
+	req.Header.Set("X-API-Key", s.APIKey)
+	req.Header.Set("X-Timestamp", timestamp)
+
+	bodyHash := fmt.Sprintf("%x", sha256.Sum256(body))
+
+	var signature string
+	switch s.Version {
+	case SignatureV1:
+		signature = signV1(req.Method, req.URL.Path, timestamp, nonce, bodyHash, s.Secret)
+	case 0, SignatureV2:
+		signedHeaders := []string{"host"}
+		for _, name := range signHeaders {
+			if req.Header.Get(name) != "" {
+				signedHeaders = append(signedHeaders, name)
+			}
+		}
+		sort.Strings(signedHeaders)
+
+		req.Header.Set("X-Signature-Version", strconv.Itoa(SignatureV2))
+		req.Header.Set("X-Signed-Headers", strings.Join(signedHeaders, ";"))
+		signature = signV2(canonicalRequest(req, signedHeaders, bodyHash), timestamp, nonce, s.Secret)
+	default:
+		return fmt.Errorf("unsupported signature version: %d", s.Version)
+	}
+
+	req.Header.Set("X-Signature", signature)
+	return nil
+}
+
+// signV1 HTTP方法 \n 路径 \n 时间戳 \n [nonce \n] 请求体哈希
+func signV1(method, path, timestamp, nonce, bodyHash, secret string) string {
+	message := fmt.Sprintf("%s\n%s\n%s\n%s", method, path, timestamp, bodyHash)
+	if nonce != "" {
+		message = fmt.Sprintf("%s\n%s\n%s\n%s\n%s", method, path, timestamp, nonce, bodyHash)
+	}
+	return hmacBase64(secret, message)
+}
+
+// signV2 GW2-HMAC-SHA256 \n 时间戳 \n nonce \n hex(sha256(规范化请求))
+func signV2(canonical, timestamp, nonce, secret string) string {
+	digest := sha256.Sum256([]byte(canonical))
+	return hmacBase64(secret, fmt.Sprintf("%s\n%s\n%s\n%x", signatureV2Algorithm, timestamp, nonce, digest))
+}
+
+// canonicalRequest 方法 \n 路径 \n 排序后的查询串 \n 规范化请求头 \n 签名头列表 \n 请求体哈希
+func canonicalRequest(req *http.Request, signedHeaders []string, bodyHash string) string {
+	var b strings.Builder
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	b.WriteString(req.Method)
+	b.WriteString("\n")
+	b.WriteString(path)
+	b.WriteString("\n")
+	b.WriteString(canonicalQuery(req.URL.Query()))
+	b.WriteString("\n")
+	for _, name := range signedHeaders {
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(canonicalHeaderValue(req, name))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteString("\n")
+	b.WriteString(bodyHash)
+
+	return b.String()
+}
+
+func canonicalQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			pairs = append(pairs, rfc3986Escape(key)+"="+rfc3986Escape(val))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func canonicalHeaderValue(req *http.Request, name string) string {
+	if name == "host" {
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		return strings.TrimSpace(host)
+	}
+
+	values := req.Header.Values(name)
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		normalized[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(normalized, ",")
+}
+
+func rfc3986Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacBase64(secret, message string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
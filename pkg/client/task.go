@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Task statuses，与 model.TaskStatus 一致
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusProcessing = "processing"
+	TaskStatusSuccess    = "success"
+	TaskStatusFailed     = "failed"
+	TaskStatusTimeout    = "timeout"
+)
+
+// Callback 异步任务完成后的回调设置，URL 为空时只能通过轮询获取结果
+type Callback struct {
+	URL    string // X-Callback-URL
+	Method string // X-Callback-Method，默认 POST
+	Auth   string // X-Callback-Auth，回调时原样放在 Authorization 头中
+}
+
+// Task 异步任务
+type Task struct {
+	TaskID      string          `json:"task_id"`
+	Status      string          `json:"status"`
+	CallbackURL string          `json:"callback_url,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// Done 任务是否已结束
+func (t *Task) Done() bool {
+	switch t.Status {
+	case TaskStatusSuccess, TaskStatusFailed, TaskStatusTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// taskResponse 网关任务接口的响应格式
+type taskResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    *Task  `json:"data"`
+}
+
+// Submit 提交异步任务，网关扣费并入队后立即返回任务ID
+func (c *Client) Submit(ctx context.Context, path string, in interface{}, callback Callback) (*Task, error) {
+	body, err := marshalBody(in)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("X-Async", "true")
+	if callback.URL != "" {
+		header.Set("X-Callback-URL", callback.URL)
+		if callback.Method != "" {
+			header.Set("X-Callback-Method", callback.Method)
+		}
+		if callback.Auth != "" {
+			header.Set("X-Callback-Auth", callback.Auth)
+		}
+	}
+
+	resp, err := c.Do(ctx, http.MethodPost, path, body, header)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTask(resp)
+}
+
+// TaskStatus 查询任务状态
+func (c *Client) TaskStatus(ctx context.Context, taskID string) (*Task, error) {
+	if taskID == "" {
+		return nil, fmt.Errorf("task id is required")
+	}
+
+	resp, err := c.Do(ctx, http.MethodGet, "/api/tasks/"+url.PathEscape(taskID)+"/status", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTask(resp)
+}
+
+// WaitTask 轮询任务状态直到任务结束或 ctx 取消，interval 为 0 时使用默认间隔
+// 任务失败或超时不作为错误返回，调用方需检查 Task.Status
+func (c *Client) WaitTask(ctx context.Context, taskID string, interval time.Duration) (*Task, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		task, err := c.TaskStatus(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task.Done() {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func decodeTask(resp *http.Response) (*Task, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result taskResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode task response: %w", err)
+	}
+	if result.Data == nil {
+		return nil, fmt.Errorf("invalid task response: %s", result.Message)
+	}
+	return result.Data, nil
+}
@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// JWK 单个JSON Web Key（仅支持 RSA 和 P-256 EC 公钥）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// KeySet 按 kid 索引的公钥集合
+type KeySet map[string]interface{}
+
+// LoadJWKS 从文件加载JWKS
+func LoadJWKS(path string) (KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file %s: %w", path, err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS 解析JWKS文档
+func ParseJWKS(data []byte) (KeySet, error) {
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(KeySet, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Kid == "" {
+			return nil, fmt.Errorf("JWKS key without kid")
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %s: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// PublicKey 将JWK转换为公钥
+func (k *JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
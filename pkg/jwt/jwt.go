@@ -0,0 +1,226 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// Header JWT头部
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Audience 兼容字符串和字符串数组两种格式的 aud 声明
+type Audience []string
+
+// UnmarshalJSON 解析 aud 声明
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("invalid aud claim")
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains 判断是否包含指定受众
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims JWT注册声明
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Token 已解析但未验证签名的JWT
+type Token struct {
+	Header       Header
+	Claims       Claims
+	payload      []byte
+	signingInput string
+	signature    []byte
+}
+
+// Parse 解析JWT，不验证签名和声明
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token payload: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	token := &Token{
+		payload:      payload,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}
+	if err := json.Unmarshal(headerBytes, &token.Header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	if err := json.Unmarshal(payload, &token.Claims); err != nil {
+		return nil, fmt.Errorf("malformed token payload: %w", err)
+	}
+
+	return token, nil
+}
+
+// DecodeClaims 将载荷解析到自定义声明结构
+func (t *Token) DecodeClaims(v interface{}) error {
+	if err := json.Unmarshal(t.payload, v); err != nil {
+		return fmt.Errorf("malformed token payload: %w", err)
+	}
+	return nil
+}
+
+// Verify 使用给定密钥验证签名
+// HS256 使用 []byte，RS256 使用 *rsa.PublicKey，ES256 使用 *ecdsa.PublicKey
+func (t *Token) Verify(key interface{}) error {
+	digest := sha256.Sum256([]byte(t.signingInput))
+
+	switch t.Header.Alg {
+	case AlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("invalid key type for %s", t.Header.Alg)
+		}
+		h := hmac.New(sha256.New, secret)
+		h.Write([]byte(t.signingInput))
+		if !hmac.Equal(t.signature, h.Sum(nil)) {
+			return fmt.Errorf("invalid token signature")
+		}
+
+	case AlgRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key type for %s", t.Header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], t.signature); err != nil {
+			return fmt.Errorf("invalid token signature")
+		}
+
+	case AlgES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key type for %s", t.Header.Alg)
+		}
+		// JWS 中 ES256 签名为 r||s 各32字节
+		if len(t.signature) != 64 {
+			return fmt.Errorf("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(t.signature[:32])
+		s := new(big.Int).SetBytes(t.signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return fmt.Errorf("invalid token signature")
+		}
+
+	default:
+		return fmt.Errorf("unsupported token algorithm: %s", t.Header.Alg)
+	}
+
+	return nil
+}
+
+// ValidationOptions 声明校验选项
+type ValidationOptions struct {
+	Audience  string        // 期望的受众，为空时不校验
+	Issuer    string        // 期望的签发方，为空时不校验
+	ClockSkew time.Duration // 允许的时钟偏差
+	Now       time.Time
+}
+
+// ValidateClaims 校验过期时间、生效时间、受众和签发方
+func (c *Claims) ValidateClaims(opts ValidationOptions) error {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("invalid token claims: missing exp")
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(opts.ClockSkew)) {
+		return fmt.Errorf("token expired")
+	}
+	if c.NotBefore != 0 && now.Add(opts.ClockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("token not yet valid")
+	}
+	if c.IssuedAt != 0 && now.Add(opts.ClockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return fmt.Errorf("token not yet valid")
+	}
+	if opts.Audience != "" && !c.Audience.Contains(opts.Audience) {
+		return fmt.Errorf("invalid token claims: audience mismatch")
+	}
+	if opts.Issuer != "" && c.Issuer != opts.Issuer {
+		return fmt.Errorf("invalid token claims: issuer mismatch")
+	}
+
+	return nil
+}
+
+// SignHS256 使用 HS256 签发令牌，claims 为任意可JSON序列化的声明结构
+func SignHS256(claims interface{}, kid string, secret []byte) (string, error) {
+	headerBytes, err := json.Marshal(Header{Alg: AlgHS256, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil)), nil
+}
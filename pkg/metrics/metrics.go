@@ -13,6 +13,20 @@ type APIGatewayMetrics struct {
 	RequestsInFlight *prometheus.GaugeVec
 	RequestTimeouts  *prometheus.CounterVec
 	RequestErrors    *prometheus.CounterVec
+	IPRejections     *prometheus.CounterVec
+	OriginRejections *prometheus.CounterVec
+	RateLimitErrors  *prometheus.CounterVec
+	ShadowViolations *prometheus.CounterVec
+	RateLimitLimit   *prometheus.GaugeVec
+	RateLimitLeft    *prometheus.GaugeVec
+	QuotaLimit       *prometheus.GaugeVec
+	QuotaUsed        *prometheus.GaugeVec
+	UpstreamLimit    *prometheus.GaugeVec
+	UpstreamInFlight *prometheus.GaugeVec
+	UpstreamShed     *prometheus.CounterVec
+	AuthFailures     *prometheus.CounterVec
+	AuthBans         *prometheus.CounterVec
+	AuthBanRejects   *prometheus.CounterVec
 }
 
 var (
@@ -102,6 +116,159 @@ func InitMetrics() *APIGatewayMetrics {
 			},
 			[]string{"client", "error_type"},
 		),
+
+		// IP 访问控制拒绝计数器
+		// Labels: client, rule (allowlist / denylist)
+		IPRejections: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "ip_rejections_total",
+				Help:      "Total number of requests rejected by client IP rules",
+			},
+			[]string{"client", "rule"},
+		),
+
+		// 浏览器请求来源拒绝计数器
+		// Labels: client
+		OriginRejections: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "origin_rejections_total",
+				Help:      "Total number of browser requests rejected by CORS origin allowlists",
+			},
+			[]string{"client"},
+		),
+
+		// 分布式限流后端错误计数器
+		// Labels: fail_mode (local / open / closed)
+		RateLimitErrors: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "rate_limit_backend_errors_total",
+				Help:      "Total number of rate limit checks that fell back because the shared backend failed",
+			},
+			[]string{"fail_mode"},
+		),
+		// shadow 模式下超过限流规则或调用配额的请求计数器
+		// Labels: client (格式: name-version), rule (限流规则名或配额名)
+		ShadowViolations: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "rate_limit_shadow_violations_total",
+				Help:      "Total number of requests that exceeded a rate limit rule or quota in shadow mode",
+			},
+			[]string{"client", "rule"},
+		),
+
+		// 客户限流规则的当前限制，包含临时调整
+		// Labels: client, rule
+		RateLimitLimit: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "rate_limit_limit",
+				Help:      "Current burst capacity of each client rate limit rule, including temporary adjustments",
+			},
+			[]string{"client", "rule"},
+		),
+
+		// 客户限流规则最近一次请求后的剩余配额
+		// Labels: client, rule
+		RateLimitLeft: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "rate_limit_remaining",
+				Help:      "Remaining requests of each client rate limit rule after the latest request",
+			},
+			[]string{"client", "rule"},
+		),
+
+		// 客户调用配额的上限
+		// Labels: client, quota
+		QuotaLimit: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "quota_limit",
+				Help:      "Call limit of each client quota in its current window",
+			},
+			[]string{"client", "quota"},
+		),
+
+		// 客户调用配额在当前窗口的用量
+		// Labels: client, quota
+		QuotaUsed: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "quota_used",
+				Help:      "Calls counted against each client quota in its current window",
+			},
+			[]string{"client", "quota"},
+		),
+
+		// 上游自适应并发限制
+		// Labels: upstream
+		UpstreamLimit: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "upstream_concurrency_limit",
+				Help:      "Current adaptive concurrency limit of each upstream",
+			},
+			[]string{"upstream"},
+		),
+
+		// 上游当前并发数
+		// Labels: upstream
+		UpstreamInFlight: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "api_gateway",
+				Name:      "upstream_requests_in_flight",
+				Help:      "Current number of requests being proxied to each upstream",
+			},
+			[]string{"upstream"},
+		),
+
+		// 超过并发限制被拒绝的请求计数器
+		// Labels: upstream
+		UpstreamShed: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "upstream_shed_total",
+				Help:      "Total number of requests rejected by the adaptive concurrency limit",
+			},
+			[]string{"upstream"},
+		),
+
+		// 认证失败计数器
+		// Labels: reason (invalid_key / invalid_signature / invalid_token / invalid_client)
+		AuthFailures: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "auth_failures_total",
+				Help:      "Total number of failed API key authentications",
+			},
+			[]string{"reason"},
+		),
+
+		// 认证失败封禁计数器
+		// Labels: scope (ip / key)
+		AuthBans: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "auth_bans_total",
+				Help:      "Total number of temporary bans after repeated authentication failures",
+			},
+			[]string{"scope"},
+		),
+
+		// 被封禁而拒绝的请求计数器
+		// Labels: scope (ip / key)
+		AuthBanRejects: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "api_gateway",
+				Name:      "auth_ban_rejections_total",
+				Help:      "Total number of requests rejected because of an active ban",
+			},
+			[]string{"scope"},
+		),
 	}
 
 	DefaultMetrics = metrics
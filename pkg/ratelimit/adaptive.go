@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 自适应并发限制默认参数
+const (
+	defaultInitialConcurrency = 20
+	defaultMinConcurrency     = 1
+	defaultMaxConcurrency     = 200
+	defaultLatencyTolerance   = 2.0
+	defaultBackoffRatio       = 0.9
+)
+
+// 延迟指数加权平均系数：短期反映近期延迟，长期作为基线
+const (
+	shortLatencyWeight = 0.2
+	longLatencyWeight  = 0.01
+	baselineDrift      = 1.01
+)
+
+// AdaptiveConfig 自适应并发限制参数，为0时使用默认值
+type AdaptiveConfig struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	Tolerance    float64 // 近期延迟超过基线的倍数时减小限制
+	BackoffRatio float64 // 减小限制时乘以的系数
+}
+
+// ConcurrencyLimiter 自适应并发限制（AIMD）。
+// 近期延迟相对长期基线升高或请求失败时按比例减小限制，并发接近限制且延迟正常时逐步增加
+type ConcurrencyLimiter struct {
+	mu           sync.Mutex
+	limit        float64
+	inflight     int
+	minLimit     float64
+	maxLimit     float64
+	tolerance    float64
+	backoffRatio float64
+	shortLatency float64 // 秒
+	longLatency  float64 // 秒
+	lastDecrease time.Time
+	clock        Clock
+}
+
+// NewConcurrencyLimiter 创建自适应并发限制
+func NewConcurrencyLimiter(cfg AdaptiveConfig) *ConcurrencyLimiter {
+	return NewConcurrencyLimiterWithClock(cfg, time.Now)
+}
+
+// NewConcurrencyLimiterWithClock 使用指定时钟创建自适应并发限制，用于测试
+func NewConcurrencyLimiterWithClock(cfg AdaptiveConfig, clock Clock) *ConcurrencyLimiter {
+	minLimit := cfg.MinLimit
+	if minLimit <= 0 {
+		minLimit = defaultMinConcurrency
+	}
+	maxLimit := cfg.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = defaultMaxConcurrency
+	}
+	if maxLimit < minLimit {
+		maxLimit = minLimit
+	}
+	initial := cfg.InitialLimit
+	if initial <= 0 {
+		initial = defaultInitialConcurrency
+	}
+	initial = int(math.Max(float64(minLimit), math.Min(float64(maxLimit), float64(initial))))
+
+	tolerance := cfg.Tolerance
+	if tolerance <= 1 {
+		tolerance = defaultLatencyTolerance
+	}
+	backoffRatio := cfg.BackoffRatio
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		backoffRatio = defaultBackoffRatio
+	}
+
+	return &ConcurrencyLimiter{
+		limit:        float64(initial),
+		minLimit:     float64(minLimit),
+		maxLimit:     float64(maxLimit),
+		tolerance:    tolerance,
+		backoffRatio: backoffRatio,
+		clock:        clock,
+	}
+}
+
+// Permit 已获得的并发许可，请求结束后必须调用 Success、Dropped 或 Ignore 之一
+type Permit struct {
+	limiter *ConcurrencyLimiter
+	once    sync.Once
+}
+
+// Acquire 获取并发许可，并发数已达限制时返回 false
+func (l *ConcurrencyLimiter) Acquire() (*Permit, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inflight >= int(l.limit) {
+		return nil, false
+	}
+	l.inflight++
+	return &Permit{limiter: l}, true
+}
+
+// Success 请求成功，latency 为上游返回响应头的耗时
+func (p *Permit) Success(latency time.Duration) {
+	p.once.Do(func() { p.limiter.release(latency, false, true) })
+}
+
+// Dropped 请求超时或上游过载，减小并发限制
+func (p *Permit) Dropped() {
+	p.once.Do(func() { p.limiter.release(0, true, true) })
+}
+
+// Ignore 释放许可但不作为样本，如调用方取消请求
+func (p *Permit) Ignore() {
+	p.once.Do(func() { p.limiter.release(0, false, false) })
+}
+
+// release 释放许可并根据样本调整限制
+func (l *ConcurrencyLimiter) release(latency time.Duration, dropped, sample bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inflight := l.inflight
+	l.inflight--
+	if !sample {
+		return
+	}
+	if dropped {
+		l.decrease()
+		return
+	}
+
+	seconds := latency.Seconds()
+	if l.longLatency == 0 {
+		l.shortLatency = seconds
+		l.longLatency = seconds
+	} else {
+		l.shortLatency += (seconds - l.shortLatency) * shortLatencyWeight
+		l.longLatency += (seconds - l.longLatency) * longLatencyWeight
+	}
+
+	if l.shortLatency > l.longLatency*l.tolerance {
+		// 基线随之缓慢上调，上游延迟长期升高后不会一直压在最小限制
+		l.longLatency *= baselineDrift
+		l.decrease()
+		return
+	}
+	// 并发未用到一半时说明限制不是瓶颈，不再增加
+	if float64(inflight)*2 >= l.limit {
+		l.limit = math.Min(l.maxLimit, l.limit+1/l.limit)
+	}
+}
+
+// decrease 按比例减小限制，近期延迟内只减小一次，避免同一批慢请求连续减小
+func (l *ConcurrencyLimiter) decrease() {
+	now := l.clock()
+	if now.Sub(l.lastDecrease).Seconds() < l.shortLatency {
+		return
+	}
+	l.lastDecrease = now
+	l.limit = math.Max(l.minLimit, math.Floor(l.limit*l.backoffRatio))
+}
+
+// Limit 返回当前并发限制
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight 返回当前并发数
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          AdaptiveConfig
+		run          func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock)
+		wantLimit    int
+		wantInFlight int
+	}{
+		{
+			name: "rejects at the limit and releases on ignore",
+			cfg:  AdaptiveConfig{InitialLimit: 2},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				first := mustAcquire(t, l)
+				mustAcquire(t, l)
+				if _, ok := l.Acquire(); ok {
+					t.Fatal("Acquire() succeeded above the limit")
+				}
+				first.Ignore()
+				mustAcquire(t, l)
+			},
+			wantLimit:    2,
+			wantInFlight: 2,
+		},
+		{
+			name: "permit is released only once",
+			cfg:  AdaptiveConfig{InitialLimit: 4},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l)
+				permit := mustAcquire(t, l)
+				permit.Ignore()
+				permit.Ignore()
+				permit.Dropped()
+			},
+			wantLimit:    4,
+			wantInFlight: 1,
+		},
+		{
+			name: "dropped request decreases the limit",
+			cfg:  AdaptiveConfig{InitialLimit: 10, BackoffRatio: 0.5},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l).Dropped()
+			},
+			wantLimit: 5,
+		},
+		{
+			name: "decrease is clamped to the minimum",
+			cfg:  AdaptiveConfig{InitialLimit: 2, MinLimit: 1, BackoffRatio: 0.5},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l).Dropped()
+				mustAcquire(t, l).Dropped()
+			},
+			wantLimit: 1,
+		},
+		{
+			name: "decreases once within the recent latency",
+			cfg:  AdaptiveConfig{InitialLimit: 10, BackoffRatio: 0.5},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l).Success(100 * time.Millisecond)
+				mustAcquire(t, l).Dropped()
+				mustAcquire(t, l).Dropped()
+				if got := l.Limit(); got != 5 {
+					t.Fatalf("Limit() = %d after drops within the recent latency, want 5", got)
+				}
+				clock.Advance(200 * time.Millisecond)
+				mustAcquire(t, l).Dropped()
+			},
+			wantLimit: 2,
+		},
+		{
+			name: "success at full concurrency increases the limit",
+			cfg:  AdaptiveConfig{InitialLimit: 1},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l).Success(10 * time.Millisecond)
+			},
+			wantLimit: 2,
+		},
+		{
+			name: "increase is clamped to the maximum",
+			cfg:  AdaptiveConfig{InitialLimit: 1, MaxLimit: 1},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				mustAcquire(t, l).Success(10 * time.Millisecond)
+			},
+			wantLimit: 1,
+		},
+		{
+			name: "latency spike decreases the limit",
+			cfg:  AdaptiveConfig{InitialLimit: 10, BackoffRatio: 0.5},
+			run: func(t *testing.T, l *ConcurrencyLimiter, clock *fakeClock) {
+				for i := 0; i < 5; i++ {
+					mustAcquire(t, l).Success(10 * time.Millisecond)
+				}
+				mustAcquire(t, l).Success(time.Second)
+			},
+			wantLimit: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := NewConcurrencyLimiterWithClock(tt.cfg, clock.Now)
+			tt.run(t, l, clock)
+
+			if got := l.Limit(); got != tt.wantLimit {
+				t.Errorf("Limit() = %d, want %d", got, tt.wantLimit)
+			}
+			if got := l.InFlight(); got != tt.wantInFlight {
+				t.Errorf("InFlight() = %d, want %d", got, tt.wantInFlight)
+			}
+		})
+	}
+}
+
+func mustAcquire(t *testing.T, l *ConcurrencyLimiter) *Permit {
+	t.Helper()
+	permit, ok := l.Acquire()
+	if !ok {
+		t.Fatalf("Acquire() rejected at limit %d with %d in flight", l.Limit(), l.InFlight())
+	}
+	return permit
+}
+
+func TestUpstreamLimiterSharedPerHost(t *testing.T) {
+	cfg := AdaptiveConfig{InitialLimit: 1, MinLimit: 1, MaxLimit: 1}
+	proxy := UpstreamLimiter(UpstreamHost("http://upstream-shared.test/v1"), cfg)
+	async := UpstreamLimiter(UpstreamHost("http://upstream-shared.test/v2/tasks"), cfg)
+	if proxy != async {
+		t.Fatal("same upstream host got different limiters")
+	}
+
+	permit, ok := proxy.Acquire()
+	if !ok {
+		t.Fatal("first Acquire() rejected")
+	}
+	if _, ok := async.Acquire(); ok {
+		t.Error("Acquire() through the shared limiter exceeded the host limit")
+	}
+	permit.Success(time.Millisecond)
+
+	if other := UpstreamLimiter(UpstreamHost("http://upstream-other.test"), cfg); other == proxy {
+		t.Error("different upstream hosts share a limiter")
+	}
+}
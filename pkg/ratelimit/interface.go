@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rate limit algorithms
+const (
+	AlgorithmGCRA          = "gcra"           // 通用信元速率算法，等价于令牌桶，支持突发
+	AlgorithmSlidingWindow = "sliding_window" // 滑动窗口计数（前后两个固定窗口加权）
+)
+
+// Limit 限流规则：每 Period 允许 Rate 次请求，Burst 为允许的突发请求数
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int // 为0时等于 Rate，滑动窗口算法不使用
+}
+
+// Result 限流检查结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 窗口内允许的请求数
+	Remaining  int           // 剩余可用请求数
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+	ResetAfter time.Duration // 配额完全恢复所需时间
+}
+
+// Limiter 分布式限流器
+type Limiter interface {
+	// Allow 检查 key 是否允许消耗 cost 个请求配额，允许时立即扣减
+	Allow(ctx context.Context, key string, limit Limit, cost int) (*Result, error)
+}
+
+// Inspector 可查看和重置限流状态的限流器，用于管理接口
+type Inspector interface {
+	// Peek 返回 key 的当前状态，不消耗配额；没有记录时返回满配额
+	Peek(ctx context.Context, key string, limit Limit) (*Result, error)
+	// Reset 清除限流状态，pattern 以 * 结尾时清除该前缀的所有键
+	Reset(ctx context.Context, pattern string) error
+}
+
+// IsValidAlgorithm returns true if the algorithm is supported
+func IsValidAlgorithm(algorithm string) bool {
+	return algorithm == AlgorithmGCRA || algorithm == AlgorithmSlidingWindow
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// clampCost 请求权重至少为1，超过突发容量时按容量计算，避免权重大的请求永远无法通过
+func (l Limit) clampCost(cost int) int {
+	if cost <= 0 {
+		return 1
+	}
+	if burst := l.burst(); cost > burst {
+		return burst
+	}
+	return cost
+}
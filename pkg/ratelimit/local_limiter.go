@@ -0,0 +1,261 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock 返回当前时间，测试时可替换为可控时钟
+type Clock func() time.Time
+
+// TokenBucket 令牌桶，按经过的时间连续补充令牌（可为小数），容量即允许的突发请求数
+type TokenBucket struct {
+	capacity   float64       // 桶容量
+	tokens     float64       // 当前令牌数
+	refillRate int           // 每个周期补充令牌数
+	period     time.Duration // 补充周期
+	lastRefill time.Time     // 上次补充时间
+	lastUsed   time.Time     // 上次使用时间
+	clock      Clock
+	mutex      sync.Mutex // 互斥锁
+}
+
+// NewTokenBucket 创建新的令牌桶，每 period 补充 refillRate 个令牌
+func NewTokenBucket(capacity, refillRate int, period time.Duration) *TokenBucket {
+	return newTokenBucket(capacity, refillRate, period, time.Now)
+}
+
+func newTokenBucket(capacity, refillRate int, period time.Duration, clock Clock) *TokenBucket {
+	now := clock()
+	return &TokenBucket{
+		capacity:   float64(capacity),
+		tokens:     float64(capacity),
+		refillRate: refillRate,
+		period:     period,
+		lastRefill: now,
+		lastUsed:   now,
+		clock:      clock,
+	}
+}
+
+// Take 尝试获取 cost 个令牌，返回剩余令牌数（向下取整）和被拒绝时的等待时间
+func (tb *TokenBucket) Take(cost int) (bool, int, time.Duration) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	now := tb.clock()
+	tb.lastUsed = now
+	tb.refill(now)
+
+	need := float64(cost)
+	if tb.tokens >= need {
+		tb.tokens -= need
+		return true, int(math.Floor(tb.tokens)), 0
+	}
+
+	return false, int(math.Floor(tb.tokens)), tb.timeFor(need - tb.tokens)
+}
+
+// ResetAfter 返回令牌桶补满所需的时间
+func (tb *TokenBucket) ResetAfter() time.Duration {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	return tb.timeFor(tb.capacity - tb.tokens)
+}
+
+// refill 按上次补充以来经过的时间补充令牌，时钟回拨时不补充
+func (tb *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(tb.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	tb.tokens = math.Min(tb.capacity, tb.tokens+elapsed.Seconds()*tb.ratePerSecond())
+	tb.lastRefill = now
+}
+
+// timeFor 返回补充 tokens 个令牌所需的时间，向上取整到纳秒
+func (tb *TokenBucket) timeFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / tb.ratePerSecond() * float64(time.Second)))
+}
+
+func (tb *TokenBucket) ratePerSecond() float64 {
+	return float64(tb.refillRate) / tb.period.Seconds()
+}
+
+// update 规则变化时调整令牌桶，先按旧速率补充到当前时间
+func (tb *TokenBucket) update(capacity, refillRate int, period time.Duration) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	if tb.capacity == float64(capacity) && tb.refillRate == refillRate && tb.period == period {
+		return
+	}
+	tb.refill(tb.clock())
+	tb.capacity = float64(capacity)
+	tb.refillRate = refillRate
+	tb.period = period
+	if tb.tokens > tb.capacity {
+		tb.tokens = tb.capacity
+	}
+}
+
+// LocalLimiter 进程内令牌桶限流器，用于单实例部署或共享限流器不可用时
+type LocalLimiter struct {
+	buckets map[string]*TokenBucket // 限流键 -> 令牌桶
+	mutex   sync.RWMutex            // 读写锁
+	clock   Clock
+}
+
+// NewLocalLimiter 创建本地限流器，并启动清理协程定期清理不活跃的令牌桶
+func NewLocalLimiter() *LocalLimiter {
+	l := NewLocalLimiterWithClock(time.Now)
+	go l.cleanup()
+	return l
+}
+
+// NewLocalLimiterWithClock 使用指定时钟创建本地限流器，不启动清理协程，用于测试
+func NewLocalLimiterWithClock(clock Clock) *LocalLimiter {
+	return &LocalLimiter{
+		buckets: make(map[string]*TokenBucket),
+		clock:   clock,
+	}
+}
+
+// Allow 检查并扣减限流配额，cost 超过突发容量时按容量计算，桶满时仍可放行
+func (l *LocalLimiter) Allow(ctx context.Context, key string, limit Limit, cost int) (*Result, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return &Result{Allowed: false, RetryAfter: time.Second}, nil
+	}
+	capacity := limit.burst()
+	cost = limit.clampCost(cost)
+
+	bucket := l.getOrCreateBucket(key, limit)
+	allowed, remaining, wait := bucket.Take(cost)
+
+	return &Result{
+		Allowed:    allowed,
+		Limit:      capacity,
+		Remaining:  remaining,
+		RetryAfter: wait,
+		ResetAfter: bucket.ResetAfter(),
+	}, nil
+}
+
+// Peek 返回令牌桶的当前状态，不消耗令牌
+func (l *LocalLimiter) Peek(ctx context.Context, key string, limit Limit) (*Result, error) {
+	capacity := limit.burst()
+
+	l.mutex.RLock()
+	bucket, exists := l.buckets[key]
+	l.mutex.RUnlock()
+	if !exists {
+		return &Result{Allowed: capacity > 0, Limit: capacity, Remaining: capacity}, nil
+	}
+
+	bucket.mutex.Lock()
+	bucket.refill(bucket.clock())
+	tokens := bucket.tokens
+	bucket.mutex.Unlock()
+
+	return &Result{
+		Allowed:    tokens >= 1,
+		Limit:      capacity,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: bucket.ResetAfter(),
+	}, nil
+}
+
+// Reset 删除令牌桶，下次请求时重新创建满的令牌桶
+func (l *LocalLimiter) Reset(ctx context.Context, pattern string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		for key := range l.buckets {
+			if strings.HasPrefix(key, prefix) {
+				delete(l.buckets, key)
+			}
+		}
+		return nil
+	}
+	delete(l.buckets, pattern)
+	return nil
+}
+
+// getOrCreateBucket 获取或创建令牌桶，规则变化时更新令牌桶
+func (l *LocalLimiter) getOrCreateBucket(key string, limit Limit) *TokenBucket {
+	capacity := limit.burst()
+
+	l.mutex.RLock()
+	bucket, exists := l.buckets[key]
+	l.mutex.RUnlock()
+
+	if exists {
+		bucket.update(capacity, limit.Rate, limit.Period)
+		return bucket
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// 双重检查，防止并发创建
+	if bucket, exists := l.buckets[key]; exists {
+		return bucket
+	}
+
+	bucket = newTokenBucket(capacity, limit.Rate, limit.Period, l.clock)
+	l.buckets[key] = bucket
+	return bucket
+}
+
+// cleanup 清理不活跃的令牌桶
+func (l *LocalLimiter) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute) // 每5分钟清理一次
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mutex.Lock()
+		now := l.clock()
+
+		for key, bucket := range l.buckets {
+			bucket.mutex.Lock()
+			// 超过10分钟且超过一个补充周期没有使用的令牌桶已经补满，可以删除
+			idle := now.Sub(bucket.lastUsed)
+			if idle > 10*time.Minute && idle > bucket.period {
+				delete(l.buckets, key)
+			}
+			bucket.mutex.Unlock()
+		}
+
+		l.mutex.Unlock()
+	}
+}
+
+// Stats 获取令牌桶统计信息（用于监控）
+func (l *LocalLimiter) Stats() map[string]map[string]interface{} {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	stats := make(map[string]map[string]interface{})
+
+	for key, bucket := range l.buckets {
+		bucket.mutex.Lock()
+		stats[key] = map[string]interface{}{
+			"capacity":    bucket.capacity,
+			"tokens":      bucket.tokens,
+			"refill_rate": bucket.refillRate,
+			"period":      bucket.period.String(),
+			"last_refill": bucket.lastRefill,
+		}
+		bucket.mutex.Unlock()
+	}
+
+	return stats
+}
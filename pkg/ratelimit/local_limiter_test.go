@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLocalLimiterAllow(t *testing.T) {
+	type step struct {
+		advance       time.Duration
+		cost          int
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "burst defaults to rate",
+			limit: Limit{Rate: 10, Period: time.Second},
+			steps: []step{
+				{cost: 10, wantAllowed: true, wantRemaining: 0},
+				{cost: 1, wantAllowed: false, wantRemaining: 0, wantRetry: 100 * time.Millisecond},
+			},
+		},
+		{
+			name:  "fractional refill",
+			limit: Limit{Rate: 2, Period: time.Second},
+			steps: []step{
+				{cost: 2, wantAllowed: true, wantRemaining: 0},
+				// 250ms 只补充半个令牌，不足以放行，剩余向下取整
+				{advance: 250 * time.Millisecond, cost: 1, wantAllowed: false, wantRemaining: 0, wantRetry: 250 * time.Millisecond},
+				{advance: 250 * time.Millisecond, cost: 1, wantAllowed: true, wantRemaining: 0},
+				{advance: 750 * time.Millisecond, cost: 1, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name:  "refill does not exceed capacity",
+			limit: Limit{Rate: 2, Period: time.Second},
+			steps: []step{
+				{cost: 1, wantAllowed: true, wantRemaining: 1},
+				{advance: time.Hour, cost: 1, wantAllowed: true, wantRemaining: 1},
+			},
+		},
+		{
+			name:  "burst above rate",
+			limit: Limit{Rate: 1, Period: time.Second, Burst: 5},
+			steps: []step{
+				{cost: 5, wantAllowed: true, wantRemaining: 0},
+				{cost: 1, wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+				{advance: time.Second, cost: 1, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name:  "cost clamped to burst",
+			limit: Limit{Rate: 3, Period: time.Minute},
+			steps: []step{
+				{cost: 10, wantAllowed: true, wantRemaining: 0},
+				{cost: 10, wantAllowed: false, wantRemaining: 0, wantRetry: time.Minute},
+				{advance: time.Minute, cost: 10, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name:  "non-positive cost counts as one",
+			limit: Limit{Rate: 3, Period: time.Second},
+			steps: []step{
+				{cost: 0, wantAllowed: true, wantRemaining: 2},
+				{cost: -5, wantAllowed: true, wantRemaining: 1},
+			},
+		},
+		{
+			name:  "clock going backwards does not refill",
+			limit: Limit{Rate: 1, Period: time.Second},
+			steps: []step{
+				{cost: 1, wantAllowed: true, wantRemaining: 0},
+				{advance: -time.Hour, cost: 1, wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+			},
+		},
+		{
+			name:  "invalid limit rejects",
+			limit: Limit{Rate: 0, Period: time.Second},
+			steps: []step{
+				{cost: 1, wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			limiter := NewLocalLimiterWithClock(clock.Now)
+
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				result, err := limiter.Allow(context.Background(), "key", tt.limit, s.cost)
+				if err != nil {
+					t.Fatalf("step %d: Allow() error = %v", i, err)
+				}
+				if result.Allowed != s.wantAllowed {
+					t.Errorf("step %d: Allowed = %v, want %v", i, result.Allowed, s.wantAllowed)
+				}
+				if result.Remaining != s.wantRemaining {
+					t.Errorf("step %d: Remaining = %d, want %d", i, result.Remaining, s.wantRemaining)
+				}
+				if diff := result.RetryAfter - s.wantRetry; diff < -time.Microsecond || diff > time.Microsecond {
+					t.Errorf("step %d: RetryAfter = %v, want %v", i, result.RetryAfter, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalLimiterPeekDoesNotConsume(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewLocalLimiterWithClock(clock.Now)
+	limit := Limit{Rate: 4, Period: time.Second}
+	ctx := context.Background()
+
+	if _, err := limiter.Allow(ctx, "key", limit, 3); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	clock.Advance(500 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Peek(ctx, "key", limit)
+		if err != nil {
+			t.Fatalf("Peek() error = %v", err)
+		}
+		if result.Remaining != 3 {
+			t.Errorf("peek %d: Remaining = %d, want 3", i, result.Remaining)
+		}
+	}
+
+	if err := limiter.Reset(ctx, "k*"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	result, err := limiter.Peek(ctx, "key", limit)
+	if err != nil {
+		t.Fatalf("Peek() error = %v", err)
+	}
+	if result.Remaining != 4 {
+		t.Errorf("after reset: Remaining = %d, want 4", result.Remaining)
+	}
+}
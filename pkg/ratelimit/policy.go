@@ -0,0 +1,271 @@
+package ratelimit
+
+import (
+	"api-gateway/pkg/routescope"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rate limit dimensions
+const (
+	ScopeClient      = "client"       // 每个客户
+	ScopeClientRoute = "client_route" // 每个客户的每个路由
+	ScopeIP          = "ip"           // 每个来源IP，所有客户合计
+	ScopeVersion     = "version"      // 每个API版本，所有客户合计
+	ScopeUpstream    = "upstream"     // 每个上游服务，所有客户合计
+)
+
+// Rate limit windows
+const (
+	WindowSecond = "second"
+	WindowMinute = "minute"
+	WindowHour   = "hour"
+)
+
+// Rule modes
+const (
+	ModeEnforce = "enforce" // 超限时拒绝请求（默认）
+	ModeShadow  = "shadow"  // 超限时只记录，请求继续处理，用于收紧限额前评估影响
+	ModeOff     = "off"     // 不检查
+)
+
+// ClientQPSRule 按 client.QPS 限流的内置规则
+const ClientQPSRule = "client_qps"
+
+// Rule 限流规则
+type Rule struct {
+	Name   string
+	Scope  string
+	Routes []string // 适用的路由模式，为空时适用所有路由
+	Window string
+	Mode   string // enforce、shadow、off，为空时等于 enforce
+	Limit
+}
+
+// Request 限流检查的请求信息
+type Request struct {
+	ClientID string
+	Route    string
+	IP       string
+	Version  string
+	Upstream string
+}
+
+// RouteCost 路由的请求权重
+type RouteCost struct {
+	Route string
+	Cost  int
+}
+
+// Decision 多条规则的检查结果，最严格的规则决定结果
+type Decision struct {
+	Allowed  bool
+	Rule     *Rule        // 拒绝时为拒绝请求的规则，否则为剩余配额最少的规则
+	Result   *Result      // Rule 的检查结果
+	Results  []RuleResult // 已检查的 enforce 规则的检查结果，拒绝后的规则不检查
+	Shadowed []RuleResult // shadow 模式下超限的规则，不影响结果
+}
+
+// RuleResult 单条规则的检查结果
+type RuleResult struct {
+	Rule   *Rule
+	Result *Result
+}
+
+// IsValidMode returns true if the rule mode is known, empty means enforce
+func IsValidMode(mode string) bool {
+	switch mode {
+	case "", ModeEnforce, ModeShadow, ModeOff:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewRule 创建并校验限流规则
+func NewRule(name, scope string, routes []string, limit int, window string, burst int, mode string) (*Rule, error) {
+	if name == "" {
+		return nil, fmt.Errorf("invalid rate limit rule: name is required")
+	}
+	if !IsValidScope(scope) {
+		return nil, fmt.Errorf("invalid rate limit rule %s: unknown scope %q", name, scope)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid rate limit rule %s: limit must be positive", name)
+	}
+	period, ok := WindowDuration(window)
+	if !ok {
+		return nil, fmt.Errorf("invalid rate limit rule %s: unknown window %q", name, window)
+	}
+	if burst < 0 {
+		return nil, fmt.Errorf("invalid rate limit rule %s: burst must not be negative", name)
+	}
+	if !IsValidMode(mode) {
+		return nil, fmt.Errorf("invalid rate limit rule %s: unknown mode %q", name, mode)
+	}
+
+	return &Rule{
+		Name:   name,
+		Scope:  scope,
+		Routes: routes,
+		Window: window,
+		Mode:   mode,
+		Limit:  Limit{Rate: limit, Period: period, Burst: burst},
+	}, nil
+}
+
+// IsValidScope returns true if the rate limit dimension is known
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeClient, ScopeClientRoute, ScopeIP, ScopeVersion, ScopeUpstream:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsClientScope returns true if the dimension is counted per client, only such rules can be overridden per client
+func IsClientScope(scope string) bool {
+	return scope == ScopeClient || scope == ScopeClientRoute
+}
+
+// WindowDuration returns the duration of a rate limit window
+func WindowDuration(window string) (time.Duration, bool) {
+	switch window {
+	case WindowSecond, "":
+		return time.Second, true
+	case WindowMinute:
+		return time.Minute, true
+	case WindowHour:
+		return time.Hour, true
+	default:
+		return 0, false
+	}
+}
+
+// NewRouteCost 创建并校验路由权重
+func NewRouteCost(route string, cost int) (RouteCost, error) {
+	if !strings.HasPrefix(route, "/") || strings.Contains(strings.TrimSuffix(route, "*"), "*") {
+		return RouteCost{}, fmt.Errorf("invalid route cost: invalid route pattern %q", route)
+	}
+	if cost <= 0 {
+		return RouteCost{}, fmt.Errorf("invalid route cost %s: cost must be positive", route)
+	}
+	return RouteCost{Route: route, Cost: cost}, nil
+}
+
+// CostFor 返回路由的请求权重，按顺序匹配第一个，未匹配时为1
+func CostFor(costs []RouteCost, route string) int {
+	for _, cost := range costs {
+		if routescope.MatchAny([]string{cost.Route}, route) {
+			return cost.Cost
+		}
+	}
+	return 1
+}
+
+// Applies returns true if the rule applies to the route
+func (r *Rule) Applies(route string) bool {
+	return len(r.Routes) == 0 || routescope.MatchAny(r.Routes, route)
+}
+
+// Key 返回规则在该请求上的限流键，维度信息缺失时返回空
+func (r *Rule) Key(req Request) string {
+	var subject string
+	switch r.Scope {
+	case ScopeClient:
+		if r.Name == ClientQPSRule {
+			return "client:" + req.ClientID
+		}
+		subject = req.ClientID
+	case ScopeClientRoute:
+		subject = req.ClientID + ":" + req.Route
+	case ScopeIP:
+		subject = req.IP
+	case ScopeVersion:
+		subject = req.Version
+	case ScopeUpstream:
+		subject = req.Upstream
+	}
+	if subject == "" || subject == ":" {
+		return ""
+	}
+	return "rule:" + r.Name + ":" + subject
+}
+
+// Evaluate 先检查客户维度的 enforce 规则，再检查其他维度的 enforce 规则，遇到第一条拒绝的规则即停止，
+// 后面的规则不再扣减；请求通过后才检查 shadow 规则，shadow 规则超限只记录在 Shadowed 中
+func Evaluate(ctx context.Context, limiter Limiter, rules []*Rule, req Request, cost int) (*Decision, error) {
+	decision := &Decision{Allowed: true}
+	var shadow []*Rule
+
+	for _, rule := range orderRules(rules) {
+		key := rule.Key(req)
+		if key == "" || rule.Mode == ModeOff || !rule.Applies(req.Route) {
+			continue
+		}
+		if rule.Mode == ModeShadow {
+			shadow = append(shadow, rule)
+			continue
+		}
+
+		result, err := limiter.Allow(ctx, key, rule.Limit, cost)
+		if err != nil {
+			return nil, err
+		}
+
+		decision.Results = append(decision.Results, RuleResult{Rule: rule, Result: result})
+		if stricter(result, decision) {
+			decision.Rule = rule
+			decision.Result = result
+		}
+		if !result.Allowed {
+			decision.Allowed = false
+			return decision, nil
+		}
+	}
+
+	for _, rule := range shadow {
+		result, err := limiter.Allow(ctx, rule.Key(req), rule.Limit, cost)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Allowed {
+			decision.Shadowed = append(decision.Shadowed, RuleResult{Rule: rule, Result: result})
+		}
+	}
+
+	return decision, nil
+}
+
+// orderRules 客户维度的规则排在前面，同一维度内保持配置顺序
+func orderRules(rules []*Rule) []*Rule {
+	ordered := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if IsClientScope(rule.Scope) {
+			ordered = append(ordered, rule)
+		}
+	}
+	for _, rule := range rules {
+		if !IsClientScope(rule.Scope) {
+			ordered = append(ordered, rule)
+		}
+	}
+	return ordered
+}
+
+// stricter 拒绝优先于放行；同为拒绝时等待时间长的更严格，同为放行时剩余配额少的更严格
+func stricter(result *Result, current *Decision) bool {
+	if current.Result == nil {
+		return true
+	}
+	if result.Allowed != current.Result.Allowed {
+		return !result.Allowed
+	}
+	if !result.Allowed {
+		return result.RetryAfter > current.Result.RetryAfter
+	}
+	return result.Remaining < current.Result.Remaining
+}
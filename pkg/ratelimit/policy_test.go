@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvaluateStopsAtFirstRejection(t *testing.T) {
+	limiter := NewLocalLimiterWithClock(newFakeClock().Now)
+	ctx := context.Background()
+
+	ipRule := &Rule{Name: "per_ip", Scope: ScopeIP, Limit: Limit{Rate: 10, Period: time.Minute}}
+	shadowRule := &Rule{Name: "shadow_ip", Scope: ScopeIP, Mode: ModeShadow, Limit: Limit{Rate: 10, Period: time.Minute}}
+	clientRule := &Rule{Name: "per_client", Scope: ScopeClient, Limit: Limit{Rate: 1, Period: time.Minute}}
+	// 共享维度的规则配置在前，客户维度的规则仍先检查
+	rules := []*Rule{ipRule, shadowRule, clientRule}
+	req := Request{ClientID: "c1", Route: "/api/v1/test", IP: "10.0.0.1"}
+
+	decision, err := Evaluate(ctx, limiter, rules, req, 1)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if !decision.Allowed {
+		t.Fatalf("first request rejected by %s", decision.Rule.Name)
+	}
+
+	for i := 0; i < 3; i++ {
+		decision, err = Evaluate(ctx, limiter, rules, req, 1)
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if decision.Allowed || decision.Rule != clientRule {
+			t.Fatalf("request %d: want rejection by %s, got allowed=%v rule=%v", i+2, clientRule.Name, decision.Allowed, decision.Rule)
+		}
+		if len(decision.Results) != 1 {
+			t.Fatalf("request %d: want only the client rule checked, got %d results", i+2, len(decision.Results))
+		}
+	}
+
+	// 被客户规则拒绝的请求不扣减 IP 维度和 shadow 规则的配额
+	for _, rule := range []*Rule{ipRule, shadowRule} {
+		result, err := limiter.Peek(ctx, rule.Key(req), rule.Limit)
+		if err != nil {
+			t.Fatalf("Peek() error = %v", err)
+		}
+		if result.Remaining != 9 {
+			t.Errorf("%s remaining = %d, want 9", rule.Name, result.Remaining)
+		}
+	}
+}
+
+func TestEvaluateShadowDoesNotReject(t *testing.T) {
+	limiter := NewLocalLimiterWithClock(newFakeClock().Now)
+	ctx := context.Background()
+
+	shadowRule := &Rule{Name: "shadow_client", Scope: ScopeClient, Mode: ModeShadow, Limit: Limit{Rate: 1, Period: time.Minute}}
+	req := Request{ClientID: "c1", Route: "/api/v1/test"}
+
+	for i := 0; i < 2; i++ {
+		decision, err := Evaluate(ctx, limiter, []*Rule{shadowRule}, req, 1)
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !decision.Allowed {
+			t.Fatalf("request %d rejected by shadow rule", i+1)
+		}
+		if want := i; len(decision.Shadowed) != want {
+			t.Errorf("request %d: shadowed = %d, want %d", i+1, len(decision.Shadowed), want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript GCRA 限流，使用 Redis 服务器时间，避免各实例时钟不一致
+// KEYS[1]: 限流键
+// ARGV[1]: 请求间隔（微秒），ARGV[2]: 突发容量，ARGV[3]: 本次消耗
+// 返回 {是否允许, 剩余次数, 重试等待（毫秒）, 完全恢复时间（毫秒）}
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission * cost
+local allow_at = new_tat - emission * burst
+local diff = now - allow_at
+
+if diff < 0 then
+  return {0, 0, math.ceil(-diff / 1000), math.ceil((tat - now) / 1000)}
+end
+
+local ttl = math.ceil((new_tat - now) / 1000)
+if ttl > 0 then
+  -- 按整数格式写入，避免 Lua 默认的 %.14g 格式丢失微秒精度
+  redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', ttl)
+end
+return {1, math.floor(diff / emission), 0, ttl}
+`)
+
+// slidingWindowScript 滑动窗口计数，按上一个窗口剩余时间比例加权估算窗口内请求数
+// KEYS[1]: 限流键
+// ARGV[1]: 窗口长度（毫秒），ARGV[2]: 窗口内允许次数，ARGV[3]: 本次消耗
+// 返回 {是否允许, 剩余次数, 重试等待（毫秒）, 当前窗口剩余时间（毫秒）}
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local start = now - (now % window)
+local elapsed = now - start
+
+local data = redis.call('HMGET', KEYS[1], 'start', 'cur', 'prev')
+local saved = tonumber(data[1])
+local cur = tonumber(data[2]) or 0
+local prev = tonumber(data[3]) or 0
+if saved ~= start then
+  if saved == start - window then
+    prev = cur
+  else
+    prev = 0
+  end
+  cur = 0
+end
+
+local used = prev * (window - elapsed) / window + cur
+if used + cost > limit then
+  local retry = window - elapsed
+  if cur + cost <= limit and prev > 0 then
+    -- 上一个窗口的权重衰减到足够小即可放行
+    local need = window * (1 - (limit - cur - cost) / prev)
+    retry = math.max(1, math.ceil(need - elapsed))
+  end
+  return {0, math.max(0, math.floor(limit - used)), retry, window - elapsed}
+end
+
+cur = cur + cost
+redis.call('HSET', KEYS[1], 'start', string.format('%.0f', start), 'cur', cur, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, math.floor(limit - used - cost), 0, window - elapsed}
+`)
+
+// gcraPeekScript 查看 GCRA 状态，不修改
+// KEYS[1]: 限流键，ARGV[1]: 请求间隔（微秒），ARGV[2]: 突发容量
+// 返回 {是否允许, 剩余次数, 0, 完全恢复时间（毫秒）}
+var gcraPeekScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  return {1, burst, 0, 0}
+end
+
+local remaining = math.floor((now - (tat - emission * burst)) / emission)
+if remaining < 0 then
+  remaining = 0
+end
+local allowed = 0
+if remaining > 0 then
+  allowed = 1
+end
+return {allowed, remaining, 0, math.ceil((tat - now) / 1000)}
+`)
+
+// slidingWindowPeekScript 查看滑动窗口状态，不修改
+// KEYS[1]: 限流键，ARGV[1]: 窗口长度（毫秒），ARGV[2]: 窗口内允许次数
+// 返回 {是否允许, 剩余次数, 0, 当前窗口剩余时间（毫秒）}
+var slidingWindowPeekScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local start = now - (now % window)
+local elapsed = now - start
+
+local data = redis.call('HMGET', KEYS[1], 'start', 'cur', 'prev')
+local saved = tonumber(data[1])
+local cur = tonumber(data[2]) or 0
+local prev = tonumber(data[3]) or 0
+if saved ~= start then
+  if saved == start - window then
+    prev = cur
+  else
+    prev = 0
+  end
+  cur = 0
+end
+
+local remaining = math.max(0, math.floor(limit - (prev * (window - elapsed) / window + cur)))
+local allowed = 0
+if remaining > 0 then
+  allowed = 1
+end
+return {allowed, remaining, 0, window - elapsed}
+`)
+
+// RedisLimiter 基于 Redis Lua 脚本的分布式限流器，多个网关实例共享限流状态
+type RedisLimiter struct {
+	client    *redis.Client
+	algorithm string
+	keyPrefix string
+}
+
+// NewRedisLimiter 创建 Redis 限流器，algorithm 为空时使用 GCRA
+func NewRedisLimiter(client *redis.Client, algorithm, keyPrefix string) (*RedisLimiter, error) {
+	if algorithm == "" {
+		algorithm = AlgorithmGCRA
+	}
+	if !IsValidAlgorithm(algorithm) {
+		return nil, fmt.Errorf("unsupported rate limit algorithm: %s", algorithm)
+	}
+	if keyPrefix == "" {
+		keyPrefix = "gw:ratelimit:"
+	}
+
+	return &RedisLimiter{
+		client:    client,
+		algorithm: algorithm,
+		keyPrefix: keyPrefix,
+	}, nil
+}
+
+// Allow 检查并扣减限流配额
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit, cost int) (*Result, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return &Result{Allowed: false, RetryAfter: time.Second}, nil
+	}
+	cost = limit.clampCost(cost)
+
+	var (
+		values []interface{}
+		err    error
+		total  int
+	)
+	switch l.algorithm {
+	case AlgorithmSlidingWindow:
+		total = limit.Rate
+		if cost > total {
+			cost = total
+		}
+		values, err = slidingWindowScript.Run(ctx, l.client, []string{l.keyPrefix + "sw:" + key},
+			windowMillis(limit), limit.Rate, cost).Slice()
+	default:
+		total = limit.burst()
+		values, err = gcraScript.Run(ctx, l.client, []string{l.keyPrefix + "gcra:" + key},
+			emissionMicros(limit), total, cost).Slice()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rate limit script failed: %w", err)
+	}
+	return parseScriptResult(values, total)
+}
+
+// Peek 返回限流键的当前状态，不消耗配额
+func (l *RedisLimiter) Peek(ctx context.Context, key string, limit Limit) (*Result, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return &Result{Allowed: false}, nil
+	}
+
+	var (
+		values []interface{}
+		err    error
+		total  int
+	)
+	switch l.algorithm {
+	case AlgorithmSlidingWindow:
+		total = limit.Rate
+		values, err = slidingWindowPeekScript.Run(ctx, l.client, []string{l.keyPrefix + "sw:" + key},
+			windowMillis(limit), limit.Rate).Slice()
+	default:
+		total = limit.burst()
+		values, err = gcraPeekScript.Run(ctx, l.client, []string{l.keyPrefix + "gcra:" + key},
+			emissionMicros(limit), total).Slice()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rate limit script failed: %w", err)
+	}
+	return parseScriptResult(values, total)
+}
+
+// Reset 删除限流状态，pattern 以 * 结尾时按前缀扫描删除
+func (l *RedisLimiter) Reset(ctx context.Context, pattern string) error {
+	algorithmPrefix := l.keyPrefix + "gcra:"
+	if l.algorithm == AlgorithmSlidingWindow {
+		algorithmPrefix = l.keyPrefix + "sw:"
+	}
+
+	if !strings.HasSuffix(pattern, "*") {
+		if err := l.client.Del(ctx, algorithmPrefix+pattern).Err(); err != nil {
+			return fmt.Errorf("failed to reset rate limit: %w", err)
+		}
+		return nil
+	}
+
+	iter := l.client.Scan(ctx, 0, algorithmPrefix+pattern, 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan rate limit keys: %w", err)
+	}
+	if len(keys) > 0 {
+		if err := l.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to reset rate limit: %w", err)
+		}
+	}
+	return nil
+}
+
+func windowMillis(limit Limit) int64 {
+	window := limit.Period.Milliseconds()
+	if window < 1 {
+		window = 1
+	}
+	return window
+}
+
+func emissionMicros(limit Limit) int64 {
+	emission := limit.Period.Microseconds() / int64(limit.Rate)
+	if emission < 1 {
+		emission = 1
+	}
+	return emission
+}
+
+// parseScriptResult 解析脚本返回的 {是否允许, 剩余次数, 重试等待（毫秒）, 恢复时间（毫秒）}
+func parseScriptResult(values []interface{}, total int) (*Result, error) {
+	if len(values) != 4 {
+		return nil, fmt.Errorf("rate limit script returned %d values", len(values))
+	}
+
+	nums := make([]int64, 4)
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("rate limit script returned unexpected value %v", v)
+		}
+		nums[i] = n
+	}
+
+	return &Result{
+		Allowed:    nums[0] == 1,
+		Limit:      total,
+		Remaining:  int(nums[1]),
+		RetryAfter: time.Duration(nums[2]) * time.Millisecond,
+		ResetAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"net/url"
+	"sync"
+)
+
+// upstreamLimiters 进程内按上游 host 共享的自适应并发限制
+var upstreamLimiters = struct {
+	sync.Mutex
+	byHost map[string]*ConcurrencyLimiter
+}{byHost: make(map[string]*ConcurrencyLimiter)}
+
+// UpstreamLimiter 返回上游服务的自适应并发限制，首次使用时按 cfg 创建。
+// 同步代理和异步任务共用同一个限制，异步任务不能绕过限制压垮正在退避的上游
+func UpstreamLimiter(host string, cfg AdaptiveConfig) *ConcurrencyLimiter {
+	upstreamLimiters.Lock()
+	defer upstreamLimiters.Unlock()
+
+	limiter, exists := upstreamLimiters.byHost[host]
+	if !exists {
+		limiter = NewConcurrencyLimiter(cfg)
+		upstreamLimiters.byHost[host] = limiter
+	}
+	return limiter
+}
+
+// UpstreamHost 返回目标 URL 的 host，用于区分上游服务，无法解析时返回原 URL
+func UpstreamHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
@@ -0,0 +1,107 @@
+package routescope
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog 路由授权目录
+// 客户的 scope 可以是产品名（映射到一组路由模式），也可以直接是路由模式。
+// 路由模式以 "/" 开头，以 "*" 结尾表示前缀匹配，否则精确匹配。
+type Catalog struct {
+	products    map[string][]string
+	exempt      []string
+	defaultDeny bool
+}
+
+// NewCatalog 创建路由授权目录
+// defaultDeny 为 false 时，未配置 scope 的客户可以访问所有路由
+func NewCatalog(products map[string][]string, exempt []string, defaultDeny bool) (*Catalog, error) {
+	for name, patterns := range products {
+		if name == "" || strings.HasPrefix(name, "/") {
+			return nil, fmt.Errorf("invalid product name: %q", name)
+		}
+		for _, pattern := range patterns {
+			if !strings.HasPrefix(pattern, "/") {
+				return nil, fmt.Errorf("invalid route pattern %q in product %s", pattern, name)
+			}
+		}
+	}
+	for _, pattern := range exempt {
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("invalid exempt route pattern %q", pattern)
+		}
+	}
+
+	return &Catalog{
+		products:    products,
+		exempt:      exempt,
+		defaultDeny: defaultDeny,
+	}, nil
+}
+
+// Validate 校验 scope 是否为已知产品或合法的路由模式
+func (c *Catalog) Validate(scope string) error {
+	if strings.HasPrefix(scope, "/") {
+		if strings.Contains(strings.TrimSuffix(scope, "*"), "*") {
+			return fmt.Errorf("invalid scope %q: wildcard only allowed at the end", scope)
+		}
+		return nil
+	}
+	if _, ok := c.products[scope]; !ok {
+		return fmt.Errorf("invalid scope %q: unknown product", scope)
+	}
+	return nil
+}
+
+// Allowed 判断拥有给定 scope 的客户是否可以访问路径
+func (c *Catalog) Allowed(scopes []string, path string) bool {
+	if matchAny(c.exempt, path) {
+		return true
+	}
+	if len(scopes) == 0 {
+		return !c.defaultDeny
+	}
+
+	for _, scope := range scopes {
+		if strings.HasPrefix(scope, "/") {
+			if match(scope, path) {
+				return true
+			}
+			continue
+		}
+		if matchAny(c.products[scope], path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers 判断给定 scope 是否覆盖路径，与 Allowed 不同，空 scope 不覆盖任何非豁免路由
+func (c *Catalog) Covers(scopes []string, path string) bool {
+	if len(scopes) == 0 {
+		return matchAny(c.exempt, path)
+	}
+	return c.Allowed(scopes, path)
+}
+
+// MatchAny 判断路径是否匹配任一路由模式
+func MatchAny(patterns []string, path string) bool {
+	return matchAny(patterns, path)
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return pattern == path
+}
@@ -0,0 +1,24 @@
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// keyPrefixLength API密钥展示前缀长度（含 "ak_" 前缀）
+const keyPrefixLength = 11
+
+// HashAPIKey 计算API密钥的查找哈希
+// API密钥为高熵随机串，直接使用SHA-256即可防止明文泄露
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyPrefix 返回API密钥的展示前缀，用于日志和管理界面识别密钥
+func KeyPrefix(apiKey string) string {
+	if len(apiKey) <= keyPrefixLength {
+		return apiKey
+	}
+	return apiKey[:keyPrefixLength]
+}
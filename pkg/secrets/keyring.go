@@ -0,0 +1,188 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultKeysEnv 默认读取加密密钥的环境变量
+const DefaultKeysEnv = "GATEWAY_ENCRYPTION_KEYS"
+
+// Keyring 多版本信封加密密钥环
+// 密文格式为 "v<版本>:<base64(nonce|ciphertext)>"，解密时按版本选择密钥，
+// 加密始终使用当前版本，便于密钥轮换后重新加密
+type Keyring struct {
+	current int
+	keys    map[int]cipher.AEAD
+}
+
+// keysFile 密钥文件格式
+type keysFile struct {
+	CurrentVersion int            `yaml:"current_version"`
+	Keys           map[int]string `yaml:"keys"` // 版本 -> base64编码的32字节密钥
+}
+
+// LoadKeyring 从密钥文件或环境变量加载密钥环
+// 环境变量格式为 "1:<base64>,2:<base64>"；currentVersion为0时使用文件中的配置或最大版本。
+// 两者均未配置时返回 nil，表示不启用加密
+func LoadKeyring(path, envName string, currentVersion int) (*Keyring, error) {
+	encoded := make(map[int]string)
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keys file %s: %w", path, err)
+		}
+		var f keysFile
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse keys file %s: %w", path, err)
+		}
+		for version, key := range f.Keys {
+			encoded[version] = key
+		}
+		if currentVersion == 0 {
+			currentVersion = f.CurrentVersion
+		}
+	}
+
+	if envName == "" {
+		envName = DefaultKeysEnv
+	}
+	if value := os.Getenv(envName); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid key entry in %s", envName)
+			}
+			version, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid key version in %s: %w", envName, err)
+			}
+			encoded[version] = parts[1]
+		}
+	}
+
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	return NewKeyring(encoded, currentVersion)
+}
+
+// NewKeyring 根据 版本 -> base64密钥 创建密钥环，currentVersion为0时使用最大版本
+func NewKeyring(encodedKeys map[int]string, currentVersion int) (*Keyring, error) {
+	keys := make(map[int]cipher.AEAD, len(encodedKeys))
+	for version, encodedKey := range encodedKeys {
+		if version <= 0 {
+			return nil, fmt.Errorf("key version must be positive: %d", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key version %d: %w", version, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key version %d must be 32 bytes, got %d", version, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher for key version %d: %w", version, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM for key version %d: %w", version, err)
+		}
+		keys[version] = aead
+	}
+
+	if currentVersion == 0 {
+		for version := range keys {
+			if version > currentVersion {
+				currentVersion = version
+			}
+		}
+	}
+
+	if _, ok := keys[currentVersion]; !ok {
+		return nil, fmt.Errorf("current key version %d not found", currentVersion)
+	}
+
+	return &Keyring{
+		current: currentVersion,
+		keys:    keys,
+	}, nil
+}
+
+// CurrentVersion 返回当前加密使用的密钥版本
+func (k *Keyring) CurrentVersion() int {
+	return k.current
+}
+
+// Encrypt 使用当前版本密钥加密
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.keys[k.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return fmt.Sprintf("v%d:%s", k.current, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt 按密文中的版本选择密钥解密
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	version, payload, err := parseCiphertext(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	aead, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("unknown key version %d", version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt 判断密文是否由非当前版本密钥加密
+func (k *Keyring) NeedsReencrypt(ciphertext string) bool {
+	version, _, err := parseCiphertext(ciphertext)
+	return err != nil || version != k.current
+}
+
+func parseCiphertext(ciphertext string) (int, string, error) {
+	parts := strings.SplitN(ciphertext, ":", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "v") {
+		return 0, "", fmt.Errorf("invalid ciphertext format")
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[0], "v"))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid ciphertext version: %w", err)
+	}
+
+	return version, parts[1], nil
+}
@@ -0,0 +1,215 @@
+package worker
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client expiry events
+const (
+	EventClientExpiring = "client.expiring" // 合同即将到期
+	EventClientExpired  = "client.expired"  // 合同已到期，客户已自动禁用
+)
+
+// ClientExpiryEvent is the webhook payload of a client contract event
+type ClientExpiryEvent struct {
+	Event      string    `json:"event"`
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	ValidUntil time.Time `json:"valid_until"`
+	DaysLeft   int       `json:"days_left"`
+	Timestamp  int64     `json:"timestamp"`
+}
+
+// ClientExpiryJob periodically disables clients whose contract has ended and
+// emits a reminder event before a contract ends
+type ClientExpiryJob struct {
+	clientRepo    repository.ClientRepository
+	interval      time.Duration
+	notifyBefore  time.Duration
+	webhookURL    string
+	webhookSecret string
+	httpClient    *http.Client
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// NewClientExpiryJob creates a client expiry job, unset config values fall back to defaults
+func NewClientExpiryJob(clientRepo repository.ClientRepository, cfg config.ExpiryConfig) *ClientExpiryJob {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	interval := time.Duration(cfg.CheckInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	notifyDays := cfg.NotifyDays
+	if notifyDays <= 0 {
+		notifyDays = 7
+	}
+
+	return &ClientExpiryJob{
+		clientRepo:    clientRepo,
+		interval:      interval,
+		notifyBefore:  time.Duration(notifyDays) * 24 * time.Hour,
+		webhookURL:    cfg.WebhookURL,
+		webhookSecret: cfg.WebhookSecret,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Start runs the check immediately and then at every interval
+func (j *ClientExpiryJob) Start() {
+	logger.Infof("Starting client expiry job, interval %s", j.interval)
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.runOnce()
+
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the job and waits for the running check to finish
+func (j *ClientExpiryJob) Stop() {
+	logger.Info("Stopping client expiry job...")
+	j.cancel()
+	j.wg.Wait()
+	logger.Info("Client expiry job stopped")
+}
+
+// runOnce disables expired clients and sends reminders for clients expiring soon
+func (j *ClientExpiryJob) runOnce() {
+	ctx, cancel := context.WithTimeout(j.ctx, time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	clients, err := j.clientRepo.FindExpiring(ctx, now.Add(j.notifyBefore))
+	if err != nil {
+		logger.Errorf("Failed to find expiring clients: %v", err)
+		return
+	}
+
+	for _, client := range clients {
+		if client.CheckValidity(now) == model.ClientValidityExpired {
+			j.disableClient(ctx, client, now)
+		} else {
+			j.remindClient(ctx, client, now)
+		}
+	}
+}
+
+// disableClient disables a client whose contract has ended
+func (j *ClientExpiryJob) disableClient(ctx context.Context, client *model.Client, now time.Time) {
+	disabled, err := j.clientRepo.DisableExpired(ctx, client.ID, now)
+	if err != nil {
+		logger.Errorf("Failed to disable expired client %s: %v", client.ID.Hex(), err)
+		return
+	}
+	if !disabled {
+		// 已被其他实例禁用或刚刚续约
+		return
+	}
+
+	logger.Infof("Client %s (%s) disabled, contract ended at %s", client.ID.Hex(), client.Name, client.ValidUntil.Format(time.RFC3339))
+	j.emit(ctx, newClientExpiryEvent(EventClientExpired, client, now))
+}
+
+// remindClient sends the expiry reminder once per contract end date
+func (j *ClientExpiryJob) remindClient(ctx context.Context, client *model.Client, now time.Time) {
+	if client.ExpiryNotifiedAt != nil {
+		return
+	}
+
+	marked, err := j.clientRepo.MarkExpiryNotified(ctx, client.ID, *client.ValidUntil, now)
+	if err != nil {
+		logger.Errorf("Failed to mark expiry reminder of client %s: %v", client.ID.Hex(), err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	event := newClientExpiryEvent(EventClientExpiring, client, now)
+	logger.Infof("Client %s (%s) contract ends at %s, %d days left", client.ID.Hex(), client.Name,
+		client.ValidUntil.Format(time.RFC3339), event.DaysLeft)
+	j.emit(ctx, event)
+}
+
+// emit posts the event to the configured webhook
+func (j *ClientExpiryJob) emit(ctx context.Context, event *ClientExpiryEvent) {
+	if j.webhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Errorf("Failed to marshal %s event: %v", event.Event, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		logger.Errorf("Failed to create %s webhook request: %v", event.Event, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event.Event)
+	if j.webhookSecret != "" {
+		h := hmac.New(sha256.New, []byte(j.webhookSecret))
+		h.Write(body)
+		req.Header.Set("X-Webhook-Signature", hex.EncodeToString(h.Sum(nil)))
+	}
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		logger.Errorf("Failed to send %s webhook for client %s: %v", event.Event, event.ClientID, err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Errorf("Webhook %s for client %s returned status %d", event.Event, event.ClientID, resp.StatusCode)
+	}
+}
+
+func newClientExpiryEvent(eventType string, client *model.Client, now time.Time) *ClientExpiryEvent {
+	daysLeft := int(client.ValidUntil.Sub(now).Hours() / 24)
+	if daysLeft < 0 {
+		daysLeft = 0
+	}
+	return &ClientExpiryEvent{
+		Event:      eventType,
+		ClientID:   client.ID.Hex(),
+		ClientName: client.Name,
+		ValidUntil: *client.ValidUntil,
+		DaysLeft:   daysLeft,
+		Timestamp:  now.Unix(),
+	}
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"api-gateway/pkg/logger"
+	"context"
+	"sync"
+	"time"
+)
+
+// ReservationExpirer refunds call reservations that were not settled in time
+type ReservationExpirer interface {
+	ExpireReservations(ctx context.Context, now time.Time) (int, error)
+}
+
+// ReservationSweepJob periodically refunds call reservations left over by crashes or lost responses
+type ReservationSweepJob struct {
+	expirer  ReservationExpirer
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewReservationSweepJob creates a reservation sweep job, interval defaults to one minute
+func NewReservationSweepJob(expirer ReservationExpirer, interval time.Duration) *ReservationSweepJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &ReservationSweepJob{
+		expirer:  expirer,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start runs the sweep at every interval
+func (j *ReservationSweepJob) Start() {
+	logger.Infof("Starting call reservation sweep job, interval %s", j.interval)
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+				j.runOnce()
+			}
+		}
+	}()
+}
+
+// Stop stops the job and waits for the running sweep to finish
+func (j *ReservationSweepJob) Stop() {
+	logger.Info("Stopping call reservation sweep job...")
+	j.cancel()
+	j.wg.Wait()
+	logger.Info("Call reservation sweep job stopped")
+}
+
+// runOnce refunds expired reservations
+func (j *ReservationSweepJob) runOnce() {
+	ctx, cancel := context.WithTimeout(j.ctx, time.Minute)
+	defer cancel()
+
+	expired, err := j.expirer.ExpireReservations(ctx, time.Now())
+	if err != nil {
+		logger.Errorf("Failed to expire call reservations: %v", err)
+	}
+	if expired > 0 {
+		logger.Infof("Refunded %d expired call reservations", expired)
+	}
+}
@@ -0,0 +1,57 @@
+package worker_test
+
+import (
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/worker"
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	config := logger.DefaultConfig("worker-test")
+	config.Level = "error"
+	logger.InitWithConfig(config)
+	os.Exit(m.Run())
+}
+
+func TestReservationSweepJobStopWaitsForSweep(t *testing.T) {
+	expirer := &blockingExpirer{started: make(chan struct{}, 1)}
+	job := worker.NewReservationSweepJob(expirer, time.Millisecond)
+	job.Start()
+
+	select {
+	case <-expirer.started:
+	case <-time.After(time.Second):
+		t.Fatal("sweep job did not run")
+	}
+	job.Stop()
+
+	if running := expirer.running.Load(); running != 0 {
+		t.Errorf("Stop() returned with %d sweeps running", running)
+	}
+	if !expirer.cancelled.Load() {
+		t.Error("running sweep was not cancelled by Stop()")
+	}
+}
+
+// blockingExpirer 一直运行到上下文取消
+type blockingExpirer struct {
+	started   chan struct{}
+	running   atomic.Int32
+	cancelled atomic.Bool
+}
+
+func (e *blockingExpirer) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	e.running.Add(1)
+	defer e.running.Add(-1)
+	select {
+	case e.started <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	e.cancelled.Store(true)
+	return 0, ctx.Err()
+}
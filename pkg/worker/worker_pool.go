@@ -1,9 +1,12 @@
 package worker
 
 import (
+	"api-gateway/config"
 	"api-gateway/model"
 	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
 	"api-gateway/pkg/queue"
+	"api-gateway/pkg/ratelimit"
 	"api-gateway/repository"
 	"bytes"
 	"context"
@@ -15,11 +18,15 @@ import (
 	"time"
 )
 
+// upstreamAcquireInterval 上游并发已满时重新尝试获取许可的间隔
+const upstreamAcquireInterval = 100 * time.Millisecond
+
 type WorkerPool struct {
 	workerCount int
 	queue       queue.TaskQueue
 	taskRepo    repository.TaskRepository
 	httpClient  *http.Client
+	concurrency *ratelimit.AdaptiveConfig // 上游自适应并发限制，未启用时为 nil
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
@@ -28,6 +35,17 @@ type WorkerPool struct {
 func NewWorkerPool(workerCount int, queue queue.TaskQueue, taskRepo repository.TaskRepository) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var concurrency *ratelimit.AdaptiveConfig
+	if cfg := config.GetConfig(); cfg != nil && cfg.Concurrency.Enabled {
+		concurrency = &ratelimit.AdaptiveConfig{
+			InitialLimit: cfg.Concurrency.InitialLimit,
+			MinLimit:     cfg.Concurrency.MinLimit,
+			MaxLimit:     cfg.Concurrency.MaxLimit,
+			Tolerance:    cfg.Concurrency.Tolerance,
+			BackoffRatio: cfg.Concurrency.BackoffRatio,
+		}
+	}
+
 	return &WorkerPool{
 		workerCount: workerCount,
 		queue:       queue,
@@ -40,8 +58,9 @@ func NewWorkerPool(workerCount int, queue queue.TaskQueue, taskRepo repository.T
 				IdleConnTimeout:     90 * time.Second,
 			},
 		},
-		ctx:    ctx,
-		cancel: cancel,
+		concurrency: concurrency,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 }
 
@@ -129,12 +148,43 @@ func (wp *WorkerPool) callUpstream(task *model.Task) (string, int, error) {
 		req.Header.Set(key, value)
 	}
 
+	// 与同步代理共用上游自适应并发限制，超出限制时等待而不是放弃任务
+	var permit *ratelimit.Permit
+	if wp.concurrency != nil {
+		host := ratelimit.UpstreamHost(task.TargetURL)
+		limiter := ratelimit.UpstreamLimiter(host, *wp.concurrency)
+		if permit, err = wp.acquireUpstream(limiter); err != nil {
+			return "", 0, fmt.Errorf("failed to acquire upstream concurrency: %w", err)
+		}
+		metrics.GetMetrics().UpstreamInFlight.WithLabelValues(host).Inc()
+		defer func() {
+			metrics.GetMetrics().UpstreamInFlight.WithLabelValues(host).Dec()
+			metrics.GetMetrics().UpstreamLimit.WithLabelValues(host).Set(float64(limiter.Limit()))
+		}()
+	}
+
+	start := time.Now()
 	resp, err := wp.httpClient.Do(req)
+	latency := time.Since(start)
 	if err != nil {
+		if permit != nil {
+			permit.Dropped()
+		}
 		return "", 0, fmt.Errorf("failed to call upstream: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if permit != nil {
+		defer func() {
+			switch resp.StatusCode {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+				permit.Dropped()
+			default:
+				permit.Success(latency)
+			}
+		}()
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
@@ -147,6 +197,20 @@ func (wp *WorkerPool) callUpstream(task *model.Task) (string, int, error) {
 	return string(body), resp.StatusCode, nil
 }
 
+// acquireUpstream 等待上游并发许可，worker pool 停止时放弃
+func (wp *WorkerPool) acquireUpstream(limiter *ratelimit.ConcurrencyLimiter) (*ratelimit.Permit, error) {
+	for {
+		if permit, ok := limiter.Acquire(); ok {
+			return permit, nil
+		}
+		select {
+		case <-wp.ctx.Done():
+			return nil, wp.ctx.Err()
+		case <-time.After(upstreamAcquireInterval):
+		}
+	}
+}
+
 func (wp *WorkerPool) executeCallback(task *model.Task) {
 	logger.Infof("Executing callback for task %s to %s", task.TaskID, task.CallbackURL)
 
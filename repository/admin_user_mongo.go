@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"api-gateway/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AdminUserMongoRepository implements AdminUserRepository using MongoDB
+type AdminUserMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAdminUserMongoRepository creates a new MongoDB admin user repository
+func NewAdminUserMongoRepository(collection *mongo.Collection) AdminUserRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// username 唯一索引
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	// token_hash 唯一索引（用于令牌认证查找）
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"token_hash": bson.M{"$type": "string"}}),
+	})
+
+	return &AdminUserMongoRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new admin user, returns an error if the username is taken
+func (r *AdminUserMongoRepository) Create(ctx context.Context, user *model.AdminUser) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("admin user already exists")
+		}
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an admin user by ID
+func (r *AdminUserMongoRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.AdminUser, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByUsername retrieves an admin user by username
+func (r *AdminUserMongoRepository) GetByUsername(ctx context.Context, username string) (*model.AdminUser, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+// GetByTokenHash retrieves an admin user by access token hash
+func (r *AdminUserMongoRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.AdminUser, error) {
+	return r.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+// List retrieves all admin users
+func (r *AdminUserMongoRepository) List(ctx context.Context) ([]*model.AdminUser, error) {
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []*model.AdminUser
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode admin users: %w", err)
+	}
+
+	return users, nil
+}
+
+// Update updates role, status, password and token of an admin user
+func (r *AdminUserMongoRepository) Update(ctx context.Context, user *model.AdminUser) error {
+	user.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"role":          user.Role,
+		"status":        user.Status,
+		"password_hash": user.PasswordHash,
+		"updated_at":    user.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	// 空令牌不能落库，否则会与 token_hash 唯一索引冲突
+	if user.TokenHash != "" {
+		set["token_hash"] = user.TokenHash
+		set["token_prefix"] = user.TokenPrefix
+	} else {
+		update["$unset"] = bson.M{"token_hash": "", "token_prefix": ""}
+	}
+
+	filter := bson.M{"_id": user.ID}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update admin user: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("admin user not found")
+	}
+
+	return nil
+}
+
+// UpdateLastLogin records the last time an admin user authenticated
+func (r *AdminUserMongoRepository) UpdateLastLogin(ctx context.Context, id primitive.ObjectID, loginAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_login_at": loginAt}})
+	if err != nil {
+		return fmt.Errorf("failed to update admin user last login time: %w", err)
+	}
+	return nil
+}
+
+func (r *AdminUserMongoRepository) findOne(ctx context.Context, filter bson.M) (*model.AdminUser, error) {
+	var user model.AdminUser
+
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("admin user not found")
+		}
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"api-gateway/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogMongoRepository implements AuditLogRepository using MongoDB
+type AuditLogMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditLogMongoRepository creates a new MongoDB audit log repository
+func NewAuditLogMongoRepository(collection *mongo.Collection) AuditLogRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return &AuditLogMongoRepository{
+		collection: collection,
+	}
+}
+
+// Create appends an audit log entry
+func (r *AuditLogMongoRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	if log.ID.IsZero() {
+		log.ID = primitive.NewObjectID()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, log)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Find retrieves audit logs matching the filter, newest first
+func (r *AuditLogMongoRepository) Find(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*model.AuditLog, error) {
+	opts := options.Find()
+	opts.SetSkip(int64(offset))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, auditLogQuery(filter), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit logs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var logs []*model.AuditLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, fmt.Errorf("failed to decode audit logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+// Count counts audit logs matching the filter
+func (r *AuditLogMongoRepository) Count(ctx context.Context, filter AuditLogFilter) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, auditLogQuery(filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	return count, nil
+}
+
+// Iterate calls fn for every audit log matching the filter in chronological order
+func (r *AuditLogMongoRepository) Iterate(ctx context.Context, filter AuditLogFilter, fn func(*model.AuditLog) error) error {
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, auditLogQuery(filter), opts)
+	if err != nil {
+		return fmt.Errorf("failed to find audit logs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var log model.AuditLog
+		if err := cursor.Decode(&log); err != nil {
+			return fmt.Errorf("failed to decode audit log: %w", err)
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	return nil
+}
+
+// auditLogQuery builds the MongoDB query of an audit log filter
+func auditLogQuery(filter AuditLogFilter) bson.M {
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.ClientID != nil {
+		query["client_id"] = *filter.ClientID
+	}
+	if filter.RequestID != "" {
+		query["request_id"] = filter.RequestID
+	}
+
+	createdAt := bson.M{}
+	if filter.From != nil {
+		createdAt["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		createdAt["$lt"] = *filter.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"api-gateway/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key prefixes of authentication failure protection
+const (
+	authFailureKeyPrefix = "gw:authguard:fail:"
+	authStrikeKeyPrefix  = "gw:authguard:strike:"
+	authBanKeyPrefix     = "gw:authguard:ban:"
+)
+
+// expiringCounter is a counter that disappears after expiresAt
+type expiringCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryAuthGuardRepository keeps failure counters and bans in process memory.
+// Only suitable for a single gateway instance.
+type MemoryAuthGuardRepository struct {
+	failures map[string]*expiringCounter
+	strikes  map[string]*expiringCounter
+	bans     map[string]*model.AuthBan
+	mutex    sync.Mutex
+}
+
+// NewMemoryAuthGuardRepository creates an in-memory auth guard repository
+func NewMemoryAuthGuardRepository() AuthGuardRepository {
+	r := &MemoryAuthGuardRepository{
+		failures: make(map[string]*expiringCounter),
+		strikes:  make(map[string]*expiringCounter),
+		bans:     make(map[string]*model.AuthBan),
+	}
+
+	// 启动清理协程，定期清理过期的计数和封禁
+	go r.cleanup()
+
+	return r
+}
+
+// IncrFailures increments the failure counter of a key, the counter expires after window
+func (r *MemoryAuthGuardRepository) IncrFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return incrCounter(r.failures, key, window, false), nil
+}
+
+// ResetFailures clears the failure counter of a key
+func (r *MemoryAuthGuardRepository) ResetFailures(ctx context.Context, key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.failures, key)
+	return nil
+}
+
+// IncrStrikes increments how many times a key has been banned, kept for ttl
+func (r *MemoryAuthGuardRepository) IncrStrikes(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return incrCounter(r.strikes, key, ttl, true), nil
+}
+
+// SaveBan stores a ban until its expiry time
+func (r *MemoryAuthGuardRepository) SaveBan(ctx context.Context, ban *model.AuthBan) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *ban
+	r.bans[model.AuthBanKey(ban.Scope, ban.Subject)] = &stored
+	return nil
+}
+
+// GetBan retrieves the active ban of a key, returns nil if not banned
+func (r *MemoryAuthGuardRepository) GetBan(ctx context.Context, key string) (*model.AuthBan, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ban, exists := r.bans[key]
+	if !exists || time.Now().After(ban.ExpiresAt) {
+		return nil, nil
+	}
+
+	result := *ban
+	return &result, nil
+}
+
+// ListBans retrieves all active bans
+func (r *MemoryAuthGuardRepository) ListBans(ctx context.Context) ([]*model.AuthBan, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	bans := make([]*model.AuthBan, 0, len(r.bans))
+	for _, ban := range r.bans {
+		if now.Before(ban.ExpiresAt) {
+			result := *ban
+			bans = append(bans, &result)
+		}
+	}
+
+	sortBans(bans)
+	return bans, nil
+}
+
+// DeleteBan removes a ban, returns false if it did not exist
+func (r *MemoryAuthGuardRepository) DeleteBan(ctx context.Context, key string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ban, exists := r.bans[key]
+	if !exists {
+		return false, nil
+	}
+	delete(r.bans, key)
+	return time.Now().Before(ban.ExpiresAt), nil
+}
+
+// cleanup removes expired counters and bans
+func (r *MemoryAuthGuardRepository) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mutex.Lock()
+		now := time.Now()
+		for key, counter := range r.failures {
+			if now.After(counter.expiresAt) {
+				delete(r.failures, key)
+			}
+		}
+		for key, counter := range r.strikes {
+			if now.After(counter.expiresAt) {
+				delete(r.strikes, key)
+			}
+		}
+		for key, ban := range r.bans {
+			if now.After(ban.ExpiresAt) {
+				delete(r.bans, key)
+			}
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// incrCounter increments a counter, extend 为 true 时每次递增都刷新过期时间
+func incrCounter(counters map[string]*expiringCounter, key string, ttl time.Duration, extend bool) int64 {
+	now := time.Now()
+	counter, exists := counters[key]
+	if !exists || now.After(counter.expiresAt) {
+		counter = &expiringCounter{expiresAt: now.Add(ttl)}
+		counters[key] = counter
+	} else if extend {
+		counter.expiresAt = now.Add(ttl)
+	}
+
+	counter.count++
+	return counter.count
+}
+
+// RedisAuthGuardRepository keeps failure counters and bans in Redis so that
+// all gateway instances share them
+type RedisAuthGuardRepository struct {
+	client *redis.Client
+}
+
+// NewRedisAuthGuardRepository creates a Redis auth guard repository
+func NewRedisAuthGuardRepository(client *redis.Client) AuthGuardRepository {
+	return &RedisAuthGuardRepository{client: client}
+}
+
+// IncrFailures increments the failure counter of a key, the counter expires after window
+func (r *RedisAuthGuardRepository) IncrFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := r.client.Incr(ctx, authFailureKeyPrefix+key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment auth failures: %w", err)
+	}
+
+	// 固定窗口：仅在首次计数时设置过期时间
+	if count == 1 {
+		if err := r.client.Expire(ctx, authFailureKeyPrefix+key, window).Err(); err != nil {
+			return 0, fmt.Errorf("failed to set auth failures expiry: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// ResetFailures clears the failure counter of a key
+func (r *RedisAuthGuardRepository) ResetFailures(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, authFailureKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to reset auth failures: %w", err)
+	}
+	return nil
+}
+
+// IncrStrikes increments how many times a key has been banned, kept for ttl
+func (r *RedisAuthGuardRepository) IncrStrikes(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, authStrikeKeyPrefix+key)
+	pipe.Expire(ctx, authStrikeKeyPrefix+key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment auth strikes: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// SaveBan stores a ban until its expiry time
+func (r *RedisAuthGuardRepository) SaveBan(ctx context.Context, ban *model.AuthBan) error {
+	ttl := time.Until(ban.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auth ban: %w", err)
+	}
+
+	key := authBanKeyPrefix + model.AuthBanKey(ban.Scope, ban.Subject)
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save auth ban: %w", err)
+	}
+	return nil
+}
+
+// GetBan retrieves the active ban of a key, returns nil if not banned
+func (r *RedisAuthGuardRepository) GetBan(ctx context.Context, key string) (*model.AuthBan, error) {
+	data, err := r.client.Get(ctx, authBanKeyPrefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get auth ban: %w", err)
+	}
+
+	var ban model.AuthBan
+	if err := json.Unmarshal(data, &ban); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auth ban: %w", err)
+	}
+	return &ban, nil
+}
+
+// ListBans retrieves all active bans
+func (r *RedisAuthGuardRepository) ListBans(ctx context.Context) ([]*model.AuthBan, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, authBanKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan auth bans: %w", err)
+	}
+
+	bans := make([]*model.AuthBan, 0, len(keys))
+	if len(keys) == 0 {
+		return bans, nil
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth bans: %w", err)
+	}
+	for _, value := range values {
+		// 扫描后过期的 key 返回 nil
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var ban model.AuthBan
+		if err := json.Unmarshal([]byte(data), &ban); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal auth ban: %w", err)
+		}
+		bans = append(bans, &ban)
+	}
+
+	sortBans(bans)
+	return bans, nil
+}
+
+// DeleteBan removes a ban, returns false if it did not exist
+func (r *RedisAuthGuardRepository) DeleteBan(ctx context.Context, key string) (bool, error) {
+	deleted, err := r.client.Del(ctx, authBanKeyPrefix+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete auth ban: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// sortBans sorts bans by ban time, newest first
+func sortBans(bans []*model.AuthBan) {
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].BannedAt.After(bans[j].BannedAt)
+	})
+}
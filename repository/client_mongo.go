@@ -2,6 +2,7 @@ package repository
 
 import (
 	"api-gateway/model"
+	"api-gateway/pkg/secrets"
 	"context"
 	"fmt"
 	"time"
@@ -15,12 +16,27 @@ import (
 // ClientMongoRepository implements ClientRepository using MongoDB
 type ClientMongoRepository struct {
 	collection *mongo.Collection
+	codec      secretCodec
 }
 
-// NewClientMongoRepository creates a new MongoDB client repository
-func NewClientMongoRepository(collection *mongo.Collection) ClientRepository {
+// NewClientMongoRepository creates a new MongoDB client repository.
+// API keys are stored as hashes; secrets are encrypted when a keyring is given.
+func NewClientMongoRepository(collection *mongo.Collection, keyring *secrets.Keyring) ClientRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// api_key_hash 唯一索引（用于认证查找）
+	_, _ = collection.Indexes().CreateOne(ctx, apiKeyHashIndex())
+
+	// valid_until 索引（用于到期检查）
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "valid_until", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+
 	return &ClientMongoRepository{
 		collection: collection,
+		codec:      secretCodec{keyring: keyring},
 	}
 }
 
@@ -33,11 +49,19 @@ func (r *ClientMongoRepository) Create(ctx context.Context, client *model.Client
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
 
-	_, err := r.collection.InsertOne(ctx, client)
+	doc, err := r.toDocument(client)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
+	_, err = r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	client.APIKeyHash = doc.APIKeyHash
+	client.APIKeyPrefix = doc.APIKeyPrefix
+	client.EncryptedSecret = doc.EncryptedSecret
 	return nil
 }
 
@@ -53,6 +77,10 @@ func (r *ClientMongoRepository) GetByID(ctx context.Context, id primitive.Object
 		return nil, fmt.Errorf("failed to get client by ID: %w", err)
 	}
 
+	if err := r.fromDocument(&client); err != nil {
+		return nil, err
+	}
+
 	return &client, nil
 }
 
@@ -60,7 +88,7 @@ func (r *ClientMongoRepository) GetByID(ctx context.Context, id primitive.Object
 func (r *ClientMongoRepository) GetByAPIKey(ctx context.Context, apiKey string) (*model.Client, error) {
 	var client model.Client
 
-	err := r.collection.FindOne(ctx, bson.M{"api_key": apiKey}).Decode(&client)
+	err := r.collection.FindOne(ctx, apiKeyFilter(apiKey)).Decode(&client)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("client not found")
@@ -68,6 +96,11 @@ func (r *ClientMongoRepository) GetByAPIKey(ctx context.Context, apiKey string)
 		return nil, fmt.Errorf("failed to get client by API key: %w", err)
 	}
 
+	if err := r.fromDocument(&client); err != nil {
+		return nil, err
+	}
+	client.APIKey = apiKey
+
 	return &client, nil
 }
 
@@ -100,8 +133,16 @@ func (r *ClientMongoRepository) UpdateCallCount(ctx context.Context, id primitiv
 func (r *ClientMongoRepository) Update(ctx context.Context, client *model.Client) error {
 	client.UpdatedAt = time.Now()
 
+	doc, err := r.toDocument(client)
+	if err != nil {
+		return fmt.Errorf("failed to update client: %w", err)
+	}
+
 	filter := bson.M{"_id": client.ID}
-	update := bson.M{"$set": client}
+	update := bson.M{"$set": doc}
+	if unset := plaintextUnset(clientSealedFields(doc)); len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -134,6 +175,9 @@ func (r *ClientMongoRepository) List(ctx context.Context, offset, limit int) ([]
 		if err := cursor.Decode(&client); err != nil {
 			return nil, fmt.Errorf("failed to decode client: %w", err)
 		}
+		if err := r.fromDocument(&client); err != nil {
+			return nil, err
+		}
 		clients = append(clients, &client)
 	}
 
@@ -144,37 +188,89 @@ func (r *ClientMongoRepository) List(ctx context.Context, offset, limit int) ([]
 	return clients, nil
 }
 
-// DeductCallCount atomically decrements call count by 1, returns error if insufficient calls
-func (r *ClientMongoRepository) DeductCallCount(ctx context.Context, id primitive.ObjectID) error {
+// DeductCallCount atomically decrements call count by amount and returns the remaining calls,
+// returns ErrInsufficientCalls if the balance is lower than amount
+func (r *ClientMongoRepository) DeductCallCount(ctx context.Context, id primitive.ObjectID, amount int) (int, error) {
+	if amount <= 0 {
+		amount = 1
+	}
+
 	// 使用findOneAndUpdate进行原子操作
 	filter := bson.M{
 		"_id":        id,
-		"call_count": bson.M{"$gt": 0}, // 只有当call_count > 0时才更新
+		"call_count": bson.M{"$gte": amount}, // 只有当余额足够时才更新
 	}
 	update := bson.M{
-		"$inc": bson.M{"call_count": -1},
+		"$inc": bson.M{"call_count": -amount},
 		"$set": bson.M{"updated_at": time.Now()},
 	}
 
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"call_count": 1})
+
 	var result model.Client
-	err := r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&result)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// 没有找到符合条件的文档，说明余额不足或客户不存在
-			return fmt.Errorf("insufficient calls")
+			return 0, ErrInsufficientCalls
 		}
-		return fmt.Errorf("failed to deduct call count: %w", err)
+		return 0, fmt.Errorf("failed to deduct call count: %w", err)
+	}
+
+	return result.CallCount, nil
+}
+
+// RefundCallCount returns a held or deducted amount to the call count, total count is unchanged
+func (r *ClientMongoRepository) RefundCallCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$inc": bson.M{"call_count": amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to refund call count: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateStatus updates the status of a client, other fields are left unchanged
+func (r *ClientMongoRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status int) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client status: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
 	}
 
 	return nil
 }
 
-// UpdateQPS updates the QPS limit for a client
-func (r *ClientMongoRepository) UpdateQPS(ctx context.Context, id primitive.ObjectID, qps int) error {
+// UpdateQPS updates the QPS limit and burst size for a client
+func (r *ClientMongoRepository) UpdateQPS(ctx context.Context, id primitive.ObjectID, qps, burst int) error {
 	filter := bson.M{"_id": id}
 	update := bson.M{
 		"$set": bson.M{
 			"qps":        qps,
+			"burst":      burst,
 			"updated_at": time.Now(),
 		},
 	}
@@ -191,6 +287,380 @@ func (r *ClientMongoRepository) UpdateQPS(ctx context.Context, id primitive.Obje
 	return nil
 }
 
+// UpdatePlan updates the billing plan of a client
+func (r *ClientMongoRepository) UpdatePlan(ctx context.Context, id primitive.ObjectID, plan string) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"plan":       plan,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client plan: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateIPRules replaces the IP allowlist and denylist of a client
+func (r *ClientMongoRepository) UpdateIPRules(ctx context.Context, id primitive.ObjectID, allowlist, denylist []string) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"ip_allowlist": allowlist,
+			"ip_denylist":  denylist,
+			"updated_at":   time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client IP rules: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateCORS replaces the CORS policy of a client, nil removes the policy
+func (r *ClientMongoRepository) UpdateCORS(ctx context.Context, id primitive.ObjectID, policy *model.CORSPolicy) error {
+	update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+	if policy != nil {
+		update["$set"].(bson.M)["cors"] = policy
+	} else {
+		update["$unset"] = bson.M{"cors": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client CORS policy: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateRateLimits replaces the rate limit overrides of a client
+func (r *ClientMongoRepository) UpdateRateLimits(ctx context.Context, id primitive.ObjectID, overrides []model.RateLimitOverride) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"rate_limits": overrides,
+			"updated_at":  time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client rate limits: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateTemporaryLimit sets the temporary rate limit adjustment of a client, nil removes it
+func (r *ClientMongoRepository) UpdateTemporaryLimit(ctx context.Context, id primitive.ObjectID, limit *model.TemporaryLimit) error {
+	update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+	if limit != nil {
+		update["$set"].(bson.M)["temporary_limit"] = limit
+	} else {
+		update["$unset"] = bson.M{"temporary_limit": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client temporary limit: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateQuotas replaces the call quotas of a client
+func (r *ClientMongoRepository) UpdateQuotas(ctx context.Context, id primitive.ObjectID, quotas []model.Quota) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"quotas":     quotas,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client quotas: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// FindCORSPolicies retrieves the CORS policies of active clients that allow browser origins.
+// 只返回 ID、名称和跨域策略，不加载密钥
+func (r *ClientMongoRepository) FindCORSPolicies(ctx context.Context) ([]*model.Client, error) {
+	filter := bson.M{
+		"status":                 model.ClientStatusActive,
+		"cors.allowed_origins.0": bson.M{"$exists": true},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "status": 1, "cors": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find client CORS policies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*model.Client
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, fmt.Errorf("failed to decode clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// AddPublicKey registers a public key, fails if the key ID is already used
+func (r *ClientMongoRepository) AddPublicKey(ctx context.Context, id primitive.ObjectID, key *model.PublicKey) error {
+	filter := bson.M{
+		"_id":                id,
+		"public_keys.key_id": bson.M{"$ne": key.KeyID},
+	}
+	update := bson.M{
+		"$push": bson.M{"public_keys": key},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to add public key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		// 区分客户不存在和 key id 重复
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return fmt.Errorf("failed to add public key: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("client not found")
+		}
+		return fmt.Errorf("public key already exists")
+	}
+
+	return nil
+}
+
+// RemovePublicKey removes a registered public key
+func (r *ClientMongoRepository) RemovePublicKey(ctx context.Context, id primitive.ObjectID, keyID string) error {
+	filter := bson.M{
+		"_id":                id,
+		"public_keys.key_id": keyID,
+	}
+	update := bson.M{
+		"$pull": bson.M{"public_keys": bson.M{"key_id": keyID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to remove public key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("public key not found")
+	}
+
+	return nil
+}
+
+// UpdateSignatureType sets the request signature type of a client
+func (r *ClientMongoRepository) UpdateSignatureType(ctx context.Context, id primitive.ObjectID, signatureType string) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"signature_type": signatureType,
+			"updated_at":     time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update client signature type: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// UpdateValidity replaces the contract validity window of a client, nil clears a bound,
+// activate also sets the client status to active.
+// 修改有效期后清空到期提醒记录，以便新的到期时间重新提醒
+func (r *ClientMongoRepository) UpdateValidity(ctx context.Context, id primitive.ObjectID, validFrom, validUntil *time.Time, activate bool) error {
+	set := bson.M{"updated_at": time.Now()}
+	if activate {
+		set["status"] = model.ClientStatusActive
+	}
+	unset := bson.M{"expiry_notified_at": ""}
+	if validFrom != nil {
+		set["valid_from"] = *validFrom
+	} else {
+		unset["valid_from"] = ""
+	}
+	if validUntil != nil {
+		set["valid_until"] = *validUntil
+	} else {
+		unset["valid_until"] = ""
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return fmt.Errorf("failed to update client validity: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// FindExpiring retrieves active clients whose contract ends before the given time
+func (r *ClientMongoRepository) FindExpiring(ctx context.Context, before time.Time) ([]*model.Client, error) {
+	filter := bson.M{
+		"status":      model.ClientStatusActive,
+		"valid_until": bson.M{"$lte": before},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "valid_until", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expiring clients: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var clients []*model.Client
+	for cursor.Next(ctx) {
+		var client model.Client
+		if err := cursor.Decode(&client); err != nil {
+			return nil, fmt.Errorf("failed to decode client: %w", err)
+		}
+		if err := r.fromDocument(&client); err != nil {
+			return nil, err
+		}
+		clients = append(clients, &client)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return clients, nil
+}
+
+// MarkExpiryNotified records that the expiry reminder was sent, returns false if already recorded.
+// 多个网关实例同时检查时，只有标记成功的实例发送提醒
+func (r *ClientMongoRepository) MarkExpiryNotified(ctx context.Context, id primitive.ObjectID, validUntil, notifiedAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":                id,
+		"valid_until":        validUntil,
+		"expiry_notified_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"expiry_notified_at": notifiedAt}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark client expiry notified: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// DisableExpired disables a client if its contract has ended, returns false if nothing changed
+func (r *ClientMongoRepository) DisableExpired(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	// 条件更新，避免覆盖同时进行的续约
+	filter := bson.M{
+		"_id":         id,
+		"status":      model.ClientStatusActive,
+		"valid_until": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.ClientStatusDisabled,
+			"updated_at": now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to disable expired client: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// AddScopes grants route scopes to a client
+func (r *ClientMongoRepository) AddScopes(ctx context.Context, id primitive.ObjectID, scopes []string) error {
+	update := bson.M{
+		"$addToSet": bson.M{"scopes": bson.M{"$each": scopes}},
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to add client scopes: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
+// RemoveScopes revokes route scopes from a client
+func (r *ClientMongoRepository) RemoveScopes(ctx context.Context, id primitive.ObjectID, scopes []string) error {
+	update := bson.M{
+		"$pull": bson.M{"scopes": bson.M{"$in": scopes}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to remove client scopes: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	return nil
+}
+
 // Delete deletes a client by ID
 func (r *ClientMongoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -204,3 +674,91 @@ func (r *ClientMongoRepository) Delete(ctx context.Context, id primitive.ObjectI
 
 	return nil
 }
+
+// MigrateSecrets hashes plaintext API keys and (re-)encrypts secrets with the current key version
+func (r *ClientMongoRepository) MigrateSecrets(ctx context.Context) (int, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list clients: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var client model.Client
+		if err := cursor.Decode(&client); err != nil {
+			return migrated, fmt.Errorf("failed to decode client: %w", err)
+		}
+
+		if !r.codec.needsMigration(client.APIKey, client.Secret, client.EncryptedSecret) {
+			continue
+		}
+
+		if err := r.fromDocument(&client); err != nil {
+			return migrated, fmt.Errorf("client %s: %w", client.ID.Hex(), err)
+		}
+
+		doc, err := r.toDocument(&client)
+		if err != nil {
+			return migrated, fmt.Errorf("client %s: %w", client.ID.Hex(), err)
+		}
+
+		sealed := clientSealedFields(doc)
+		update := bson.M{"$set": migrationSet(sealed)}
+		if unset := plaintextUnset(sealed); len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": client.ID}, update); err != nil {
+			return migrated, fmt.Errorf("failed to migrate client %s: %w", client.ID.Hex(), err)
+		}
+		migrated++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return migrated, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// toDocument returns a copy of the client with API key hashed and secret sealed for storage
+func (r *ClientMongoRepository) toDocument(client *model.Client) (*model.Client, error) {
+	sealed, err := r.codec.seal(client.APIKey, client.Secret, sealedFields{
+		APIKeyHash:      client.APIKeyHash,
+		APIKeyPrefix:    client.APIKeyPrefix,
+		EncryptedSecret: client.EncryptedSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := *client
+	doc.APIKey = ""
+	doc.APIKeyHash = sealed.APIKeyHash
+	doc.APIKeyPrefix = sealed.APIKeyPrefix
+	doc.Secret = sealed.Secret
+	doc.EncryptedSecret = sealed.EncryptedSecret
+
+	return &doc, nil
+}
+
+// fromDocument decrypts the stored secret of a client in place
+func (r *ClientMongoRepository) fromDocument(client *model.Client) error {
+	secret, err := r.codec.open(client.Secret, client.EncryptedSecret)
+	if err != nil {
+		return fmt.Errorf("client %s: %w", client.ID.Hex(), err)
+	}
+	client.Secret = secret
+	return nil
+}
+
+// clientSealedFields extracts the stored key fields of a client document
+func clientSealedFields(doc *model.Client) sealedFields {
+	return sealedFields{
+		APIKeyHash:      doc.APIKeyHash,
+		APIKeyPrefix:    doc.APIKeyPrefix,
+		Secret:          doc.Secret,
+		EncryptedSecret: doc.EncryptedSecret,
+	}
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/secrets"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CredentialMongoRepository implements CredentialRepository using MongoDB
+type CredentialMongoRepository struct {
+	collection *mongo.Collection
+	codec      secretCodec
+}
+
+// NewCredentialMongoRepository creates a new MongoDB credential repository.
+// API keys are stored as hashes; secrets are encrypted when a keyring is given.
+func NewCredentialMongoRepository(collection *mongo.Collection, keyring *secrets.Keyring) CredentialRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// api_key_hash 唯一索引（用于认证查找）
+	_, _ = collection.Indexes().CreateOne(ctx, apiKeyHashIndex())
+
+	// client_id 索引（用于查询客户的密钥列表）
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+
+	return &CredentialMongoRepository{
+		collection: collection,
+		codec:      secretCodec{keyring: keyring},
+	}
+}
+
+// Create creates a new credential
+func (r *CredentialMongoRepository) Create(ctx context.Context, credential *model.Credential) error {
+	if credential.ID.IsZero() {
+		credential.ID = primitive.NewObjectID()
+	}
+
+	credential.CreatedAt = time.Now()
+	credential.UpdatedAt = time.Now()
+
+	doc, err := r.toDocument(credential)
+	if err != nil {
+		return fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	_, err = r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	credential.APIKeyHash = doc.APIKeyHash
+	credential.APIKeyPrefix = doc.APIKeyPrefix
+	credential.EncryptedSecret = doc.EncryptedSecret
+	return nil
+}
+
+// GetByID retrieves a credential by ID
+func (r *CredentialMongoRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Credential, error) {
+	var credential model.Credential
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&credential)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("credential not found")
+		}
+		return nil, fmt.Errorf("failed to get credential by ID: %w", err)
+	}
+
+	if err := r.fromDocument(&credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// GetByAPIKey retrieves a credential by API key
+func (r *CredentialMongoRepository) GetByAPIKey(ctx context.Context, apiKey string) (*model.Credential, error) {
+	var credential model.Credential
+
+	err := r.collection.FindOne(ctx, apiKeyFilter(apiKey)).Decode(&credential)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("credential not found")
+		}
+		return nil, fmt.Errorf("failed to get credential by API key: %w", err)
+	}
+
+	if err := r.fromDocument(&credential); err != nil {
+		return nil, err
+	}
+	credential.APIKey = apiKey
+
+	return &credential, nil
+}
+
+// ListByClientID retrieves all credentials of a client
+func (r *CredentialMongoRepository) ListByClientID(ctx context.Context, clientID primitive.ObjectID) ([]*model.Credential, error) {
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}}) // Sort by created_at descending
+
+	cursor, err := r.collection.Find(ctx, bson.M{"client_id": clientID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var credentials []*model.Credential
+	for cursor.Next(ctx) {
+		var credential model.Credential
+		if err := cursor.Decode(&credential); err != nil {
+			return nil, fmt.Errorf("failed to decode credential: %w", err)
+		}
+		if err := r.fromDocument(&credential); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, &credential)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// SetExpiry sets the expiry time of a credential
+func (r *CredentialMongoRepository) SetExpiry(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"expires_at": expiresAt,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to set credential expiry: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("credential not found")
+	}
+
+	return nil
+}
+
+// Revoke marks a credential as revoked
+func (r *CredentialMongoRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.CredentialStatusRevoked,
+			"revoked_at": now,
+			"updated_at": now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to revoke credential: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("credential not found")
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records the last time a credential was used
+func (r *CredentialMongoRepository) UpdateLastUsed(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"last_used_at": usedAt}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update credential last used time: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateSecrets hashes plaintext API keys and (re-)encrypts secrets with the current key version
+func (r *CredentialMongoRepository) MigrateSecrets(ctx context.Context) (int, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var credential model.Credential
+		if err := cursor.Decode(&credential); err != nil {
+			return migrated, fmt.Errorf("failed to decode credential: %w", err)
+		}
+
+		if !r.codec.needsMigration(credential.APIKey, credential.Secret, credential.EncryptedSecret) {
+			continue
+		}
+
+		if err := r.fromDocument(&credential); err != nil {
+			return migrated, err
+		}
+
+		doc, err := r.toDocument(&credential)
+		if err != nil {
+			return migrated, fmt.Errorf("credential %s: %w", credential.ID.Hex(), err)
+		}
+
+		sealed := credentialSealedFields(doc)
+		update := bson.M{"$set": migrationSet(sealed)}
+		if unset := plaintextUnset(sealed); len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": credential.ID}, update); err != nil {
+			return migrated, fmt.Errorf("failed to migrate credential %s: %w", credential.ID.Hex(), err)
+		}
+		migrated++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return migrated, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// toDocument returns a copy of the credential with API key hashed and secret sealed for storage
+func (r *CredentialMongoRepository) toDocument(credential *model.Credential) (*model.Credential, error) {
+	sealed, err := r.codec.seal(credential.APIKey, credential.Secret, sealedFields{
+		APIKeyHash:      credential.APIKeyHash,
+		APIKeyPrefix:    credential.APIKeyPrefix,
+		EncryptedSecret: credential.EncryptedSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := *credential
+	doc.APIKey = ""
+	doc.APIKeyHash = sealed.APIKeyHash
+	doc.APIKeyPrefix = sealed.APIKeyPrefix
+	doc.Secret = sealed.Secret
+	doc.EncryptedSecret = sealed.EncryptedSecret
+
+	return &doc, nil
+}
+
+// fromDocument decrypts the stored secret of a credential in place
+func (r *CredentialMongoRepository) fromDocument(credential *model.Credential) error {
+	secret, err := r.codec.open(credential.Secret, credential.EncryptedSecret)
+	if err != nil {
+		return fmt.Errorf("credential %s: %w", credential.ID.Hex(), err)
+	}
+	credential.Secret = secret
+	return nil
+}
+
+// credentialSealedFields extracts the stored key fields of a credential document
+func credentialSealedFields(doc *model.Credential) sealedFields {
+	return sealedFields{
+		APIKeyHash:      doc.APIKeyHash,
+		APIKeyPrefix:    doc.APIKeyPrefix,
+		Secret:          doc.Secret,
+		EncryptedSecret: doc.EncryptedSecret,
+	}
+}
@@ -3,10 +3,15 @@ package repository
 import (
 	"api-gateway/model"
 	"context"
+	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInsufficientCalls is returned by DeductCallCount when the balance is lower than the amount
+var ErrInsufficientCalls = errors.New("insufficient calls")
+
 // ClientRepository defines the interface for client data operations
 type ClientRepository interface {
 	// Create creates a new client
@@ -17,16 +22,172 @@ type ClientRepository interface {
 	GetByAPIKey(ctx context.Context, apiKey string) (*model.Client, error)
 	// UpdateCallCount updates the call count for a client
 	UpdateCallCount(ctx context.Context, id primitive.ObjectID, delta int) error
-	// DeductCallCount atomically decrements call count by 1, returns error if insufficient calls
-	DeductCallCount(ctx context.Context, id primitive.ObjectID) error
-	// UpdateQPS updates the QPS limit for a client
-	UpdateQPS(ctx context.Context, id primitive.ObjectID, qps int) error
+	// DeductCallCount atomically decrements call count by amount and returns the remaining calls,
+	// returns ErrInsufficientCalls if the balance is lower than amount
+	DeductCallCount(ctx context.Context, id primitive.ObjectID, amount int) (int, error)
+	// RefundCallCount returns a held or deducted amount to the call count, total count is unchanged
+	RefundCallCount(ctx context.Context, id primitive.ObjectID, amount int) error
+	// UpdatePlan updates the billing plan of a client
+	UpdatePlan(ctx context.Context, id primitive.ObjectID, plan string) error
+	// UpdateStatus updates the status of a client
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status int) error
+	// UpdateQPS updates the QPS limit and burst size for a client, burst 0 means equal to QPS
+	UpdateQPS(ctx context.Context, id primitive.ObjectID, qps, burst int) error
+	// UpdateIPRules replaces the IP allowlist and denylist of a client
+	UpdateIPRules(ctx context.Context, id primitive.ObjectID, allowlist, denylist []string) error
+	// AddScopes grants route scopes to a client
+	AddScopes(ctx context.Context, id primitive.ObjectID, scopes []string) error
+	// RemoveScopes revokes route scopes from a client
+	RemoveScopes(ctx context.Context, id primitive.ObjectID, scopes []string) error
+	// UpdateCORS replaces the CORS policy of a client, nil removes the policy
+	UpdateCORS(ctx context.Context, id primitive.ObjectID, policy *model.CORSPolicy) error
+	// UpdateRateLimits replaces the rate limit overrides of a client
+	UpdateRateLimits(ctx context.Context, id primitive.ObjectID, overrides []model.RateLimitOverride) error
+	// UpdateTemporaryLimit sets the temporary rate limit adjustment of a client, nil removes it
+	UpdateTemporaryLimit(ctx context.Context, id primitive.ObjectID, limit *model.TemporaryLimit) error
+	// UpdateQuotas replaces the call quotas of a client
+	UpdateQuotas(ctx context.Context, id primitive.ObjectID, quotas []model.Quota) error
+	// FindCORSPolicies retrieves the CORS policies of active clients that allow browser origins
+	FindCORSPolicies(ctx context.Context) ([]*model.Client, error)
+	// AddPublicKey registers a public key, fails if the key ID is already used
+	AddPublicKey(ctx context.Context, id primitive.ObjectID, key *model.PublicKey) error
+	// RemovePublicKey removes a registered public key
+	RemovePublicKey(ctx context.Context, id primitive.ObjectID, keyID string) error
+	// UpdateSignatureType sets the request signature type of a client
+	UpdateSignatureType(ctx context.Context, id primitive.ObjectID, signatureType string) error
+	// UpdateValidity replaces the contract validity window of a client, nil clears a bound,
+	// activate also sets the client status to active
+	UpdateValidity(ctx context.Context, id primitive.ObjectID, validFrom, validUntil *time.Time, activate bool) error
+	// FindExpiring retrieves active clients whose contract ends before the given time
+	FindExpiring(ctx context.Context, before time.Time) ([]*model.Client, error)
+	// MarkExpiryNotified records that the expiry reminder was sent, returns false if already recorded
+	MarkExpiryNotified(ctx context.Context, id primitive.ObjectID, validUntil, notifiedAt time.Time) (bool, error)
+	// DisableExpired disables a client if its contract has ended, returns false if nothing changed
+	DisableExpired(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error)
 	// Update updates a client
 	Update(ctx context.Context, client *model.Client) error
 	// List retrieves all clients with pagination
 	List(ctx context.Context, offset, limit int) ([]*model.Client, error)
 	// Delete deletes a client by ID
 	Delete(ctx context.Context, id primitive.ObjectID) error
+	// MigrateSecrets hashes plaintext API keys and re-encrypts secrets, returns the number of migrated clients
+	MigrateSecrets(ctx context.Context) (int, error)
+}
+
+// CredentialRepository defines the interface for client credential operations
+type CredentialRepository interface {
+	// Create creates a new credential
+	Create(ctx context.Context, credential *model.Credential) error
+	// GetByID retrieves a credential by ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Credential, error)
+	// GetByAPIKey retrieves a credential by API key
+	GetByAPIKey(ctx context.Context, apiKey string) (*model.Credential, error)
+	// ListByClientID retrieves all credentials of a client
+	ListByClientID(ctx context.Context, clientID primitive.ObjectID) ([]*model.Credential, error)
+	// SetExpiry sets the expiry time of a credential
+	SetExpiry(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error
+	// Revoke marks a credential as revoked
+	Revoke(ctx context.Context, id primitive.ObjectID) error
+	// UpdateLastUsed records the last time a credential was used
+	UpdateLastUsed(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error
+	// MigrateSecrets hashes plaintext API keys and re-encrypts secrets, returns the number of migrated credentials
+	MigrateSecrets(ctx context.Context) (int, error)
+}
+
+// AdminUserRepository defines the interface for admin user operations
+type AdminUserRepository interface {
+	// Create creates a new admin user, returns an error if the username is taken
+	Create(ctx context.Context, user *model.AdminUser) error
+	// GetByID retrieves an admin user by ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.AdminUser, error)
+	// GetByUsername retrieves an admin user by username
+	GetByUsername(ctx context.Context, username string) (*model.AdminUser, error)
+	// GetByTokenHash retrieves an admin user by access token hash
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.AdminUser, error)
+	// List retrieves all admin users
+	List(ctx context.Context) ([]*model.AdminUser, error)
+	// Update updates role, status, password and token of an admin user
+	Update(ctx context.Context, user *model.AdminUser) error
+	// UpdateLastLogin records the last time an admin user authenticated
+	UpdateLastLogin(ctx context.Context, id primitive.ObjectID, loginAt time.Time) error
+}
+
+// AuditLogFilter holds the optional conditions of an audit log query
+type AuditLogFilter struct {
+	Actor     string
+	Action    string
+	ClientID  *primitive.ObjectID
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+}
+
+// AuditLogRepository defines the interface for audit log operations.
+// Audit logs are append-only, so there is no update or delete.
+type AuditLogRepository interface {
+	// Create appends an audit log entry
+	Create(ctx context.Context, log *model.AuditLog) error
+	// Find retrieves audit logs matching the filter, newest first
+	Find(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*model.AuditLog, error)
+	// Count counts audit logs matching the filter
+	Count(ctx context.Context, filter AuditLogFilter) (int64, error)
+	// Iterate calls fn for every audit log matching the filter in chronological order
+	Iterate(ctx context.Context, filter AuditLogFilter, fn func(*model.AuditLog) error) error
+}
+
+// TokenRevocationRepository records revoked access tokens until they expire
+type TokenRevocationRepository interface {
+	// Revoke marks a token ID as revoked until its expiry time
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked reports whether a token ID has been revoked
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// NonceRepository remembers request nonces to reject replayed requests
+type NonceRepository interface {
+	// Remember stores a nonce for the given TTL, returns false if it was already seen
+	Remember(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// AuthGuardRepository stores authentication failure counters and temporary bans
+type AuthGuardRepository interface {
+	// IncrFailures increments the failure counter of a key, the counter expires after window
+	IncrFailures(ctx context.Context, key string, window time.Duration) (int64, error)
+	// ResetFailures clears the failure counter of a key
+	ResetFailures(ctx context.Context, key string) error
+	// IncrStrikes increments how many times a key has been banned, kept for ttl
+	IncrStrikes(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// SaveBan stores a ban until its expiry time
+	SaveBan(ctx context.Context, ban *model.AuthBan) error
+	// GetBan retrieves the active ban of a key, returns nil if not banned
+	GetBan(ctx context.Context, key string) (*model.AuthBan, error)
+	// ListBans retrieves all active bans
+	ListBans(ctx context.Context) ([]*model.AuthBan, error)
+	// DeleteBan removes a ban, returns false if it did not exist
+	DeleteBan(ctx context.Context, key string) (bool, error)
+}
+
+// QuotaRepository stores calendar window quota counters
+type QuotaRepository interface {
+	// Consume atomically adds cost to a counter if the result does not exceed limit.
+	// Returns the counter value and whether the cost was added; the counter expires at expireAt
+	Consume(ctx context.Context, key string, cost, limit int, expireAt time.Time) (int, bool, error)
+	// Release subtracts cost from a counter, used when a request is rejected by another quota or fails
+	Release(ctx context.Context, key string, cost int) error
+	// Get retrieves the values of counters, missing counters are omitted
+	Get(ctx context.Context, keys []string) (map[string]int, error)
+	// Delete removes counters
+	Delete(ctx context.Context, keys []string) error
+}
+
+// ReservationRepository stores call cost reservations
+type ReservationRepository interface {
+	// Create inserts a held reservation
+	Create(ctx context.Context, reservation *model.CallReservation) error
+	// Transition atomically changes the status from one state to another, returns false if the status was not from
+	Transition(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error)
+	// FindExpired finds held reservations that expired before the given time and reservations whose refund failed
+	FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.CallReservation, error)
 }
 
 // CallLogRepository defines the interface for call log operations
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// nonceKeyPrefix Redis key prefix of request nonces
+const nonceKeyPrefix = "gw:nonce:"
+
+// MemoryNonceRepository keeps nonces in process memory.
+// Only suitable for a single gateway instance.
+type MemoryNonceRepository struct {
+	nonces map[string]time.Time // key -> 过期时间
+	mutex  sync.Mutex
+}
+
+// NewMemoryNonceRepository creates an in-memory nonce repository
+func NewMemoryNonceRepository() NonceRepository {
+	r := &MemoryNonceRepository{
+		nonces: make(map[string]time.Time),
+	}
+
+	// 启动清理协程，定期清理过期的 nonce
+	go r.cleanup()
+
+	return r
+}
+
+// Remember stores a nonce for the given TTL, returns false if it was already seen
+func (r *MemoryNonceRepository) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	if expiresAt, exists := r.nonces[key]; exists && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	r.nonces[key] = now.Add(ttl)
+	return true, nil
+}
+
+// cleanup removes expired nonces
+func (r *MemoryNonceRepository) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mutex.Lock()
+		now := time.Now()
+		for key, expiresAt := range r.nonces {
+			if now.After(expiresAt) {
+				delete(r.nonces, key)
+			}
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// RedisNonceRepository keeps nonces in Redis so that a request replayed
+// against another gateway instance is also rejected
+type RedisNonceRepository struct {
+	client *redis.Client
+}
+
+// NewRedisNonceRepository creates a Redis nonce repository
+func NewRedisNonceRepository(client *redis.Client) NonceRepository {
+	return &RedisNonceRepository{client: client}
+}
+
+// Remember stores a nonce for the given TTL, returns false if it was already seen
+func (r *RedisNonceRepository) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	stored, err := r.client.SetNX(ctx, nonceKeyPrefix+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return stored, nil
+}
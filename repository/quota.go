@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// quotaKeyPrefix Redis key prefix of quota counters
+const quotaKeyPrefix = "gw:quota:"
+
+// quotaCounter is a quota counter document
+type quotaCounter struct {
+	Key      string    `bson:"_id"`
+	Count    int       `bson:"count"`
+	ExpireAt time.Time `bson:"expire_at"`
+}
+
+// QuotaMongoRepository implements QuotaRepository using MongoDB, counters expire via a TTL index
+type QuotaMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewQuotaMongoRepository creates a new MongoDB quota counter repository
+func NewQuotaMongoRepository(collection *mongo.Collection) QuotaRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 窗口结束后自动删除计数
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expire_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return &QuotaMongoRepository{
+		collection: collection,
+	}
+}
+
+// Consume atomically adds cost to a counter if the result does not exceed limit
+func (r *QuotaMongoRepository) Consume(ctx context.Context, key string, cost, limit int, expireAt time.Time) (int, bool, error) {
+	if cost > limit {
+		counts, err := r.Get(ctx, []string{key})
+		return counts[key], false, err
+	}
+
+	// 计数超限时过滤条件不匹配，upsert 插入同一 _id 会触发唯一键冲突
+	filter := bson.M{
+		"_id":   key,
+		"count": bson.M{"$lte": limit - cost},
+	}
+	update := bson.M{
+		"$inc":         bson.M{"count": cost},
+		"$setOnInsert": bson.M{"expire_at": expireAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter quotaCounter
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			counts, err := r.Get(ctx, []string{key})
+			return counts[key], false, err
+		}
+		return 0, false, fmt.Errorf("failed to consume quota: %w", err)
+	}
+
+	return counter.Count, true, nil
+}
+
+// Release subtracts cost from a counter, the count does not go below 0
+func (r *QuotaMongoRepository) Release(ctx context.Context, key string, cost int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": key}, bson.A{
+		bson.M{"$set": bson.M{"count": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$count", cost}}}}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release quota: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the values of counters
+func (r *QuotaMongoRepository) Get(ctx context.Context, keys []string) (map[string]int, error) {
+	counts := make(map[string]int, len(keys))
+	if len(keys) == 0 {
+		return counts, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota counters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var counters []quotaCounter
+	if err := cursor.All(ctx, &counters); err != nil {
+		return nil, fmt.Errorf("failed to decode quota counters: %w", err)
+	}
+	for _, counter := range counters {
+		counts[counter.Key] = counter.Count
+	}
+
+	return counts, nil
+}
+
+// Delete removes counters
+func (r *QuotaMongoRepository) Delete(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}}); err != nil {
+		return fmt.Errorf("failed to delete quota counters: %w", err)
+	}
+	return nil
+}
+
+// quotaConsumeScript 计数不超过上限时增加并设置过期时间
+// KEYS[1]: 计数键，ARGV[1]: 消耗，ARGV[2]: 上限，ARGV[3]: 过期时间（Unix 秒）
+var quotaConsumeScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local cost = tonumber(ARGV[1])
+if current + cost > tonumber(ARGV[2]) then
+  return {current, 0}
+end
+current = redis.call('INCRBY', KEYS[1], cost)
+redis.call('EXPIREAT', KEYS[1], ARGV[3])
+return {current, 1}
+`)
+
+// quotaReleaseScript 计数存在时减少，最少减到0；DECRBY 保留原有的过期时间，窗口已结束的计数不会被重新创建
+// KEYS[1]: 计数键，ARGV[1]: 退回数量
+var quotaReleaseScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]))
+if not current or current <= 0 then
+  return 0
+end
+return redis.call('DECRBY', KEYS[1], math.min(current, tonumber(ARGV[1])))
+`)
+
+// RedisQuotaRepository implements QuotaRepository using Redis
+type RedisQuotaRepository struct {
+	client *redis.Client
+}
+
+// NewRedisQuotaRepository creates a Redis quota counter repository
+func NewRedisQuotaRepository(client *redis.Client) QuotaRepository {
+	return &RedisQuotaRepository{client: client}
+}
+
+// Consume atomically adds cost to a counter if the result does not exceed limit
+func (r *RedisQuotaRepository) Consume(ctx context.Context, key string, cost, limit int, expireAt time.Time) (int, bool, error) {
+	values, err := quotaConsumeScript.Run(ctx, r.client, []string{quotaKeyPrefix + key}, cost, limit, expireAt.Unix()).Int64Slice()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to consume quota: %w", err)
+	}
+	if len(values) != 2 {
+		return 0, false, fmt.Errorf("failed to consume quota: unexpected script result")
+	}
+	return int(values[0]), values[1] == 1, nil
+}
+
+// Release subtracts cost from a counter, a counter that no longer exists is left untouched
+func (r *RedisQuotaRepository) Release(ctx context.Context, key string, cost int) error {
+	if err := quotaReleaseScript.Run(ctx, r.client, []string{quotaKeyPrefix + key}, cost).Err(); err != nil {
+		return fmt.Errorf("failed to release quota: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the values of counters
+func (r *RedisQuotaRepository) Get(ctx context.Context, keys []string) (map[string]int, error) {
+	counts := make(map[string]int, len(keys))
+	if len(keys) == 0 {
+		return counts, nil
+	}
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = quotaKeyPrefix + key
+	}
+	values, err := r.client.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota counters: %w", err)
+	}
+
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(s); err == nil {
+			counts[keys[i]] = n
+		}
+	}
+
+	return counts, nil
+}
+
+// Delete removes counters
+func (r *RedisQuotaRepository) Delete(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = quotaKeyPrefix + key
+	}
+	if err := r.client.Del(ctx, redisKeys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete quota counters: %w", err)
+	}
+	return nil
+}
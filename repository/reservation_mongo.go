@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"api-gateway/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reservationRetention 已完成的预扣记录保留时间
+const reservationRetention = 7 * 24 * time.Hour
+
+// ReservationMongoRepository implements ReservationRepository using MongoDB
+type ReservationMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewReservationMongoRepository creates a new MongoDB call reservation repository
+func NewReservationMongoRepository(collection *mongo.Collection) ReservationRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		// 只清理已完成的记录，未确认的记录由清理任务退回后再过期
+		{
+			Keys: bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().
+				SetExpireAfterSeconds(int32(reservationRetention.Seconds())).
+				SetPartialFilterExpression(bson.M{"status": bson.M{"$in": []string{
+					model.ReservationCommitted, model.ReservationReleased, model.ReservationExpired,
+				}}}),
+		},
+	})
+
+	return &ReservationMongoRepository{
+		collection: collection,
+	}
+}
+
+// Create inserts a held reservation
+func (r *ReservationMongoRepository) Create(ctx context.Context, reservation *model.CallReservation) error {
+	result, err := r.collection.InsertOne(ctx, reservation)
+	if err != nil {
+		return fmt.Errorf("failed to create call reservation: %w", err)
+	}
+	reservation.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Transition atomically changes the status of a reservation from one state to another.
+// 返回 false 表示预扣已不在 from 状态（已被确认、退回或清理）
+func (r *ReservationMongoRepository) Transition(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+	filter := bson.M{"_id": id, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update call reservation: %w", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindExpired finds held reservations that expired before the given time and reservations whose refund failed
+func (r *ReservationMongoRepository) FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.CallReservation, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": model.ReservationHeld, "expires_at": bson.M{"$lt": before}},
+		{"status": model.ReservationRefundFailed},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired call reservations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*model.CallReservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, fmt.Errorf("failed to decode call reservations: %w", err)
+	}
+	return reservations, nil
+}
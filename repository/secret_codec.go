@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"api-gateway/pkg/secrets"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// secretCodec 负责API密钥哈希和签名密钥加解密
+// keyring 为空时签名密钥以明文存储（兼容未配置加密密钥的部署）
+type secretCodec struct {
+	keyring *secrets.Keyring
+}
+
+// sealedFields 存储用的密钥字段
+type sealedFields struct {
+	APIKeyHash      string
+	APIKeyPrefix    string
+	Secret          string // 未启用加密时的明文密钥
+	EncryptedSecret string
+}
+
+// seal 将明文API密钥和签名密钥转换为存储格式
+// apiKey 为空时保留已有的哈希；secret 为空时保留已有的密文
+func (sc secretCodec) seal(apiKey, secret string, existing sealedFields) (sealedFields, error) {
+	sealed := existing
+
+	if apiKey != "" {
+		sealed.APIKeyHash = secrets.HashAPIKey(apiKey)
+		sealed.APIKeyPrefix = secrets.KeyPrefix(apiKey)
+	}
+
+	if secret == "" {
+		return sealed, nil
+	}
+
+	if sc.keyring == nil {
+		sealed.Secret = secret
+		return sealed, nil
+	}
+
+	encrypted, err := sc.keyring.Encrypt(secret)
+	if err != nil {
+		return sealed, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	sealed.Secret = ""
+	sealed.EncryptedSecret = encrypted
+
+	return sealed, nil
+}
+
+// open 解密存储的签名密钥，未加密时原样返回明文
+func (sc secretCodec) open(secret, encryptedSecret string) (string, error) {
+	if encryptedSecret == "" {
+		return secret, nil
+	}
+
+	if sc.keyring == nil {
+		return "", fmt.Errorf("secret is encrypted but no encryption key is configured")
+	}
+
+	plaintext, err := sc.keyring.Decrypt(encryptedSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// needsMigration 判断存储的文档是否仍含明文密钥或使用旧版本密钥加密
+func (sc secretCodec) needsMigration(apiKey, secret, encryptedSecret string) bool {
+	if apiKey != "" {
+		return true
+	}
+	if sc.keyring == nil {
+		return false
+	}
+	if secret != "" {
+		return true
+	}
+	return encryptedSecret != "" && sc.keyring.NeedsReencrypt(encryptedSecret)
+}
+
+// apiKeyFilter 按哈希查找API密钥，同时兼容尚未迁移的明文文档
+func apiKeyFilter(apiKey string) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"api_key_hash": secrets.HashAPIKey(apiKey)},
+		{"api_key": apiKey},
+	}}
+}
+
+// apiKeyHashIndex api_key_hash 唯一索引，仅对已迁移的文档生效
+func apiKeyHashIndex() mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys: bson.D{{Key: "api_key_hash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+			"api_key_hash": bson.M{"$exists": true},
+		}),
+	}
+}
+
+// plaintextUnset 返回需要从文档中移除的明文字段
+func plaintextUnset(sealed sealedFields) bson.M {
+	unset := bson.M{}
+	if sealed.APIKeyHash != "" {
+		unset["api_key"] = ""
+	}
+	if sealed.EncryptedSecret != "" {
+		unset["secret"] = ""
+	}
+	return unset
+}
+
+// migrationSet 返回迁移时需要写入的密钥字段
+func migrationSet(sealed sealedFields) bson.M {
+	set := bson.M{"updated_at": time.Now()}
+	if sealed.APIKeyHash != "" {
+		set["api_key_hash"] = sealed.APIKeyHash
+		set["api_key_prefix"] = sealed.APIKeyPrefix
+	}
+	if sealed.EncryptedSecret != "" {
+		set["encrypted_secret"] = sealed.EncryptedSecret
+	}
+	return set
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// revokedTokenKeyPrefix Redis key prefix of revoked token IDs
+const revokedTokenKeyPrefix = "gw:oauth:revoked:"
+
+// MemoryTokenRevocationRepository keeps revoked token IDs in process memory.
+// Only suitable for a single gateway instance.
+type MemoryTokenRevocationRepository struct {
+	revoked map[string]time.Time // jti -> 令牌过期时间
+	mutex   sync.RWMutex
+}
+
+// NewMemoryTokenRevocationRepository creates an in-memory revocation repository
+func NewMemoryTokenRevocationRepository() TokenRevocationRepository {
+	return &MemoryTokenRevocationRepository{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke marks a token ID as revoked until its expiry time
+func (r *MemoryTokenRevocationRepository) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// 顺带清理已过期的记录，过期令牌本身已无法通过校验
+	now := time.Now()
+	for id, exp := range r.revoked {
+		if now.After(exp) {
+			delete(r.revoked, id)
+		}
+	}
+
+	r.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked reports whether a token ID has been revoked
+func (r *MemoryTokenRevocationRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, revoked := r.revoked[jti]
+	return revoked, nil
+}
+
+// RedisTokenRevocationRepository keeps revoked token IDs in Redis so that all
+// gateway instances share the same revocation list
+type RedisTokenRevocationRepository struct {
+	client *redis.Client
+}
+
+// NewRedisTokenRevocationRepository creates a Redis revocation repository
+func NewRedisTokenRevocationRepository(client *redis.Client) TokenRevocationRepository {
+	return &RedisTokenRevocationRepository{client: client}
+}
+
+// Revoke marks a token ID as revoked until its expiry time
+func (r *RedisTokenRevocationRepository) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := r.client.Set(ctx, revokedTokenKeyPrefix+jti, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether a token ID has been revoked
+func (r *RedisTokenRevocationRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := r.client.Exists(ctx, revokedTokenKeyPrefix+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check revocation status: %w", err)
+	}
+	return n > 0, nil
+}
@@ -2,51 +2,181 @@ package router
 
 import (
 	"api-gateway/config"
+	"api-gateway/database"
 	"api-gateway/handler"
 	"api-gateway/middleware"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
 	"api-gateway/pkg/metrics"
 	"api-gateway/pkg/queue"
+	"api-gateway/pkg/ratelimit"
+	"api-gateway/pkg/routescope"
 	"api-gateway/repository"
 	"api-gateway/service"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func SetupRouter(clientRepo repository.ClientRepository, callLogRepo repository.CallLogRepository,
-	taskRepo repository.TaskRepository, taskQueue queue.TaskQueue) *gin.Engine {
+func SetupRouter(dbManager *database.DatabaseManager, taskRepo repository.TaskRepository, taskQueue queue.TaskQueue) (*gin.Engine, error) {
 	gin.SetMode(gin.ReleaseMode) // 设置为 release 模式
 	r := gin.New()               // 不添加任何中间件
 	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
 
 	cfg := config.GetConfig()
 
+	// 只有来自可信代理的请求才使用转发头中的客户端 IP
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	if len(cfg.RemoteIPHeaders) > 0 {
+		r.RemoteIPHeaders = cfg.RemoteIPHeaders
+	}
+
 	metrics.InitMetrics()
 
+	clientRepo := dbManager.ClientRepo
+	callLogRepo := dbManager.CallLogRepo
+
 	timeWindow := time.Duration(cfg.Auth.SignatureTimeWindow) * time.Second
+	gracePeriod := time.Duration(cfg.Auth.KeyRotationGracePeriod) * time.Second
 
-	signatureValidator := middleware.NewHMACSignatureValidator(timeWindow)
-	authMiddleware := middleware.NewAuthMiddleware(clientRepo, signatureValidator, cfg)
-	rateLimitMiddleware := middleware.NewRateLimitMiddleware()
-	billingMiddleware := middleware.NewBillingMiddleware(clientRepo, callLogRepo)
+	// OAuth2 访问令牌，配置了 Redis 时吊销列表在实例间共享
+	var oauthService *service.OAuthService
+	if cfg.OAuth.Enabled {
+		revocationRepo := repository.NewMemoryTokenRevocationRepository()
+		if dbManager.Redis != nil {
+			revocationRepo = repository.NewRedisTokenRevocationRepository(dbManager.Redis)
+		}
+		keyResolver := service.NewKeyResolver(clientRepo, dbManager.CredentialRepo)
+
+		var err error
+		oauthService, err = service.NewOAuthService(keyResolver, revocationRepo, cfg.OAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize OAuth service: %w", err)
+		}
+	}
+
+	// 签名 nonce 防重放，多实例部署时需配置 Redis
+	var nonceRepo repository.NonceRepository
+	if dbManager.Redis != nil {
+		nonceRepo = repository.NewRedisNonceRepository(dbManager.Redis)
+	} else {
+		nonceRepo = repository.NewMemoryNonceRepository()
+	}
+
+	// 认证失败防护，多实例部署时需配置 Redis 共享封禁状态
+	var authGuard *service.AuthGuard
+	if cfg.Auth.BruteForce.Enabled {
+		var authGuardRepo repository.AuthGuardRepository
+		if dbManager.Redis != nil {
+			authGuardRepo = repository.NewRedisAuthGuardRepository(dbManager.Redis)
+		} else {
+			authGuardRepo = repository.NewMemoryAuthGuardRepository()
+		}
+		authGuard = service.NewAuthGuard(authGuardRepo, cfg.Auth.BruteForce)
+	}
+
+	if cfg.Auth.AllowMissingNonce {
+		logger.Info("Signature nonce is optional for legacy clients; requests without X-Nonce are not protected against replay")
+	}
+
+	// 按客户的 signature_type 选择 HMAC 或公钥签名验证
+	signatureValidator := middleware.NewClientSignatureValidator(
+		middleware.NewHMACSignatureValidator(timeWindow, nonceRepo, !cfg.Auth.AllowMissingNonce, cfg.Auth.MinSignatureVersion),
+		middleware.NewAsymmetricSignatureValidator(timeWindow, nonceRepo, !cfg.Auth.AllowMissingNonce),
+	)
+	authMiddleware := middleware.NewAuthMiddleware(clientRepo, dbManager.CredentialRepo, signatureValidator, oauthService, authGuard, cfg)
+	rateLimitMiddleware, err := newRateLimitMiddleware(dbManager, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// 按日、按月的调用配额
+	quotaService, err := service.NewQuotaService(dbManager.QuotaRepo, cfg.Quota)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quota config: %w", err)
+	}
+	quotaMiddleware := middleware.NewQuotaMiddleware(quotaService)
+	billingMiddleware, err := middleware.NewBillingMiddleware(clientRepo, callLogRepo,
+		service.NewBillingService(clientRepo, dbManager.ReservationRepo, cfg.Pricing), quotaService, cfg.Pricing)
+	if err != nil {
+		return nil, err
+	}
 	loggingMiddleware := middleware.NewLoggingMiddleware(callLogRepo)
 	prometheusMiddleware := middleware.NewPrometheusMiddleware()
 	asyncMiddleware := middleware.NewAsyncMiddleware(taskQueue, taskRepo, cfg)
 
+	// 浏览器跨域访问
+	var corsMiddleware *middleware.CORSMiddleware
+	if cfg.CORS.Enabled {
+		var err error
+		corsMiddleware, err = middleware.NewCORSMiddleware(clientRepo, cfg.CORS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 路由授权目录，任务查询接口默认不做授权检查
+	exemptRoutes := cfg.Access.ExemptRoutes
+	if exemptRoutes == nil {
+		exemptRoutes = []string{"/api/tasks*"}
+	}
+	catalog, err := routescope.NewCatalog(cfg.Access.Products, exemptRoutes, cfg.Access.DefaultDeny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access config: %w", err)
+	}
+	// 访问令牌的 scope 除 api 外必须是产品名或路由模式
+	if oauthService != nil {
+		for _, scope := range cfg.OAuth.Scopes {
+			if scope == service.OAuthScopeAll {
+				continue
+			}
+			if err := catalog.Validate(scope); err != nil {
+				return nil, fmt.Errorf("invalid OAuth config: %w", err)
+			}
+		}
+	}
+	scopeMiddleware := middleware.NewScopeMiddleware(catalog)
+
 	clientService := service.NewClientService(clientRepo, callLogRepo)
+	credentialService := service.NewCredentialService(clientRepo, dbManager.CredentialRepo, gracePeriod)
+	scopeService := service.NewScopeService(clientRepo, catalog)
+	auditService := service.NewAuditService(dbManager.AuditLogRepo)
+	quotaHandler := handler.NewQuotaHandler(clientService, quotaService, auditService)
+	limiterHandler := handler.NewLimiterHandler(clientService, rateLimitMiddleware, quotaService, auditService)
 
 	proxyHandler := handler.NewProxyHandler()
-	adminHandler := handler.NewAdminHandler(clientService)
+	adminHandler := handler.NewAdminHandler(clientService, credentialService, scopeService, auditService)
 	taskHandler := handler.NewTaskHandler(taskRepo)
 
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// OAuth2 客户端凭证模式
+	if oauthService != nil {
+		oauthHandler := handler.NewOAuthHandler(oauthService, authGuard)
+		oauth := r.Group("/oauth")
+		{
+			oauth.POST("/token", oauthHandler.Token)
+			oauth.POST("/revoke", oauthHandler.Revoke)
+			oauth.POST("/introspect", oauthHandler.Introspect)
+		}
+	}
+
 	// 测试接口
 	r.POST("/test/callback", proxyHandler.CallbackHandler)
 
 	api := r.Group("/api")
 	{
+		// 跨域预检请求在认证之前返回，不计费
+		if corsMiddleware != nil {
+			api.Use(corsMiddleware.Handle())
+			api.OPTIONS("/*path", corsMiddleware.Options())
+		}
+
 		api.GET("/health", func(c *gin.Context) {
 			c.JSON(200, gin.H{
 				"status":  "ok",
@@ -54,15 +184,20 @@ func SetupRouter(clientRepo repository.ClientRepository, callLogRepo repository.
 			})
 		})
 
-		api.Use(authMiddleware.Authenticate())   // 1. 认证
-		api.Use(rateLimitMiddleware.RateLimit()) // 2. 限流
-		api.Use(billingMiddleware.CheckCalls())  // 3. 检查次数
-		api.Use(billingMiddleware.DeductCalls()) // 4. 扣减次数（异步请求也要先扣费）
-		api.Use(loggingMiddleware.LogAPICall())  // 5. 记录日志
+		api.Use(authMiddleware.Authenticate()) // 1. 认证
+		if corsMiddleware != nil {
+			api.Use(corsMiddleware.CheckOrigin()) // 浏览器请求来源检查
+		}
+		api.Use(scopeMiddleware.Authorize())     // 2. 路由授权
+		api.Use(rateLimitMiddleware.RateLimit()) // 3. 限流
+		api.Use(billingMiddleware.CheckCalls())  // 4. 检查次数
+		api.Use(quotaMiddleware.CheckQuota())    // 5. 调用配额
+		api.Use(billingMiddleware.DeductCalls()) // 6. 扣减次数（异步请求也要先扣费）
+		api.Use(loggingMiddleware.LogAPICall())  // 7. 记录日志
 		if asyncMiddleware != nil {
-			api.Use(asyncMiddleware.HandleAsync()) // 6. 异步处理（异步请求在这里提前返回）
+			api.Use(asyncMiddleware.HandleAsync()) // 8. 异步处理（异步请求在这里提前返回）
 		}
-		api.Use(prometheusMiddleware.Monitor()) // 7. Prometheus 监控
+		api.Use(prometheusMiddleware.Monitor()) // 9. Prometheus 监控
 
 		// 业务接口
 		api.POST("/essay/evaluate/stream", proxyHandler.ProxyRequest)
@@ -79,19 +214,106 @@ func SetupRouter(clientRepo repository.ClientRepository, callLogRepo repository.
 		api.GET("/tasks", taskHandler.ListTasks)
 	}
 
+	// 管理接口认证，初始管理员来自配置文件
+	adminUserService := service.NewAdminUserService(dbManager.AdminUserRepo)
+	bootstrapCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := adminUserService.Bootstrap(bootstrapCtx, cfg.Admin.Bootstrap); err != nil {
+		return nil, fmt.Errorf("failed to bootstrap admin users: %w", err)
+	}
+	adminAuth := middleware.NewAdminAuthMiddleware(adminUserService)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService, auditService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	can := adminAuth.RequirePermission
+
 	admin := r.Group("/admin")
+	admin.Use(adminAuth.Authenticate())
 	{
-		admin.POST("/clients", adminHandler.CreateClient)
-		admin.GET("/clients", adminHandler.ListClients)
-		admin.GET("/clients/:id", adminHandler.GetClient)
-		admin.PUT("/clients/:id/status", adminHandler.UpdateClientStatus)
-		admin.PUT("/clients/:id/qps", adminHandler.UpdateClientQPS)
+		admin.POST("/clients", can(model.PermClientsWrite), adminHandler.CreateClient)
+		admin.GET("/clients", can(model.PermClientsRead), adminHandler.ListClients)
+		admin.GET("/clients/:id", can(model.PermClientsRead), adminHandler.GetClient)
+		admin.PUT("/clients/:id/status", can(model.PermClientsStatus), adminHandler.UpdateClientStatus)
+		admin.PUT("/clients/:id/qps", can(model.PermClientsWrite), adminHandler.UpdateClientQPS)
+		admin.PUT("/clients/:id/plan", can(model.PermClientsWrite), adminHandler.UpdateClientPlan)
+		admin.GET("/clients/:id/ip-rules", can(model.PermClientsRead), adminHandler.GetClientIPRules)
+		admin.PUT("/clients/:id/ip-rules", can(model.PermClientsWrite), adminHandler.UpdateClientIPRules)
+		admin.GET("/clients/:id/cors", can(model.PermClientsRead), adminHandler.GetClientCORS)
+		admin.PUT("/clients/:id/cors", can(model.PermClientsWrite), adminHandler.UpdateClientCORS)
+		admin.GET("/clients/:id/rate-limits", can(model.PermClientsRead), adminHandler.GetClientRateLimits)
+		admin.PUT("/clients/:id/rate-limits", can(model.PermClientsWrite), adminHandler.UpdateClientRateLimits)
+		admin.GET("/clients/:id/quotas", can(model.PermClientsRead), quotaHandler.GetClientQuotas)
+		admin.PUT("/clients/:id/quotas", can(model.PermClientsWrite), quotaHandler.UpdateClientQuotas)
+		admin.GET("/clients/:id/limiter", can(model.PermClientsRead), limiterHandler.GetClientLimiter)
+		admin.DELETE("/clients/:id/limiter", can(model.PermClientsWrite), limiterHandler.ResetClientLimiter)
+		admin.PUT("/clients/:id/temporary-limit", can(model.PermClientsWrite), limiterHandler.UpdateTemporaryLimit)
+		admin.DELETE("/clients/:id/temporary-limit", can(model.PermClientsWrite), limiterHandler.DeleteTemporaryLimit)
+		admin.PUT("/clients/:id/validity", can(model.PermClientsWrite), adminHandler.UpdateClientValidity)
+
+		// 路由授权
+		admin.POST("/clients/:id/scopes", can(model.PermClientsWrite), adminHandler.GrantScopes)
+		admin.DELETE("/clients/:id/scopes", can(model.PermClientsWrite), adminHandler.RevokeScopes)
+
+		admin.POST("/clients/:id/recharge", can(model.PermBillingWrite), adminHandler.RechargeClient)
 
-		admin.POST("/clients/:id/recharge", adminHandler.RechargeClient)
+		// 多密钥管理
+		admin.GET("/clients/:id/keys", can(model.PermKeysRead), adminHandler.ListCredentials)
+		admin.POST("/clients/:id/keys", can(model.PermKeysManage), adminHandler.IssueCredential)
+		admin.POST("/clients/:id/keys/:key_id/rotate", can(model.PermKeysManage), adminHandler.RotateCredential)
+		admin.DELETE("/clients/:id/keys/:key_id", can(model.PermKeysManage), adminHandler.RevokeCredential)
 
-		admin.GET("/clients/:id/logs", adminHandler.GetClientCallLogs)
-		admin.GET("/stats", adminHandler.GetStats)
+		// 公钥签名
+		admin.GET("/clients/:id/public-keys", can(model.PermKeysRead), adminHandler.ListPublicKeys)
+		admin.POST("/clients/:id/public-keys", can(model.PermKeysManage), adminHandler.RegisterPublicKey)
+		admin.DELETE("/clients/:id/public-keys/:key_id", can(model.PermKeysManage), adminHandler.RemovePublicKey)
+		admin.PUT("/clients/:id/signature-type", can(model.PermKeysManage), adminHandler.UpdateSignatureType)
+
+		admin.GET("/clients/:id/logs", can(model.PermLogsRead), adminHandler.GetClientCallLogs)
+		admin.GET("/stats", can(model.PermStatsRead), adminHandler.GetStats)
+
+		// 审计日志
+		admin.GET("/audit-logs", can(model.PermAuditRead), auditHandler.ListAuditLogs)
+		admin.GET("/audit-logs/export", can(model.PermAuditRead), auditHandler.ExportAuditLogs)
+
+		// 认证失败封禁
+		if authGuard != nil {
+			authBanHandler := handler.NewAuthBanHandler(authGuard, auditService)
+			admin.GET("/bans", can(model.PermBansManage), authBanHandler.ListBans)
+			admin.DELETE("/bans/:scope/:subject", can(model.PermBansManage), authBanHandler.Unban)
+		}
+
+		// 管理员账号
+		admin.GET("/me", adminUserHandler.Me)
+		admin.GET("/users", can(model.PermAdminsManage), adminUserHandler.ListUsers)
+		admin.POST("/users", can(model.PermAdminsManage), adminUserHandler.CreateUser)
+		admin.PUT("/users/:id/role", can(model.PermAdminsManage), adminUserHandler.UpdateRole)
+		admin.PUT("/users/:id/status", can(model.PermAdminsManage), adminUserHandler.UpdateStatus)
+		admin.POST("/users/:id/token", can(model.PermAdminsManage), adminUserHandler.RotateToken)
+	}
+
+	return r, nil
+}
+
+// newRateLimitMiddleware 创建限流中间件，backend 为 redis 时多个实例共享限流状态
+func newRateLimitMiddleware(dbManager *database.DatabaseManager, cfg *config.Config) (*middleware.RateLimitMiddleware, error) {
+	var limiter ratelimit.Limiter
+	switch cfg.RateLimit.Backend {
+	case "", "local":
+	case "redis":
+		if dbManager.Redis == nil {
+			return nil, fmt.Errorf("invalid rate limit config: redis backend requires redis.addr")
+		}
+		redisLimiter, err := ratelimit.NewRedisLimiter(dbManager.Redis, cfg.RateLimit.Algorithm, cfg.RateLimit.KeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit config: %w", err)
+		}
+		limiter = redisLimiter
+	default:
+		return nil, fmt.Errorf("invalid rate limit config: unsupported backend %s", cfg.RateLimit.Backend)
 	}
 
-	return r
+	rateLimitMiddleware, err := middleware.NewRateLimitMiddleware(limiter, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit config: %w", err)
+	}
+	return rateLimitMiddleware, nil
 }
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/secrets"
+	"api-gateway/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minAdminPasswordLength 管理员密码最小长度
+const minAdminPasswordLength = 12
+
+// adminLoginUpdateInterval 最后登录时间的最小更新间隔
+const adminLoginUpdateInterval = time.Minute
+
+// AdminUserService provides authentication and management of admin users
+type AdminUserService struct {
+	adminUserRepo repository.AdminUserRepository
+}
+
+// NewAdminUserService creates a new admin user service
+func NewAdminUserService(adminUserRepo repository.AdminUserRepository) *AdminUserService {
+	return &AdminUserService{
+		adminUserRepo: adminUserRepo,
+	}
+}
+
+// Authenticate verifies a username and password
+func (s *AdminUserService) Authenticate(ctx context.Context, username, password string) (*model.AdminUser, error) {
+	user, err := s.adminUserRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("invalid admin credentials")
+		}
+		return nil, err
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, fmt.Errorf("invalid admin credentials")
+	}
+
+	return s.checkActive(user)
+}
+
+// AuthenticateToken verifies an admin access token
+func (s *AdminUserService) AuthenticateToken(ctx context.Context, token string) (*model.AdminUser, error) {
+	user, err := s.adminUserRepo.GetByTokenHash(ctx, secrets.HashAPIKey(token))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("invalid admin credentials")
+		}
+		return nil, err
+	}
+
+	return s.checkActive(user)
+}
+
+// CreateUser creates an admin user and issues an access token, which is only returned once
+func (s *AdminUserService) CreateUser(ctx context.Context, username, password, role string) (*model.AdminUser, string, error) {
+	if !model.IsValidAdminRole(role) {
+		return nil, "", fmt.Errorf("invalid role: %s", role)
+	}
+
+	user := &model.AdminUser{
+		Username: username,
+		Role:     role,
+		Status:   model.AdminUserStatusActive,
+	}
+	if password != "" {
+		if err := setAdminPassword(user, password); err != nil {
+			return nil, "", err
+		}
+	}
+
+	token, err := generateAdminToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate admin token: %w", err)
+	}
+	setAdminToken(user, token)
+
+	if err := s.adminUserRepo.Create(ctx, user); err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+// ListUsers retrieves all admin users
+func (s *AdminUserService) ListUsers(ctx context.Context) ([]*model.AdminUser, error) {
+	return s.adminUserRepo.List(ctx)
+}
+
+// UpdateRole changes the role of an admin user
+func (s *AdminUserService) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) (*model.AdminUser, error) {
+	if !model.IsValidAdminRole(role) {
+		return nil, fmt.Errorf("invalid role: %s", role)
+	}
+
+	user, err := s.adminUserRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	if err := s.adminUserRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateStatus enables or disables an admin user
+func (s *AdminUserService) UpdateStatus(ctx context.Context, id primitive.ObjectID, status int) (*model.AdminUser, error) {
+	user, err := s.adminUserRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Status = status
+	if err := s.adminUserRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RotateToken issues a new access token for an admin user, invalidating the old one
+func (s *AdminUserService) RotateToken(ctx context.Context, id primitive.ObjectID) (*model.AdminUser, string, error) {
+	user, err := s.adminUserRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := generateAdminToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate admin token: %w", err)
+	}
+	setAdminToken(user, token)
+
+	if err := s.adminUserRepo.Update(ctx, user); err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+// Bootstrap creates the admin users configured in the config file if they do not exist yet.
+// Existing users are left untouched so that passwords changed later are not reset.
+func (s *AdminUserService) Bootstrap(ctx context.Context, users []config.AdminBootstrapUser) error {
+	for _, bootstrap := range users {
+		if bootstrap.Username == "" {
+			return fmt.Errorf("bootstrap admin user without username")
+		}
+		if bootstrap.Password == "" && bootstrap.Token == "" {
+			return fmt.Errorf("bootstrap admin user %s has neither password nor token", bootstrap.Username)
+		}
+
+		role := bootstrap.Role
+		if role == "" {
+			role = model.AdminRoleSuperAdmin
+		}
+		if !model.IsValidAdminRole(role) {
+			return fmt.Errorf("bootstrap admin user %s: invalid role: %s", bootstrap.Username, role)
+		}
+
+		if _, err := s.adminUserRepo.GetByUsername(ctx, bootstrap.Username); err == nil {
+			continue
+		} else if !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+
+		user := &model.AdminUser{
+			Username: bootstrap.Username,
+			Role:     role,
+			Status:   model.AdminUserStatusActive,
+		}
+		if bootstrap.Password != "" {
+			if err := setAdminPassword(user, bootstrap.Password); err != nil {
+				return fmt.Errorf("bootstrap admin user %s: %w", bootstrap.Username, err)
+			}
+		}
+		if bootstrap.Token != "" {
+			setAdminToken(user, bootstrap.Token)
+		}
+
+		if err := s.adminUserRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create bootstrap admin user %s: %w", bootstrap.Username, err)
+		}
+		logger.Infof("Created bootstrap admin user %s with role %s", user.Username, user.Role)
+	}
+
+	return nil
+}
+
+// checkActive rejects disabled users and records the login time
+func (s *AdminUserService) checkActive(user *model.AdminUser) (*model.AdminUser, error) {
+	if !user.IsActive() {
+		return nil, fmt.Errorf("admin user is disabled")
+	}
+
+	now := time.Now()
+	if user.LastLoginAt == nil || now.Sub(*user.LastLoginAt) >= adminLoginUpdateInterval {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := s.adminUserRepo.UpdateLastLogin(ctx, user.ID, now); err != nil {
+				logger.Errorf("Failed to update last login time of admin user %s: %v", user.Username, err)
+			}
+		}()
+	}
+
+	return user, nil
+}
+
+// setAdminPassword hashes and sets the password of an admin user
+func setAdminPassword(user *model.AdminUser, password string) error {
+	if len(password) < minAdminPasswordLength {
+		return fmt.Errorf("invalid password: must be at least %d characters", minAdminPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = string(hash)
+	return nil
+}
+
+// setAdminToken stores the hash and display prefix of an admin token
+func setAdminToken(user *model.AdminUser, token string) {
+	user.TokenHash = secrets.HashAPIKey(token)
+	user.TokenPrefix = secrets.KeyPrefix(token)
+}
+
+// generateAdminToken generates a random admin access token
+func generateAdminToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "adm_" + hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"time"
+)
+
+// AuditService records and queries administrative actions
+type AuditService struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditLogRepo repository.AuditLogRepository) *AuditService {
+	return &AuditService{
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// Record appends an audit log entry. The action has already been applied at
+// this point, so failures are logged instead of being returned to the caller.
+func (s *AuditService) Record(entry *model.AuditLog) {
+	// 不使用请求上下文，避免调用方断开连接导致审计日志丢失
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.auditLogRepo.Create(ctx, entry); err != nil {
+		logger.Errorf("Failed to record audit log %s by %s (request %s): %v",
+			entry.Action, entry.Actor, entry.RequestID, err)
+	}
+}
+
+// Find retrieves audit logs matching the filter and the total count
+func (s *AuditService) Find(ctx context.Context, filter repository.AuditLogFilter, offset, limit int) ([]*model.AuditLog, int64, error) {
+	logs, err := s.auditLogRepo.Find(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.auditLogRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// Export calls fn for every audit log matching the filter in chronological order
+func (s *AuditService) Export(ctx context.Context, filter repository.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	return s.auditLogRepo.Iterate(ctx, filter, fn)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/metrics"
+	"api-gateway/pkg/secrets"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuthGuard counts failed authentications per client IP and per presented API
+// key, and bans a subject temporarily after too many failures. Keys are tracked
+// by the hash of the full key, so a public key prefix cannot be used to get
+// another client's key banned.
+type AuthGuard struct {
+	repo           repository.AuthGuardRepository
+	maxFailures    int64
+	window         time.Duration
+	banDuration    time.Duration
+	maxBanDuration time.Duration
+	strikeTTL      time.Duration
+}
+
+// NewAuthGuard creates an auth guard, unset config values fall back to defaults
+func NewAuthGuard(repo repository.AuthGuardRepository, cfg config.BruteForceConfig) *AuthGuard {
+	g := &AuthGuard{
+		repo:           repo,
+		maxFailures:    int64(cfg.MaxFailures),
+		window:         time.Duration(cfg.Window) * time.Second,
+		banDuration:    time.Duration(cfg.BanDuration) * time.Second,
+		maxBanDuration: time.Duration(cfg.MaxBanDuration) * time.Second,
+		strikeTTL:      time.Duration(cfg.StrikeTTL) * time.Second,
+	}
+	if g.maxFailures <= 0 {
+		g.maxFailures = 10
+	}
+	if g.window <= 0 {
+		g.window = time.Minute
+	}
+	if g.banDuration <= 0 {
+		g.banDuration = time.Minute
+	}
+	if g.maxBanDuration <= 0 {
+		g.maxBanDuration = 24 * time.Hour
+	}
+	if g.maxBanDuration < g.banDuration {
+		g.maxBanDuration = g.banDuration
+	}
+	if g.strikeTTL <= 0 {
+		g.strikeTTL = 24 * time.Hour
+	}
+	return g
+}
+
+// Check returns the active ban of the client IP or the API key, nil if neither is banned.
+// apiKey 为空时只检查IP
+func (g *AuthGuard) Check(ctx context.Context, ip, apiKey string) (*model.AuthBan, error) {
+	for _, subject := range guardSubjects(ip, apiKey) {
+		ban, err := g.repo.GetBan(ctx, model.AuthBanKey(subject.scope, subject.value))
+		if err != nil {
+			return nil, err
+		}
+		if ban != nil {
+			metrics.GetMetrics().AuthBanRejects.WithLabelValues(ban.Scope).Inc()
+			return ban, nil
+		}
+	}
+	return nil, nil
+}
+
+// RecordFailure counts a failed authentication against the client IP and the
+// API key, and bans whichever reaches the threshold
+func (g *AuthGuard) RecordFailure(ctx context.Context, ip, apiKey, reason string) {
+	metrics.GetMetrics().AuthFailures.WithLabelValues(reason).Inc()
+
+	for _, subject := range guardSubjects(ip, apiKey) {
+		if err := g.recordSubjectFailure(ctx, subject); err != nil {
+			// 防护存储不可用时不影响正常认证
+			logger.Errorf("Failed to record auth failure of %s %s: %v", subject.scope, subject.label(), err)
+		}
+	}
+}
+
+// ListBans retrieves all active bans
+func (g *AuthGuard) ListBans(ctx context.Context) ([]*model.AuthBan, error) {
+	return g.repo.ListBans(ctx)
+}
+
+// Unban lifts an active ban and clears its failure counter
+func (g *AuthGuard) Unban(ctx context.Context, scope, subject string) error {
+	if !model.IsValidAuthBanScope(scope) {
+		return fmt.Errorf("invalid ban scope: %s", scope)
+	}
+
+	key := model.AuthBanKey(scope, subject)
+	deleted, err := g.repo.DeleteBan(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("ban not found")
+	}
+
+	return g.repo.ResetFailures(ctx, key)
+}
+
+// recordSubjectFailure increments the failure counter of one subject and bans it at the threshold
+func (g *AuthGuard) recordSubjectFailure(ctx context.Context, subject guardSubject) error {
+	key := model.AuthBanKey(subject.scope, subject.value)
+
+	failures, err := g.repo.IncrFailures(ctx, key, g.window)
+	if err != nil {
+		return err
+	}
+	if failures < g.maxFailures {
+		return nil
+	}
+
+	strikes, err := g.repo.IncrStrikes(ctx, key, g.strikeTTL)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	duration := g.banDurationFor(strikes)
+	ban := &model.AuthBan{
+		Scope:     subject.scope,
+		Subject:   subject.value,
+		KeyPrefix: subject.keyPrefix,
+		Strikes:   int(strikes),
+		Failures:  failures,
+		BannedAt:  now,
+		ExpiresAt: now.Add(duration),
+	}
+	if err := g.repo.SaveBan(ctx, ban); err != nil {
+		return err
+	}
+	if err := g.repo.ResetFailures(ctx, key); err != nil {
+		return err
+	}
+
+	metrics.GetMetrics().AuthBans.WithLabelValues(subject.scope).Inc()
+	logger.Infof("Banned %s %s for %s after %d authentication failures (strike %d)",
+		subject.scope, subject.label(), duration, failures, strikes)
+	return nil
+}
+
+// banDurationFor 封禁时长随封禁次数指数增长：base * 2^(strikes-1)，不超过上限
+func (g *AuthGuard) banDurationFor(strikes int64) time.Duration {
+	duration := g.banDuration
+	for i := int64(1); i < strikes; i++ {
+		duration *= 2
+		if duration >= g.maxBanDuration {
+			return g.maxBanDuration
+		}
+	}
+	return duration
+}
+
+type guardSubject struct {
+	scope     string
+	value     string
+	keyPrefix string // 密钥封禁的展示前缀
+}
+
+// label 返回日志中使用的封禁对象，密钥只记录前缀
+func (s guardSubject) label() string {
+	if s.keyPrefix != "" {
+		return s.keyPrefix
+	}
+	return s.value
+}
+
+func guardSubjects(ip, apiKey string) []guardSubject {
+	subjects := make([]guardSubject, 0, 2)
+	if ip != "" {
+		subjects = append(subjects, guardSubject{scope: model.AuthBanScopeIP, value: ip})
+	}
+	if apiKey != "" {
+		subjects = append(subjects, guardSubject{
+			scope:     model.AuthBanScopeKey,
+			value:     secrets.HashAPIKey(apiKey),
+			keyPrefix: secrets.KeyPrefix(apiKey),
+		})
+	}
+	return subjects
+}
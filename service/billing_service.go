@@ -0,0 +1,197 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 预扣记录默认有效期和每次清理的最大数量
+const (
+	defaultReservationTTL = 10 * time.Minute
+	reservationSweepBatch = 100
+)
+
+// ErrInsufficientCalls is returned by Reserve when the client balance is lower than the cost
+var ErrInsufficientCalls = repository.ErrInsufficientCalls
+
+// BillingService holds the cost of a call from the client balance before proxying and settles it afterwards.
+// 预扣时原子扣减余额，调用成功时确认，失败或超时未确认时退回；状态转换保证每笔预扣只结算一次
+type BillingService struct {
+	clientRepo      repository.ClientRepository
+	reservationRepo repository.ReservationRepository
+	ttl             time.Duration
+}
+
+// NewBillingService creates a billing service, unset config values fall back to defaults
+func NewBillingService(clientRepo repository.ClientRepository, reservationRepo repository.ReservationRepository, cfg config.PricingConfig) *BillingService {
+	ttl := time.Duration(cfg.ReservationTTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultReservationTTL
+	}
+
+	return &BillingService{
+		clientRepo:      clientRepo,
+		reservationRepo: reservationRepo,
+		ttl:             ttl,
+	}
+}
+
+// Reserve atomically deducts the cost from the client balance and records a held reservation.
+// 返回预扣后的剩余次数；余额不足时返回 ErrInsufficientCalls
+func (s *BillingService) Reserve(ctx context.Context, client *model.Client, route string, cost int) (*model.CallReservation, int, error) {
+	remaining, err := s.clientRepo.DeductCallCount(ctx, client.ID, cost)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	reservation := &model.CallReservation{
+		ClientID:  client.ID,
+		Route:     route,
+		Cost:      cost,
+		Status:    model.ReservationHeld,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.reservationRepo.Create(ctx, reservation); err != nil {
+		// 没有预扣记录就无法结算，立即退回
+		if refundErr := s.clientRepo.RefundCallCount(ctx, client.ID, cost); refundErr != nil {
+			logger.Errorf("Failed to refund %d calls to client %s: %v", cost, client.ID.Hex(), refundErr)
+		}
+		return nil, 0, err
+	}
+
+	return reservation, remaining, nil
+}
+
+// Commit settles a held reservation as consumed.
+// 耗时超过有效期的调用，预扣可能已被清理任务退回，此时重新扣费，避免长时间的调用免费
+func (s *BillingService) Commit(ctx context.Context, reservation *model.CallReservation) error {
+	committed, err := s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationHeld, model.ReservationCommitted)
+	if err != nil {
+		return err
+	}
+	if !committed {
+		// 退回失败的预扣仍在余额中扣着，直接确认
+		committed, err = s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationRefundFailed, model.ReservationCommitted)
+		if err != nil {
+			return err
+		}
+	}
+	if committed {
+		reservation.Status = model.ReservationCommitted
+		return nil
+	}
+
+	return s.recharge(ctx, reservation)
+}
+
+// recharge 预扣已被清理任务退回时重新扣费。
+// 先扣费再将预扣转为 committed，转换失败时退回本次扣费；余额不足时转为 unpaid，留待对账
+func (s *BillingService) recharge(ctx context.Context, reservation *model.CallReservation) error {
+	if _, err := s.clientRepo.DeductCallCount(ctx, reservation.ClientID, reservation.Cost); err != nil {
+		if errors.Is(err, ErrInsufficientCalls) {
+			unpaid, transitionErr := s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationExpired, model.ReservationUnpaid)
+			if transitionErr != nil {
+				logger.Errorf("Failed to mark call reservation %s as unpaid: %v", reservation.ID.Hex(), transitionErr)
+			} else if unpaid {
+				reservation.Status = model.ReservationUnpaid
+			}
+		}
+		return fmt.Errorf("reservation %s expired before commit and could not be charged again: %w", reservation.ID.Hex(), err)
+	}
+
+	recharged, err := s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationExpired, model.ReservationCommitted)
+	if err != nil || !recharged {
+		// 预扣不是已退回的状态，本次扣费不应生效
+		if refundErr := s.clientRepo.RefundCallCount(ctx, reservation.ClientID, reservation.Cost); refundErr != nil {
+			logger.Errorf("Failed to refund %d calls charged again for reservation %s to client %s: %v",
+				reservation.Cost, reservation.ID.Hex(), reservation.ClientID.Hex(), refundErr)
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("reservation %s is no longer held", reservation.ID.Hex())
+	}
+	reservation.Status = model.ReservationCommitted
+
+	logger.Infof("Call reservation %s of client %s expired before commit, charged %d calls again",
+		reservation.ID.Hex(), reservation.ClientID.Hex(), reservation.Cost)
+	return nil
+}
+
+// Release returns the cost of a held reservation to the client balance
+func (s *BillingService) Release(ctx context.Context, reservation *model.CallReservation) error {
+	_, err := s.refund(ctx, reservation, model.ReservationReleased)
+	return err
+}
+
+// ExpireReservations refunds held reservations that were not settled in time, such as after a crash,
+// and retries failed refunds. 返回退回的预扣数量
+func (s *BillingService) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		reservations, err := s.reservationRepo.FindExpired(ctx, now, reservationSweepBatch)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, reservation := range reservations {
+			// 之前退回失败的预扣重试退回
+			refunded, err := s.refundFrom(ctx, reservation, reservation.Status, model.ReservationExpired)
+			if err != nil {
+				return expired, err
+			}
+			if refunded {
+				logger.Infof("Call reservation %s of client %s expired, refunded %d calls",
+					reservation.ID.Hex(), reservation.ClientID.Hex(), reservation.Cost)
+				expired++
+			}
+		}
+
+		if len(reservations) < reservationSweepBatch {
+			return expired, nil
+		}
+	}
+}
+
+// refund 将预扣从 held 转为 refunding 后退回余额，完成后转为 status；预扣已被结算时返回 false
+func (s *BillingService) refund(ctx context.Context, reservation *model.CallReservation, status string) (bool, error) {
+	return s.refundFrom(ctx, reservation, model.ReservationHeld, status)
+}
+
+// refundFrom 转为 refunding 的一方独占退回，不会重复退回。
+// 退回失败时转为 refund_failed，由清理任务重试
+func (s *BillingService) refundFrom(ctx context.Context, reservation *model.CallReservation, from, status string) (bool, error) {
+	claimed, err := s.reservationRepo.Transition(ctx, reservation.ID, from, model.ReservationRefunding)
+	if err != nil || !claimed {
+		return false, err
+	}
+	reservation.Status = model.ReservationRefunding
+
+	if err := s.clientRepo.RefundCallCount(ctx, reservation.ClientID, reservation.Cost); err != nil {
+		logger.Errorf("Failed to refund %d calls of reservation %s to client %s: %v",
+			reservation.Cost, reservation.ID.Hex(), reservation.ClientID.Hex(), err)
+		if _, transitionErr := s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationRefunding, model.ReservationRefundFailed); transitionErr != nil {
+			logger.Errorf("Failed to mark call reservation %s for refund retry: %v", reservation.ID.Hex(), transitionErr)
+		} else {
+			reservation.Status = model.ReservationRefundFailed
+		}
+		return false, err
+	}
+
+	if _, err := s.reservationRepo.Transition(ctx, reservation.ID, model.ReservationRefunding, status); err != nil {
+		// 已退回，停留在 refunding 不会被重试；记录下来以便人工核对
+		logger.Errorf("Refunded call reservation %s but failed to mark it %s: %v", reservation.ID.Hex(), status, err)
+		return true, nil
+	}
+	reservation.Status = status
+	return true, nil
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	config := logger.DefaultConfig("service-test")
+	config.Level = "error"
+	logger.InitWithConfig(config)
+	os.Exit(m.Run())
+}
+
+func TestReserveConcurrentDoesNotOverdraw(t *testing.T) {
+	const balance, requests = 10, 50
+	billing, clients, _ := newTestBilling(t, balance)
+	client := clients.client
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		reservations []*model.CallReservation
+		insufficient int
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, _, err := billing.Reserve(context.Background(), client, "/api/v1/test", 1)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				reservations = append(reservations, reservation)
+			case errors.Is(err, ErrInsufficientCalls):
+				insufficient++
+			default:
+				t.Errorf("Reserve() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(reservations) != balance {
+		t.Fatalf("reserved %d times, want %d", len(reservations), balance)
+	}
+	if insufficient != requests-balance {
+		t.Errorf("insufficient calls %d times, want %d", insufficient, requests-balance)
+	}
+	if got := clients.balance(); got != 0 {
+		t.Errorf("balance = %d, want 0", got)
+	}
+
+	// 并发退回（每笔重复退回一次）后余额恰好恢复
+	for _, reservation := range reservations {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(reservation model.CallReservation) {
+				defer wg.Done()
+				if err := billing.Release(context.Background(), &reservation); err != nil {
+					t.Errorf("Release() error = %v", err)
+				}
+			}(*reservation)
+		}
+	}
+	wg.Wait()
+
+	if got := clients.balance(); got != balance {
+		t.Errorf("balance after release = %d, want %d", got, balance)
+	}
+}
+
+func TestSettlementRacesWithSweep(t *testing.T) {
+	const balance, requests, cost = 100, 20, 2
+	billing, clients, reservations := newTestBilling(t, balance)
+
+	held := make([]*model.CallReservation, requests)
+	for i := range held {
+		reservation, _, err := billing.Reserve(context.Background(), clients.client, "/api/v1/test", cost)
+		if err != nil {
+			t.Fatalf("Reserve() error = %v", err)
+		}
+		held[i] = reservation
+	}
+
+	// 所有预扣都已过期，确认、退回和清理同时进行
+	var wg sync.WaitGroup
+	for i, reservation := range held {
+		wg.Add(1)
+		go func(i int, reservation *model.CallReservation) {
+			defer wg.Done()
+			if i%2 == 0 {
+				if err := billing.Commit(context.Background(), reservation); err != nil {
+					t.Errorf("Commit() error = %v", err)
+				}
+				return
+			}
+			if err := billing.Release(context.Background(), reservation); err != nil {
+				t.Errorf("Release() error = %v", err)
+			}
+		}(i, reservation)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := billing.ExpireReservations(context.Background(), time.Now().Add(time.Hour)); err != nil {
+				t.Errorf("ExpireReservations() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 无论结算和清理谁先完成，确认的调用恰好扣费一次，其余的恰好退回一次
+	if got, want := clients.balance(), balance-requests/2*cost; got != want {
+		t.Errorf("balance = %d, want %d", got, want)
+	}
+	for i, reservation := range held {
+		status := reservations.status(reservation.ID)
+		if i%2 == 0 && status != model.ReservationCommitted {
+			t.Errorf("committed reservation %d status = %s", i, status)
+		}
+		if i%2 == 1 && status != model.ReservationReleased && status != model.ReservationExpired {
+			t.Errorf("released reservation %d status = %s", i, status)
+		}
+	}
+}
+
+func TestSettleReservation(t *testing.T) {
+	const balance, cost = 10, 3
+
+	tests := []struct {
+		name        string
+		settle      func(ctx context.Context, b *BillingService, r *model.CallReservation) error
+		wantBalance int
+		wantStatus  string
+		wantErr     bool
+	}{
+		{
+			name: "commit",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				return b.Commit(ctx, r)
+			},
+			wantBalance: balance - cost,
+			wantStatus:  model.ReservationCommitted,
+		},
+		{
+			name: "release",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				return b.Release(ctx, r)
+			},
+			wantBalance: balance,
+			wantStatus:  model.ReservationReleased,
+		},
+		{
+			name: "expire",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				_, err := b.ExpireReservations(ctx, time.Now().Add(time.Hour))
+				return err
+			},
+			wantBalance: balance,
+			wantStatus:  model.ReservationExpired,
+		},
+		{
+			name: "commit after expiry charges again",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				if _, err := b.ExpireReservations(ctx, time.Now().Add(time.Hour)); err != nil {
+					return err
+				}
+				return b.Commit(ctx, r)
+			},
+			wantBalance: balance - cost,
+			wantStatus:  model.ReservationCommitted,
+		},
+		{
+			name: "commit after expiry without balance is unpaid",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				if _, err := b.ExpireReservations(ctx, time.Now().Add(time.Hour)); err != nil {
+					return err
+				}
+				// 退回的次数在调用结束前已被其他请求用完
+				if _, err := b.clientRepo.DeductCallCount(ctx, r.ClientID, balance); err != nil {
+					return err
+				}
+				return b.Commit(ctx, r)
+			},
+			wantBalance: 0,
+			wantStatus:  model.ReservationUnpaid,
+			wantErr:     true,
+		},
+		{
+			name: "commit after release",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				if err := b.Release(ctx, r); err != nil {
+					return err
+				}
+				return b.Commit(ctx, r)
+			},
+			wantBalance: balance,
+			wantStatus:  model.ReservationReleased,
+			wantErr:     true,
+		},
+		{
+			name: "release after commit",
+			settle: func(ctx context.Context, b *BillingService, r *model.CallReservation) error {
+				if err := b.Commit(ctx, r); err != nil {
+					return err
+				}
+				return b.Release(ctx, r)
+			},
+			wantBalance: balance - cost,
+			wantStatus:  model.ReservationCommitted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			billing, clients, reservations := newTestBilling(t, balance)
+
+			reservation, remaining, err := billing.Reserve(ctx, clients.client, "/api/v1/test", cost)
+			if err != nil {
+				t.Fatalf("Reserve() error = %v", err)
+			}
+			if remaining != balance-cost {
+				t.Errorf("Reserve() remaining = %d, want %d", remaining, balance-cost)
+			}
+
+			err = tt.settle(ctx, billing, reservation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("settle error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := clients.balance(); got != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", got, tt.wantBalance)
+			}
+			if got := reservations.status(reservation.ID); got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReserveRefundsWhenRecordFails(t *testing.T) {
+	billing, clients, reservations := newTestBilling(t, 5)
+	reservations.createErr = fmt.Errorf("write failed")
+
+	if _, _, err := billing.Reserve(context.Background(), clients.client, "/api/v1/test", 2); err == nil {
+		t.Fatal("Reserve() succeeded without a reservation record")
+	}
+	if got := clients.balance(); got != 5 {
+		t.Errorf("balance = %d, want 5", got)
+	}
+}
+
+func TestFailedRefundIsRetriedBySweep(t *testing.T) {
+	const balance, cost = 10, 4
+	ctx := context.Background()
+	billing, clients, reservations := newTestBilling(t, balance)
+
+	released, _, err := billing.Reserve(ctx, clients.client, "/api/v1/test", cost)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	committed, _, err := billing.Reserve(ctx, clients.client, "/api/v1/test", cost)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+
+	clients.failRefunds(fmt.Errorf("write failed"))
+	if err := billing.Release(ctx, released); err == nil {
+		t.Fatal("Release() succeeded while refunds fail")
+	}
+	if _, err := billing.ExpireReservations(ctx, time.Now()); err == nil {
+		t.Fatal("ExpireReservations() succeeded while refunds fail")
+	}
+	if got := reservations.status(released.ID); got != model.ReservationRefundFailed {
+		t.Fatalf("status after failed refund = %s, want %s", got, model.ReservationRefundFailed)
+	}
+	if got := clients.balance(); got != balance-2*cost {
+		t.Fatalf("balance after failed refund = %d, want %d", got, balance-2*cost)
+	}
+
+	// 调用成功时，退回失败的预扣直接确认，不重复扣费
+	if err := billing.Release(ctx, committed); err == nil {
+		t.Fatal("Release() succeeded while refunds fail")
+	}
+	if err := billing.Commit(ctx, committed); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	clients.failRefunds(nil)
+	refunded, err := billing.ExpireReservations(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("ExpireReservations() error = %v", err)
+	}
+	if refunded != 1 {
+		t.Errorf("ExpireReservations() refunded %d, want 1", refunded)
+	}
+	if got := clients.balance(); got != balance-cost {
+		t.Errorf("balance = %d, want %d", got, balance-cost)
+	}
+	if got := reservations.status(released.ID); got != model.ReservationExpired {
+		t.Errorf("released reservation status = %s, want %s", got, model.ReservationExpired)
+	}
+	if got := reservations.status(committed.ID); got != model.ReservationCommitted {
+		t.Errorf("committed reservation status = %s, want %s", got, model.ReservationCommitted)
+	}
+}
+
+func newTestBilling(t *testing.T, balance int) (*BillingService, *memoryClientRepo, *memoryReservationRepo) {
+	t.Helper()
+	clients := &memoryClientRepo{client: &model.Client{ID: primitive.NewObjectID(), CallCount: balance}}
+	reservations := &memoryReservationRepo{reservations: make(map[primitive.ObjectID]*model.CallReservation)}
+	return NewBillingService(clients, reservations, config.PricingConfig{}), clients, reservations
+}
+
+// memoryClientRepo 内存中的单个客户余额，扣减和退回与 MongoDB 实现一样是原子的
+type memoryClientRepo struct {
+	repository.ClientRepository
+	mu        sync.Mutex
+	client    *model.Client
+	refundErr error
+}
+
+func (r *memoryClientRepo) failRefunds(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refundErr = err
+}
+
+func (r *memoryClientRepo) balance() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client.CallCount
+}
+
+func (r *memoryClientRepo) DeductCallCount(ctx context.Context, id primitive.ObjectID, amount int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id != r.client.ID || r.client.CallCount < amount {
+		return 0, repository.ErrInsufficientCalls
+	}
+	r.client.CallCount -= amount
+	return r.client.CallCount, nil
+}
+
+func (r *memoryClientRepo) RefundCallCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id != r.client.ID {
+		return fmt.Errorf("client not found")
+	}
+	if r.refundErr != nil {
+		return r.refundErr
+	}
+	r.client.CallCount += amount
+	return nil
+}
+
+// memoryReservationRepo 内存中的预扣记录，状态转换是原子的
+type memoryReservationRepo struct {
+	mu           sync.Mutex
+	reservations map[primitive.ObjectID]*model.CallReservation
+	createErr    error
+}
+
+func (r *memoryReservationRepo) status(id primitive.ObjectID) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reservations[id].Status
+}
+
+func (r *memoryReservationRepo) Create(ctx context.Context, reservation *model.CallReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	reservation.ID = primitive.NewObjectID()
+	stored := *reservation
+	r.reservations[reservation.ID] = &stored
+	return nil
+}
+
+func (r *memoryReservationRepo) Transition(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[id]
+	if !ok || reservation.Status != from {
+		return false, nil
+	}
+	reservation.Status = to
+	return true, nil
+}
+
+func (r *memoryReservationRepo) FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.CallReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []*model.CallReservation
+	for _, reservation := range r.reservations {
+		if (reservation.Status == model.ReservationHeld && reservation.ExpiresAt.Before(before)) ||
+			reservation.Status == model.ReservationRefundFailed {
+			found := *reservation
+			expired = append(expired, &found)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(expired[j].ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -28,13 +29,13 @@ func NewClientService(clientRepo repository.ClientRepository, callLogRepo reposi
 // CreateClient creates a new client with a generated API key and secret
 func (s *ClientService) CreateClient(ctx context.Context, name, version string, initialCallCount int) (*model.Client, error) {
 	// Generate API key
-	apiKey, err := s.generateAPIKey()
+	apiKey, err := generateAPIKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate API key: %w", err)
 	}
 
 	// Generate secret
-	secret, err := s.generateSecret()
+	secret, err := generateSecret()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate secret: %w", err)
 	}
@@ -43,7 +44,7 @@ func (s *ClientService) CreateClient(ctx context.Context, name, version string,
 	existing, _ := s.clientRepo.GetByAPIKey(ctx, apiKey)
 	if existing != nil {
 		// Regenerate if collision occurs
-		apiKey, err = s.generateAPIKey()
+		apiKey, err = generateAPIKey()
 		if err != nil {
 			return nil, fmt.Errorf("failed to regenerate API key: %w", err)
 		}
@@ -79,28 +80,187 @@ func (s *ClientService) RechargeClient(ctx context.Context, id primitive.ObjectI
 }
 
 // UpdateClientStatus updates the status of a client
+// 只更新状态字段，避免覆盖并发扣费后的余额
 func (s *ClientService) UpdateClientStatus(ctx context.Context, id primitive.ObjectID, status int) error {
+	return s.clientRepo.UpdateStatus(ctx, id, status)
+}
+
+// GetClientCallLogs retrieves call logs for a client
+func (s *ClientService) GetClientCallLogs(ctx context.Context, clientID primitive.ObjectID, offset, limit int) ([]*model.CallLog, error) {
+	return s.callLogRepo.GetByClientID(ctx, clientID, offset, limit)
+}
+
+// UpdateClientQPS updates a client's QPS limit and burst size
+func (s *ClientService) UpdateClientQPS(ctx context.Context, id primitive.ObjectID, qps, burst int) error {
+	return s.clientRepo.UpdateQPS(ctx, id, qps, burst)
+}
+
+// UpdateClientPlan updates a client's billing plan
+func (s *ClientService) UpdateClientPlan(ctx context.Context, id primitive.ObjectID, plan string) error {
+	return s.clientRepo.UpdatePlan(ctx, id, plan)
+}
+
+// UpdateClientIPRules validates and replaces a client's IP allowlist and denylist
+func (s *ClientService) UpdateClientIPRules(ctx context.Context, id primitive.ObjectID, allowlist, denylist []string) (*model.Client, error) {
+	allow, err := model.NormalizeIPRules(allowlist)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := model.NormalizeIPRules(denylist)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.UpdateIPRules(ctx, id, allow, deny); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, id)
+}
+
+// UpdateClientCORS validates and replaces a client's CORS policy, nil or empty removes the policy
+func (s *ClientService) UpdateClientCORS(ctx context.Context, id primitive.ObjectID, policy *model.CORSPolicy) (*model.Client, error) {
+	normalized, err := model.NormalizeCORSPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.UpdateCORS(ctx, id, normalized); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, id)
+}
+
+// UpdateClientRateLimits validates and replaces a client's rate limit overrides
+func (s *ClientService) UpdateClientRateLimits(ctx context.Context, id primitive.ObjectID, overrides []model.RateLimitOverride) (*model.Client, error) {
+	if err := model.ValidateRateLimitOverrides(overrides); err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.UpdateRateLimits(ctx, id, overrides); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, id)
+}
+
+// UpdateClientQuotas validates and replaces a client's call quotas
+func (s *ClientService) UpdateClientQuotas(ctx context.Context, id primitive.ObjectID, quotas []model.Quota) (*model.Client, error) {
+	if err := model.ValidateQuotas(quotas); err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.UpdateQuotas(ctx, id, quotas); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, id)
+}
+
+// UpdateClientTemporaryLimit validates and sets a client's temporary rate limit adjustment, nil removes it
+func (s *ClientService) UpdateClientTemporaryLimit(ctx context.Context, id primitive.ObjectID, limit *model.TemporaryLimit) (*model.Client, error) {
+	if limit != nil {
+		if err := model.ValidateTemporaryLimit(limit, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.clientRepo.UpdateTemporaryLimit(ctx, id, limit); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, id)
+}
+
+// UpdateClientValidity replaces a client's contract validity window.
+// reactivate 为 true 时，新有效期内被禁用的客户（如到期自动禁用）会重新启用
+func (s *ClientService) UpdateClientValidity(ctx context.Context, id primitive.ObjectID, validFrom, validUntil *time.Time, reactivate bool) (*model.Client, error) {
+	if validFrom != nil && validUntil != nil && !validFrom.Before(*validUntil) {
+		return nil, fmt.Errorf("invalid validity window: valid_from must be before valid_until")
+	}
+
 	client, err := s.clientRepo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// 按新的有效期判断是否恢复已禁用的客户，状态和有效期在同一次更新中写入
+	client.ValidFrom = validFrom
+	client.ValidUntil = validUntil
+	activate := reactivate && !client.IsActive() && client.CheckValidity(time.Now()) != model.ClientValidityExpired
+
+	if err := s.clientRepo.UpdateValidity(ctx, id, validFrom, validUntil, activate); err != nil {
+		return nil, err
 	}
 
-	client.Status = status
-	return s.clientRepo.Update(ctx, client)
+	return s.clientRepo.GetByID(ctx, id)
 }
 
-// GetClientCallLogs retrieves call logs for a client
-func (s *ClientService) GetClientCallLogs(ctx context.Context, clientID primitive.ObjectID, offset, limit int) ([]*model.CallLog, error) {
-	return s.callLogRepo.GetByClientID(ctx, clientID, offset, limit)
+// RegisterPublicKey parses and registers a public key for asymmetric request signatures
+func (s *ClientService) RegisterPublicKey(ctx context.Context, id primitive.ObjectID, keyID, pemData string) (*model.PublicKey, error) {
+	key, err := model.NewPublicKey(keyID, pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.AddPublicKey(ctx, id, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
 }
 
-// UpdateClientQPS updates a client's QPS limit
-func (s *ClientService) UpdateClientQPS(ctx context.Context, id primitive.ObjectID, qps int) error {
-	return s.clientRepo.UpdateQPS(ctx, id, qps)
+// RemovePublicKey removes a registered public key.
+// 客户当前使用公钥签名时，不允许删除该算法的最后一个公钥
+func (s *ClientService) RemovePublicKey(ctx context.Context, id primitive.ObjectID, keyID string) error {
+	client, err := s.clientRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	signatureType := client.EffectiveSignatureType()
+	if signatureType != model.SignatureTypeHMAC {
+		remaining := 0
+		for _, key := range client.PublicKeys {
+			if key.Algorithm == signatureType && key.KeyID != keyID {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return fmt.Errorf("cannot remove the last %s public key while it is the client's signature type", signatureType)
+		}
+	}
+
+	return s.clientRepo.RemovePublicKey(ctx, id, keyID)
+}
+
+// UpdateSignatureType switches the request signature type of a client.
+// 切换为公钥签名前必须已登记对应算法的公钥
+func (s *ClientService) UpdateSignatureType(ctx context.Context, id primitive.ObjectID, signatureType string) (*model.Client, error) {
+	if !model.IsValidSignatureType(signatureType) {
+		return nil, fmt.Errorf("invalid signature type: %s", signatureType)
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if signatureType != model.SignatureTypeHMAC && !client.HasPublicKey(signatureType) {
+		return nil, fmt.Errorf("invalid signature type: no %s public key registered", signatureType)
+	}
+
+	if err := s.clientRepo.UpdateSignatureType(ctx, id, signatureType); err != nil {
+		return nil, err
+	}
+
+	client.SignatureType = signatureType
+	return client, nil
 }
 
 // generateAPIKey generates a random API key
-func (s *ClientService) generateAPIKey() (string, error) {
+func generateAPIKey() (string, error) {
 	bytes := make([]byte, 32) // 64 character hex string
 	_, err := rand.Read(bytes)
 	if err != nil {
@@ -110,7 +270,7 @@ func (s *ClientService) generateAPIKey() (string, error) {
 }
 
 // generateSecret generates a random secret for HMAC signing
-func (s *ClientService) generateSecret() (string, error) {
+func generateSecret() (string, error) {
 	bytes := make([]byte, 32) // 64 character hex string
 	_, err := rand.Read(bytes)
 	if err != nil {
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/secrets"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CredentialService provides business logic for client credential operations
+type CredentialService struct {
+	clientRepo     repository.ClientRepository
+	credentialRepo repository.CredentialRepository
+	gracePeriod    time.Duration // 轮换时旧密钥的默认保留时间
+}
+
+// NewCredentialService creates a new credential service
+func NewCredentialService(clientRepo repository.ClientRepository, credentialRepo repository.CredentialRepository, gracePeriod time.Duration) *CredentialService {
+	if gracePeriod <= 0 {
+		gracePeriod = 24 * time.Hour // 默认保留24小时
+	}
+	return &CredentialService{
+		clientRepo:     clientRepo,
+		credentialRepo: credentialRepo,
+		gracePeriod:    gracePeriod,
+	}
+}
+
+// IssueCredential issues a new API key and secret for a client
+func (s *CredentialService) IssueCredential(ctx context.Context, clientID primitive.ObjectID, label string, expiresAt *time.Time) (*model.Credential, error) {
+	if _, err := s.clientRepo.GetByID(ctx, clientID); err != nil {
+		return nil, err
+	}
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	credential := model.NewCredential(clientID, label, apiKey, secret, expiresAt)
+	if err := s.credentialRepo.Create(ctx, credential); err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+// ListCredentials retrieves all credentials of a client, including its primary key
+func (s *CredentialService) ListCredentials(ctx context.Context, clientID primitive.ObjectID) ([]*model.Credential, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := migratePrimaryCredential(ctx, s.credentialRepo, client); err != nil {
+		return nil, err
+	}
+
+	return s.credentialRepo.ListByClientID(ctx, clientID)
+}
+
+// RotateCredential issues a replacement for a credential and keeps the old one
+// valid for the grace period. A zero grace period uses the configured default.
+func (s *CredentialService) RotateCredential(ctx context.Context, clientID, credentialID primitive.ObjectID, gracePeriod time.Duration) (*model.Credential, error) {
+	old, err := s.getClientCredential(ctx, clientID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !old.IsValid(time.Now()) {
+		return nil, fmt.Errorf("credential is not active")
+	}
+
+	if gracePeriod <= 0 {
+		gracePeriod = s.gracePeriod
+	}
+
+	replacement, err := s.IssueCredential(ctx, clientID, old.Label, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 旧密钥在宽限期后失效，若原本更早过期则保持不变
+	graceEnd := time.Now().Add(gracePeriod)
+	if old.ExpiresAt == nil || graceEnd.Before(*old.ExpiresAt) {
+		if err := s.credentialRepo.SetExpiry(ctx, old.ID, graceEnd); err != nil {
+			return nil, fmt.Errorf("failed to expire rotated credential: %w", err)
+		}
+	}
+
+	return replacement, nil
+}
+
+// RevokeCredential revokes a credential immediately
+func (s *CredentialService) RevokeCredential(ctx context.Context, clientID, credentialID primitive.ObjectID) error {
+	if _, err := s.getClientCredential(ctx, clientID, credentialID); err != nil {
+		return err
+	}
+
+	return s.credentialRepo.Revoke(ctx, credentialID)
+}
+
+// getClientCredential retrieves a credential and checks that it belongs to the client
+func (s *CredentialService) getClientCredential(ctx context.Context, clientID, credentialID primitive.ObjectID) (*model.Credential, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 主密钥尚未迁移时先迁移，使其可以被轮换和吊销
+	if _, err := migratePrimaryCredential(ctx, s.credentialRepo, client); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.credentialRepo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.ClientID != clientID {
+		return nil, fmt.Errorf("credential not found")
+	}
+
+	return credential, nil
+}
+
+// migratePrimaryCredential moves the primary key of a client into a credential
+// record so that it can be rotated, expired and revoked like any issued key.
+// Returns the existing record if the primary key was already migrated.
+func migratePrimaryCredential(ctx context.Context, credentialRepo repository.CredentialRepository, client *model.Client) (*model.Credential, error) {
+	keyHash := client.APIKeyHash
+	if client.APIKey != "" {
+		keyHash = secrets.HashAPIKey(client.APIKey)
+	}
+	if keyHash == "" {
+		return nil, nil
+	}
+
+	existing, err := findCredentialByHash(ctx, credentialRepo, client.ID, keyHash)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	credential := model.NewCredential(client.ID, model.PrimaryCredentialLabel, client.APIKey, client.Secret, nil)
+	credential.APIKeyHash = keyHash
+	credential.APIKeyPrefix = client.APIKeyPrefix
+	credential.EncryptedSecret = client.EncryptedSecret
+
+	if err := credentialRepo.Create(ctx, credential); err != nil {
+		// 并发迁移时 api_key_hash 唯一索引会拒绝重复记录，以已写入的记录为准
+		if existing, findErr := findCredentialByHash(ctx, credentialRepo, client.ID, keyHash); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to migrate primary key: %w", err)
+	}
+
+	logger.Infof("Migrated primary API key of client %s to credential %s", client.ID.Hex(), credential.ID.Hex())
+	return credential, nil
+}
+
+// findCredentialByHash returns the credential of a client with the given API key hash, nil if none
+func findCredentialByHash(ctx context.Context, credentialRepo repository.CredentialRepository, clientID primitive.ObjectID, keyHash string) (*model.Credential, error) {
+	credentials, err := credentialRepo.ListByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, credential := range credentials {
+		if credential.APIKeyHash == keyHash || (credential.APIKey != "" && secrets.HashAPIKey(credential.APIKey) == keyHash) {
+			return credential, nil
+		}
+	}
+	return nil, nil
+}
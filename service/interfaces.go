@@ -3,6 +3,7 @@ package service
 import (
 	"api-gateway/model"
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -14,6 +15,35 @@ type ClientServiceInterface interface {
 	ListClients(ctx context.Context, offset, limit int) ([]*model.Client, error)
 	RechargeClient(ctx context.Context, id primitive.ObjectID, callCount int) error
 	UpdateClientStatus(ctx context.Context, id primitive.ObjectID, status int) error
-	UpdateClientQPS(ctx context.Context, id primitive.ObjectID, qps int) error
+	UpdateClientQPS(ctx context.Context, id primitive.ObjectID, qps, burst int) error
+	UpdateClientPlan(ctx context.Context, id primitive.ObjectID, plan string) error
+	UpdateClientIPRules(ctx context.Context, id primitive.ObjectID, allowlist, denylist []string) (*model.Client, error)
+	UpdateClientCORS(ctx context.Context, id primitive.ObjectID, policy *model.CORSPolicy) (*model.Client, error)
+	UpdateClientRateLimits(ctx context.Context, id primitive.ObjectID, overrides []model.RateLimitOverride) (*model.Client, error)
+	UpdateClientQuotas(ctx context.Context, id primitive.ObjectID, quotas []model.Quota) (*model.Client, error)
+	UpdateClientTemporaryLimit(ctx context.Context, id primitive.ObjectID, limit *model.TemporaryLimit) (*model.Client, error)
+	RegisterPublicKey(ctx context.Context, id primitive.ObjectID, keyID, pemData string) (*model.PublicKey, error)
+	RemovePublicKey(ctx context.Context, id primitive.ObjectID, keyID string) error
+	UpdateSignatureType(ctx context.Context, id primitive.ObjectID, signatureType string) (*model.Client, error)
+	UpdateClientValidity(ctx context.Context, id primitive.ObjectID, validFrom, validUntil *time.Time, reactivate bool) (*model.Client, error)
 	GetClientCallLogs(ctx context.Context, clientID primitive.ObjectID, offset, limit int) ([]*model.CallLog, error)
 }
+
+// CredentialServiceInterface defines the interface for client credential operations
+type CredentialServiceInterface interface {
+	IssueCredential(ctx context.Context, clientID primitive.ObjectID, label string, expiresAt *time.Time) (*model.Credential, error)
+	ListCredentials(ctx context.Context, clientID primitive.ObjectID) ([]*model.Credential, error)
+	RotateCredential(ctx context.Context, clientID, credentialID primitive.ObjectID, gracePeriod time.Duration) (*model.Credential, error)
+	RevokeCredential(ctx context.Context, clientID, credentialID primitive.ObjectID) error
+}
+
+// ScopeServiceInterface defines the interface for client route scope operations
+type ScopeServiceInterface interface {
+	GrantScopes(ctx context.Context, clientID primitive.ObjectID, scopes []string) (*model.Client, error)
+	RevokeScopes(ctx context.Context, clientID primitive.ObjectID, scopes []string) (*model.Client, error)
+}
+
+// AuditServiceInterface defines the interface for recording administrative actions
+type AuditServiceInterface interface {
+	Record(entry *model.AuditLog)
+}
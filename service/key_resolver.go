@@ -0,0 +1,70 @@
+package service
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// KeyResolver resolves an API key to its client, checking issued credentials
+// first and falling back to the client's primary key. A primary key found this
+// way is migrated into a credential so that later revocation takes effect.
+type KeyResolver struct {
+	clientRepo     repository.ClientRepository
+	credentialRepo repository.CredentialRepository
+}
+
+// NewKeyResolver creates a new API key resolver
+func NewKeyResolver(clientRepo repository.ClientRepository, credentialRepo repository.CredentialRepository) *KeyResolver {
+	return &KeyResolver{
+		clientRepo:     clientRepo,
+		credentialRepo: credentialRepo,
+	}
+}
+
+// Resolve returns the client owning the API key. When the key belongs to an
+// issued credential, the credential is returned as well and the client's key
+// and secret are replaced with the credential's.
+func (r *KeyResolver) Resolve(ctx context.Context, apiKey string) (*model.Client, *model.Credential, error) {
+	credential, err := r.credentialRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, nil, err
+		}
+		client, err := r.clientRepo.GetByAPIKey(ctx, apiKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		credential, err := migratePrimaryCredential(ctx, r.credentialRepo, client)
+		if err != nil {
+			// 迁移失败不影响本次请求，下次使用时重试
+			logger.Errorf("Failed to migrate primary key of client %s: %v", client.ID.Hex(), err)
+			return client, nil, nil
+		}
+		if credential != nil && !credential.IsValid(time.Now()) {
+			return nil, nil, fmt.Errorf("credential not found")
+		}
+		return client, credential, nil
+	}
+
+	if !credential.IsValid(time.Now()) {
+		logger.Infof("Credential %s of client %s is revoked or expired", credential.ID.Hex(), credential.ClientID.Hex())
+		return nil, nil, fmt.Errorf("credential not found")
+	}
+
+	client, err := r.clientRepo.GetByID(ctx, credential.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 使用本次请求所用凭证的密钥进行后续签名校验和日志记录
+	client.APIKey = credential.APIKey
+	client.Secret = credential.Secret
+
+	return client, credential, nil
+}
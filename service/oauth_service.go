@@ -0,0 +1,393 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/jwt"
+	"api-gateway/pkg/logger"
+	"api-gateway/repository"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oauthKeyID 网关签发令牌使用的 kid
+const oauthKeyID = "gateway"
+
+// OAuthScopeAll 覆盖客户被授权的全部路由的 scope，其余 scope 为产品名或路由模式
+const OAuthScopeAll = "api"
+
+// tokenClientCacheTTL 访问令牌对应客户和凭证的缓存时间，禁用客户或吊销凭证最迟在此时间后生效
+const tokenClientCacheTTL = 30 * time.Second
+
+// AccessTokenClaims 网关签发的访问令牌声明
+// 令牌只标识客户和凭证，认证时以数据库中的客户为准
+type AccessTokenClaims struct {
+	jwt.Claims
+	Scope        string `json:"scope,omitempty"`
+	CredentialID string `json:"cid,omitempty"`
+}
+
+// Scopes returns the granted scopes
+func (c *AccessTokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// AccessToken 签发结果
+type AccessToken struct {
+	Token     string
+	ExpiresIn int
+	Scope     string
+	Claims    *AccessTokenClaims
+}
+
+// OAuthService issues and validates OAuth2 client-credentials access tokens
+type OAuthService struct {
+	keyResolver    *KeyResolver
+	revocationRepo repository.TokenRevocationRepository
+	signingKey     []byte
+	issuer         string
+	ttl            time.Duration
+	scopes         map[string]bool
+	defaultScopes  []string
+
+	mu           sync.Mutex
+	tokenClients map[string]cachedTokenClient // 客户ID/凭证ID -> 客户和凭证
+	lastPrune    time.Time                    // 上次清理过期缓存的时间
+}
+
+type cachedTokenClient struct {
+	client     *model.Client
+	credential *model.Credential // 凭证已删除时为空
+	loadedAt   time.Time
+}
+
+// NewOAuthService creates a new OAuth service.
+// Without a configured signing key a random one is generated, so tokens do not
+// survive a restart and are not accepted by other gateway instances.
+func NewOAuthService(keyResolver *KeyResolver, revocationRepo repository.TokenRevocationRepository, cfg config.OAuthConfig) (*OAuthService, error) {
+	signingKey := cfg.SigningKey
+	if env := os.Getenv("OAUTH_SIGNING_KEY"); env != "" {
+		signingKey = env
+	}
+	key := []byte(signingKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate OAuth signing key: %w", err)
+		}
+		logger.Error("OAuth signing key is not configured, using a random key; tokens will not survive restarts")
+	} else if len(key) < 32 {
+		return nil, fmt.Errorf("OAuth signing key must be at least 32 bytes")
+	}
+
+	issuer := cfg.Issuer
+	if issuer == "" {
+		issuer = "api-gateway"
+	}
+
+	ttl := time.Duration(cfg.TokenTTL) * time.Second
+	if cfg.TokenTTL <= 0 {
+		ttl = time.Hour // 默认有效期1小时
+	}
+
+	defaultScopes := cfg.Scopes
+	if len(defaultScopes) == 0 {
+		defaultScopes = []string{OAuthScopeAll}
+	}
+	scopes := make(map[string]bool, len(defaultScopes))
+	for _, scope := range defaultScopes {
+		scopes[scope] = true
+	}
+
+	return &OAuthService{
+		keyResolver:    keyResolver,
+		revocationRepo: revocationRepo,
+		signingKey:     key,
+		issuer:         issuer,
+		ttl:            ttl,
+		scopes:         scopes,
+		defaultScopes:  defaultScopes,
+		tokenClients:   make(map[string]cachedTokenClient),
+	}, nil
+}
+
+// Issuer returns the issuer of gateway tokens
+func (s *OAuthService) Issuer() string {
+	return s.issuer
+}
+
+// AuthenticateClient verifies an API key and secret pair
+func (s *OAuthService) AuthenticateClient(ctx context.Context, apiKey, secret string) (*model.Client, *model.Credential, error) {
+	client, credential, err := s.keyResolver.Resolve(ctx, apiKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil, fmt.Errorf("invalid client credentials")
+		}
+		return nil, nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		return nil, nil, fmt.Errorf("invalid client credentials")
+	}
+
+	if !client.IsActive() {
+		return nil, nil, fmt.Errorf("client is disabled")
+	}
+
+	if state := client.CheckValidity(time.Now()); state != model.ClientValidityActive {
+		return nil, nil, fmt.Errorf("client contract %s", state)
+	}
+
+	return client, credential, nil
+}
+
+// IssueToken issues an access token for an authenticated client.
+// An empty scope grants all configured scopes.
+func (s *OAuthService) IssueToken(client *model.Client, credential *model.Credential, scope string) (*AccessToken, error) {
+	granted, err := s.resolveScopes(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	// 令牌有效期不超过客户合同结束时间
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	if client.ValidUntil != nil && client.ValidUntil.Before(expiresAt) {
+		expiresAt = *client.ValidUntil
+	}
+
+	claims := &AccessTokenClaims{
+		Claims: jwt.Claims{
+			Issuer:    s.issuer,
+			Subject:   client.ID.Hex(),
+			Audience:  jwt.Audience{s.issuer},
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  now.Unix(),
+			ID:        jti,
+		},
+		Scope: strings.Join(granted, " "),
+	}
+	if credential != nil {
+		claims.CredentialID = credential.ID.Hex()
+	}
+
+	token, err := jwt.SignHS256(claims, oauthKeyID, s.signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{
+		Token:     token,
+		ExpiresIn: int(expiresAt.Sub(now).Seconds()),
+		Scope:     claims.Scope,
+		Claims:    claims,
+	}, nil
+}
+
+// IsGatewayToken reports whether a bearer token was issued by this gateway
+func (s *OAuthService) IsGatewayToken(rawToken string) bool {
+	token, err := jwt.Parse(rawToken)
+	if err != nil {
+		return false
+	}
+	return token.Header.Kid == oauthKeyID && token.Claims.Issuer == s.issuer
+}
+
+// ValidateToken verifies signature, claims and revocation status of an access token
+func (s *OAuthService) ValidateToken(ctx context.Context, rawToken string) (*AccessTokenClaims, error) {
+	claims, err := s.parseToken(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.revocationRepo.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("token revoked")
+	}
+
+	return claims, nil
+}
+
+// RevokeToken revokes an access token owned by the client.
+// Invalid tokens and tokens of other clients are ignored as required by RFC 7009.
+func (s *OAuthService) RevokeToken(ctx context.Context, client *model.Client, rawToken string) error {
+	claims, err := s.parseToken(rawToken)
+	if err != nil || claims.Subject != client.ID.Hex() {
+		return nil
+	}
+
+	return s.revocationRepo.Revoke(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0))
+}
+
+// Introspect returns the claims of an active token owned by the client, or nil
+// when the token is invalid, expired, revoked or belongs to another client
+func (s *OAuthService) Introspect(ctx context.Context, client *model.Client, rawToken string) (*AccessTokenClaims, error) {
+	claims, err := s.ValidateToken(ctx, rawToken)
+	if err != nil {
+		if strings.Contains(err.Error(), "token") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if claims.Subject != client.ID.Hex() {
+		return nil, nil
+	}
+
+	return claims, nil
+}
+
+// ClientForToken loads the current client of a validated access token and checks
+// that the credential the token was issued with is still valid. Results are cached
+// for a short time, so disabling a client or revoking a credential also ends its tokens.
+func (s *OAuthService) ClientForToken(ctx context.Context, claims *AccessTokenClaims) (*model.Client, error) {
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token claims: bad subject")
+	}
+
+	var credentialID primitive.ObjectID
+	if claims.CredentialID != "" {
+		credentialID, err = primitive.ObjectIDFromHex(claims.CredentialID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token claims: bad credential")
+		}
+	}
+
+	cacheKey := claims.Subject + "/" + claims.CredentialID
+	s.mu.Lock()
+	cached, ok := s.tokenClients[cacheKey]
+	s.mu.Unlock()
+
+	if !ok || time.Since(cached.loadedAt) >= tokenClientCacheTTL {
+		cached, err = s.loadTokenClient(ctx, id, credentialID)
+		if err != nil {
+			return nil, err
+		}
+		s.storeTokenClient(cacheKey, cached)
+	}
+
+	if !credentialID.IsZero() {
+		credential := cached.credential
+		if credential == nil || credential.ClientID != id || !credential.IsValid(time.Now()) {
+			return nil, fmt.Errorf("token revoked: credential is no longer valid")
+		}
+	}
+
+	client := *cached.client
+	return &client, nil
+}
+
+// storeTokenClient 缓存令牌对应的客户和凭证，每个缓存周期清理一次过期的记录
+func (s *OAuthService) storeTokenClient(key string, cached cachedTokenClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.Sub(s.lastPrune) >= tokenClientCacheTTL {
+		for k, entry := range s.tokenClients {
+			if now.Sub(entry.loadedAt) >= tokenClientCacheTTL {
+				delete(s.tokenClients, k)
+			}
+		}
+		s.lastPrune = now
+	}
+	s.tokenClients[key] = cached
+}
+
+// loadTokenClient loads the client and credential of an access token from the database
+func (s *OAuthService) loadTokenClient(ctx context.Context, clientID, credentialID primitive.ObjectID) (cachedTokenClient, error) {
+	client, err := s.keyResolver.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return cachedTokenClient{}, fmt.Errorf("invalid token claims: unknown client")
+		}
+		return cachedTokenClient{}, err
+	}
+
+	loaded := cachedTokenClient{client: client, loadedAt: time.Now()}
+	if credentialID.IsZero() {
+		return loaded, nil
+	}
+
+	credential, err := s.keyResolver.credentialRepo.GetByID(ctx, credentialID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return cachedTokenClient{}, err
+	}
+	loaded.credential = credential
+
+	return loaded, nil
+}
+
+// parseToken verifies signature and registered claims of a gateway token
+func (s *OAuthService) parseToken(rawToken string) (*AccessTokenClaims, error) {
+	token, err := jwt.Parse(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Header.Alg != jwt.AlgHS256 {
+		return nil, fmt.Errorf("unsupported token algorithm: %s", token.Header.Alg)
+	}
+
+	if err := token.Verify(s.signingKey); err != nil {
+		return nil, err
+	}
+
+	if err := token.Claims.ValidateClaims(jwt.ValidationOptions{
+		Audience: s.issuer,
+		Issuer:   s.issuer,
+	}); err != nil {
+		return nil, err
+	}
+
+	var claims AccessTokenClaims
+	if err := token.DecodeClaims(&claims); err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("invalid token claims: missing jti")
+	}
+
+	return &claims, nil
+}
+
+// resolveScopes checks the requested scopes against the configured ones
+func (s *OAuthService) resolveScopes(scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return s.defaultScopes, nil
+	}
+
+	for _, sc := range requested {
+		if !s.scopes[sc] {
+			return nil, fmt.Errorf("invalid scope: %s", sc)
+		}
+	}
+	return requested, nil
+}
+
+// newTokenID generates a random token ID
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
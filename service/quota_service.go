@@ -0,0 +1,257 @@
+package service
+
+import (
+	"api-gateway/config"
+	"api-gateway/model"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/ratelimit"
+	"api-gateway/pkg/routescope"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// QuotaUsage is the usage of a quota in its current window
+type QuotaUsage struct {
+	Name        string    `json:"name"`
+	Period      string    `json:"period"`
+	Routes      []string  `json:"routes,omitempty"`
+	Mode        string    `json:"mode"`
+	Limit       int       `json:"limit"`
+	Used        int       `json:"used"`
+	Remaining   int       `json:"remaining"`
+	WindowStart time.Time `json:"window_start"`
+	ResetAt     time.Time `json:"reset_at"`
+}
+
+// QuotaResult is the result of counting a call against the quotas of a client
+type QuotaResult struct {
+	Usage    []*QuotaUsage  // 计入后各配额的用量
+	Exceeded *QuotaUsage    // 超限的配额，为空时放行
+	Shadowed []*QuotaUsage  // shadow 模式下超限的配额，不影响结果
+	consumed []quotaCounter // 已计入的计数，请求失败时由 Release 退回
+}
+
+// QuotaService checks calendar window call quotas.
+// 配额窗口按配置的时区和重置时间计算，计数存储在 Redis 或 MongoDB
+type QuotaService struct {
+	repo        repository.QuotaRepository
+	location    *time.Location
+	resetHour   int
+	resetMinute int
+	defaults    []model.Quota
+}
+
+// NewQuotaService creates a quota service, returns an error if the config is invalid
+func NewQuotaService(repo repository.QuotaRepository, cfg config.QuotaConfig) (*QuotaService, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota timezone: %w", err)
+		}
+		location = loc
+	}
+
+	var resetHour, resetMinute int
+	if cfg.ResetTime != "" {
+		reset, err := time.Parse("15:04", cfg.ResetTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota reset time %q: expected HH:MM", cfg.ResetTime)
+		}
+		resetHour, resetMinute = reset.Hour(), reset.Minute()
+	}
+
+	defaults := make([]model.Quota, 0, len(cfg.Quotas))
+	for _, rule := range cfg.Quotas {
+		defaults = append(defaults, model.Quota{
+			Name:   rule.Name,
+			Period: rule.Period,
+			Limit:  rule.Limit,
+			Routes: rule.Routes,
+			Mode:   rule.Mode,
+		})
+	}
+	if err := model.ValidateQuotas(defaults); err != nil {
+		return nil, err
+	}
+
+	return &QuotaService{
+		repo:        repo,
+		location:    location,
+		resetHour:   resetHour,
+		resetMinute: resetMinute,
+		defaults:    defaults,
+	}, nil
+}
+
+// QuotasFor returns the effective quotas of a client, client quotas override defaults with the same name
+func (s *QuotaService) QuotasFor(client *model.Client) []model.Quota {
+	quotas := make([]model.Quota, 0, len(s.defaults)+len(client.Quotas))
+	overridden := make(map[string]bool, len(client.Quotas))
+	for _, quota := range client.Quotas {
+		overridden[quota.Name] = true
+	}
+
+	for _, quota := range s.defaults {
+		if !overridden[quota.Name] {
+			quotas = append(quotas, quota)
+		}
+	}
+	for _, quota := range client.Quotas {
+		if !quota.Unlimited {
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas
+}
+
+// Consume counts a call against every quota of the client that applies to the route.
+// 任一 enforce 配额超限时，已计入其他配额的调用会被退回；shadow 配额超限只记录在结果中，off 配额不计数
+func (s *QuotaService) Consume(ctx context.Context, client *model.Client, route string) (*QuotaResult, error) {
+	now := time.Now()
+
+	var done []quotaCounter
+	result := &QuotaResult{}
+
+	for _, quota := range s.QuotasFor(client) {
+		if quota.Mode == ratelimit.ModeOff || (len(quota.Routes) > 0 && !routescope.MatchAny(quota.Routes, route)) {
+			continue
+		}
+
+		start, end := s.Window(quota.Period, now)
+		key := quotaKey(client, quota, start)
+		used, ok, err := s.repo.Consume(ctx, key, 1, quota.Limit, end.Add(time.Hour))
+		if err != nil {
+			s.release(ctx, done)
+			return nil, err
+		}
+		if !ok && quota.Mode == ratelimit.ModeShadow {
+			result.Shadowed = append(result.Shadowed, newQuotaUsage(quota, used, start, end))
+			continue
+		}
+		if !ok {
+			s.release(ctx, done)
+			return &QuotaResult{Exceeded: newQuotaUsage(quota, used, start, end), Shadowed: result.Shadowed}, nil
+		}
+		done = append(done, quotaCounter{key: key, cost: 1})
+		result.Usage = append(result.Usage, newQuotaUsage(quota, used, start, end))
+	}
+
+	result.consumed = done
+	return result, nil
+}
+
+// Release returns the calls counted by Consume, used when the request fails after passing the quotas
+func (s *QuotaService) Release(ctx context.Context, result *QuotaResult) {
+	if result == nil {
+		return
+	}
+	s.release(ctx, result.consumed)
+	result.consumed = nil
+}
+
+// Reset clears the counters of every quota of the client in the current window
+func (s *QuotaService) Reset(ctx context.Context, client *model.Client) error {
+	now := time.Now()
+	quotas := s.QuotasFor(client)
+
+	keys := make([]string, len(quotas))
+	for i, quota := range quotas {
+		start, _ := s.Window(quota.Period, now)
+		keys[i] = quotaKey(client, quota, start)
+	}
+	return s.repo.Delete(ctx, keys)
+}
+
+// Usage returns the usage of every quota of the client in the current window
+func (s *QuotaService) Usage(ctx context.Context, client *model.Client) ([]*QuotaUsage, error) {
+	now := time.Now()
+	quotas := s.QuotasFor(client)
+
+	keys := make([]string, len(quotas))
+	windows := make([][2]time.Time, len(quotas))
+	for i, quota := range quotas {
+		start, end := s.Window(quota.Period, now)
+		keys[i] = quotaKey(client, quota, start)
+		windows[i] = [2]time.Time{start, end}
+	}
+
+	counts, err := s.repo.Get(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]*QuotaUsage, len(quotas))
+	for i, quota := range quotas {
+		usage[i] = newQuotaUsage(quota, counts[keys[i]], windows[i][0], windows[i][1])
+	}
+	return usage, nil
+}
+
+// Window returns the start and end of the quota window containing now
+func (s *QuotaService) Window(period string, now time.Time) (time.Time, time.Time) {
+	local := now.In(s.location)
+	reset := time.Duration(s.resetHour)*time.Hour + time.Duration(s.resetMinute)*time.Minute
+	// 重置时间之前仍属于上一个窗口
+	shifted := local.Add(-reset)
+
+	if period == model.QuotaPeriodMonth {
+		start := time.Date(shifted.Year(), shifted.Month(), 1, s.resetHour, s.resetMinute, 0, 0, s.location)
+		end := time.Date(shifted.Year(), shifted.Month()+1, 1, s.resetHour, s.resetMinute, 0, 0, s.location)
+		return start, end
+	}
+
+	start := time.Date(shifted.Year(), shifted.Month(), shifted.Day(), s.resetHour, s.resetMinute, 0, 0, s.location)
+	end := time.Date(shifted.Year(), shifted.Month(), shifted.Day()+1, s.resetHour, s.resetMinute, 0, 0, s.location)
+	return start, end
+}
+
+// release 退回已计入的调用
+func (s *QuotaService) release(ctx context.Context, done []quotaCounter) {
+	for _, item := range done {
+		if err := s.repo.Release(ctx, item.key, item.cost); err != nil {
+			logger.Errorf("Failed to release quota %s: %v", item.key, err)
+		}
+	}
+}
+
+// quotaCounter 已计入的配额计数
+type quotaCounter struct {
+	key  string
+	cost int
+}
+
+// quotaKey 配额计数键：客户ID:配额名:窗口开始日期，月配额为窗口开始月份
+func quotaKey(client *model.Client, quota model.Quota, start time.Time) string {
+	if quota.Period == model.QuotaPeriodMonth {
+		return client.ID.Hex() + ":" + quota.Name + ":" + start.Format("200601")
+	}
+	return client.ID.Hex() + ":" + quota.Name + ":" + start.Format("20060102")
+}
+
+func newQuotaUsage(quota model.Quota, used int, start, end time.Time) *QuotaUsage {
+	remaining := quota.Limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &QuotaUsage{
+		Name:        quota.Name,
+		Period:      quota.Period,
+		Routes:      quota.Routes,
+		Mode:        quotaMode(quota),
+		Limit:       quota.Limit,
+		Used:        used,
+		Remaining:   remaining,
+		WindowStart: start,
+		ResetAt:     end,
+	}
+}
+
+func quotaMode(quota model.Quota) string {
+	if quota.Mode == "" {
+		return ratelimit.ModeEnforce
+	}
+	return quota.Mode
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"api-gateway/model"
+	"api-gateway/pkg/routescope"
+	"api-gateway/repository"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScopeService provides business logic for client route scopes
+type ScopeService struct {
+	clientRepo repository.ClientRepository
+	catalog    *routescope.Catalog
+}
+
+// NewScopeService creates a new scope service
+func NewScopeService(clientRepo repository.ClientRepository, catalog *routescope.Catalog) *ScopeService {
+	return &ScopeService{
+		clientRepo: clientRepo,
+		catalog:    catalog,
+	}
+}
+
+// GrantScopes grants products or route patterns to a client
+func (s *ScopeService) GrantScopes(ctx context.Context, clientID primitive.ObjectID, scopes []string) (*model.Client, error) {
+	if err := s.validate(scopes); err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.AddScopes(ctx, clientID, scopes); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, clientID)
+}
+
+// RevokeScopes revokes products or route patterns from a client
+func (s *ScopeService) RevokeScopes(ctx context.Context, clientID primitive.ObjectID, scopes []string) (*model.Client, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("invalid scope: empty list")
+	}
+
+	if err := s.clientRepo.RemoveScopes(ctx, clientID, scopes); err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByID(ctx, clientID)
+}
+
+// validate checks that all scopes are known products or valid route patterns
+func (s *ScopeService) validate(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("invalid scope: empty list")
+	}
+	for _, scope := range scopes {
+		if err := s.catalog.Validate(scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}